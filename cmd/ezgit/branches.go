@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// branchMgr is the branch manager screen: local branches with upstream,
+// ahead/behind, age and merged/gone state, and the actions on them. Every
+// change goes through launch, so policy and typed confirmation apply.
+type branchMgr struct {
+	items  []repo.Branch
+	base   string
+	cursor int
+	// editing is "rename" or "upstream" while the input is open.
+	editing string
+	input   textinput.Model
+	// renameTo is set while asking whether to rename on the remote too.
+	renameTo string
+	// cleanup is the checked state of the cleanup candidates while the
+	// cleanup preview is open.
+	cleanup map[string]bool
+	err     string
+}
+
+type branchesLoadedMsg struct {
+	Items []repo.Branch
+	Base  string
+	Err   error
+}
+
+func loadBranchesCmd(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		base := repo.DefaultBranch(ctx)
+		items, err := repo.Branches(ctx, base)
+		return branchesLoadedMsg{Items: items, Base: base, Err: err}
+	}
+}
+
+func (m *model) openBranches() tea.Cmd {
+	b := &branchMgr{input: textinput.New()}
+	b.input.Prompt = "> "
+	m.branches = b
+	m.mode = "branches"
+	return loadBranchesCmd(m.ctx())
+}
+
+func (b *branchMgr) selected() (repo.Branch, bool) {
+	if b.cursor < 0 || b.cursor >= len(b.items) {
+		return repo.Branch{}, false
+	}
+	return b.items[b.cursor], true
+}
+
+// candidates are the branches the cleanup offers: merged or upstream gone,
+// never the current or the default branch.
+func (b *branchMgr) candidates() []repo.Branch {
+	var out []repo.Branch
+	for _, it := range b.items {
+		if it.Stale() && !it.Current && it.Name != b.base {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// deleteSteps backs each branch up under refs/ezgit/backup/branches/ and
+// then deletes them all in one go.
+func deleteSteps(names []string) []action.Step {
+	stamp := time.Now().Format("20060102-150405")
+	var steps []action.Step
+	for _, n := range names {
+		steps = append(steps, action.Step{Cmd: "git", Args: []string{"update-ref", "-m", "ezgit: backup before deleting " + n, "refs/ezgit/backup/branches/" + n + "-" + stamp, "refs/heads/" + n}})
+	}
+	return append(steps, action.Step{Cmd: "git", Args: append([]string{"branch", "-D"}, names...)})
+}
+
+// runSteps launches steps one after another, returning to the branch
+// manager after each.
+func (m *model) runSteps(steps []action.Step) (tea.Model, tea.Cmd) {
+	if len(steps) == 0 {
+		return m, nil
+	}
+	m.queue, m.undo = steps[1:], steps[0].Undo
+	m.afterRun = m.mode
+	return m.launch(steps[0].Cmd, steps[0].Args, false)
+}
+
+func (m *model) updateBranches(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.branches
+	k := msg.String()
+	b.err = ""
+
+	if b.renameTo != "" {
+		cur, _ := b.selected()
+		to := b.renameTo
+		b.renameTo = ""
+		switch k {
+		case "y", "Y":
+			remote := repo.BranchRemote(m.ctx(), cur.Name)
+			old := strings.TrimPrefix(cur.Upstream, remote+"/")
+			return m.runSteps([]action.Step{
+				{Cmd: "git", Args: []string{"branch", "-m", cur.Name, to}},
+				{Cmd: "git", Args: []string{"push", "--set-upstream", remote, to}},
+				{Cmd: "git", Args: []string{"push", remote, "--delete", old}},
+			})
+		case "n", "N", "enter":
+			return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"branch", "-m", cur.Name, to}}})
+		}
+		return m, nil
+	}
+
+	if b.editing != "" {
+		switch k {
+		case "esc":
+			b.editing = ""
+			b.input.Blur()
+			return m, nil
+		case "enter":
+			cur, _ := b.selected()
+			v := strings.TrimSpace(b.input.Value())
+			what := b.editing
+			b.editing = ""
+			b.input.Blur()
+			if what == "rename" {
+				if v == "" || v == cur.Name {
+					return m, nil
+				}
+				if _, err := repo.Git(m.ctx(), "check-ref-format", "--branch", v); err != nil {
+					b.err = fmt.Sprintf("%q is not a valid branch name", v)
+					return m, nil
+				}
+				if cur.Upstream != "" && !cur.Gone {
+					b.renameTo = v
+					return m, nil
+				}
+				return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"branch", "-m", cur.Name, v}}})
+			}
+			if v == "" {
+				return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"branch", "--unset-upstream", cur.Name}}})
+			}
+			return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"branch", "--set-upstream-to=" + v, cur.Name}}})
+		}
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		return m, cmd
+	}
+
+	if b.cleanup != nil {
+		cands := b.candidates()
+		switch k {
+		case "esc":
+			b.cleanup = nil
+		case "up", "k":
+			if b.cursor > 0 {
+				b.cursor--
+			}
+		case "down", "j":
+			if b.cursor < len(cands)-1 {
+				b.cursor++
+			}
+		case " ", "x":
+			if b.cursor < len(cands) {
+				n := cands[b.cursor].Name
+				b.cleanup[n] = !b.cleanup[n]
+			}
+		case "enter":
+			var names []string
+			for _, c := range cands {
+				if b.cleanup[c.Name] {
+					names = append(names, c.Name)
+				}
+			}
+			if len(names) == 0 {
+				b.err = "nothing selected"
+				return m, nil
+			}
+			b.cleanup = nil
+			b.cursor = 0
+			return m.runSteps(deleteSteps(names))
+		}
+		return m, nil
+	}
+
+	cur, ok := b.selected()
+	switch k {
+	case "esc":
+		m.branches = nil
+		m.mode = "verbs"
+		return m, nil
+	case "up", "k":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+		return m, nil
+	case "down", "j":
+		if b.cursor < len(b.items)-1 {
+			b.cursor++
+		}
+		return m, nil
+	case "R":
+		return m, loadBranchesCmd(m.ctx())
+	case "c":
+		cands := b.candidates()
+		if len(cands) == 0 {
+			b.err = "no merged or gone branches to clean up"
+			return m, nil
+		}
+		b.cleanup = map[string]bool{}
+		for _, c := range cands {
+			b.cleanup[c.Name] = true
+		}
+		b.cursor = 0
+		return m, nil
+	}
+	if !ok {
+		return m, nil
+	}
+	switch k {
+	case "enter", "s":
+		if cur.Current {
+			return m, nil
+		}
+		if a, ok := m.actions.Get("switch"); ok && repo.Dirty(m.ctx()) {
+			// let the switch action ask what to do with the changes
+			m.branches = nil
+			m.currentAction = a
+			m.wizardInputs = action.ActionInput{"branch": cur.Name}
+			m.promptIndex = 1
+			m.mode = "wizard"
+			return m, m.beginPrompt()
+		}
+		return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"switch", cur.Name}}})
+	case "r":
+		b.editing = "rename"
+		b.input.SetValue(cur.Name)
+		b.input.CursorEnd()
+		return m, b.input.Focus()
+	case "u":
+		b.editing = "upstream"
+		up := cur.Upstream
+		if up == "" {
+			up = "origin/" + cur.Name
+		}
+		b.input.SetValue(up)
+		b.input.CursorEnd()
+		return m, b.input.Focus()
+	case "d":
+		if cur.Current {
+			b.err = "can't delete the branch you are on"
+			return m, nil
+		}
+		if cur.Merged {
+			return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"branch", "-d", cur.Name}}})
+		}
+		return m.runSteps(deleteSteps([]string{cur.Name}))
+	}
+	return m, nil
+}
+
+func (m model) renderBranches() string {
+	b := m.branches
+	if b == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	state := func(it repo.Branch) string {
+		var tags []string
+		if it.Merged && it.Name != b.base {
+			tags = append(tags, green.Render("merged"))
+		}
+		if it.Gone {
+			tags = append(tags, red.Render("gone"))
+		}
+		return strings.Join(tags, " ")
+	}
+	track := func(it repo.Branch) string {
+		switch {
+		case it.Upstream == "":
+			return dim.Render("(no upstream)")
+		case it.Gone:
+			return it.Upstream
+		}
+		s := it.Upstream
+		if it.Ahead > 0 {
+			s += green.Render(fmt.Sprintf(" ↑%d", it.Ahead))
+		}
+		if it.Behind > 0 {
+			s += red.Render(fmt.Sprintf(" ↓%d", it.Behind))
+		}
+		return s
+	}
+
+	var lines []string
+	if b.cleanup != nil {
+		cands := b.candidates()
+		lines = append(lines, bold.Render("Clean up merged / gone branches"))
+		var names []string
+		for i, c := range cands {
+			box := "[ ]"
+			if b.cleanup[c.Name] {
+				box = "[x]"
+				names = append(names, c.Name)
+			}
+			line := fmt.Sprintf("%s %-28s %s %s", box, c.Name, state(c), dim.Render(c.Date))
+			if i == b.cursor {
+				line = m.activeStyle.Render("> ") + line
+			} else {
+				line = "  " + line
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, "", bold.Render("Will run"))
+		if len(names) > 0 {
+			for _, s := range deleteSteps(names) {
+				lines = append(lines, "git "+strings.Join(s.Args, " "))
+			}
+		}
+		lines = append(lines, "", dim.Render("[space] toggle • [enter] back up and delete • [esc] back"))
+	} else {
+		title := "Branches"
+		if b.base != "" {
+			title += dim.Render(" (merged = merged into " + b.base + ")")
+		}
+		lines = append(lines, bold.Render(title))
+		if len(b.items) == 0 {
+			lines = append(lines, dim.Render("(loading branches...)"))
+		}
+		for i, it := range b.items {
+			mark := " "
+			if it.Current {
+				mark = "*"
+			}
+			line := fmt.Sprintf("%s %-24s %-28s %-16s %-16s %s", mark, it.Name, track(it), it.Date, it.Author, state(it))
+			if i == b.cursor {
+				line = m.activeStyle.Render("> ") + line
+			} else {
+				line = "  " + line
+			}
+			lines = append(lines, line)
+		}
+		if cur, ok := b.selected(); ok {
+			lines = append(lines, "", dim.Render(cur.Subject))
+		}
+		switch {
+		case b.editing == "rename":
+			lines = append(lines, "", "Rename to:", b.input.View(), dim.Render("[enter] rename • [esc] cancel"))
+		case b.editing == "upstream":
+			lines = append(lines, "", "Upstream (empty to unset):", b.input.View(), dim.Render("[enter] set • [esc] cancel"))
+		case b.renameTo != "":
+			lines = append(lines, "", warn.Render("Also rename it on the remote (push "+b.renameTo+", delete the old name)? [y/N]"))
+		default:
+			lines = append(lines, "", dim.Render("[enter/s] switch • [r] rename • [u] upstream • [d] delete • [c] clean up • [R] refresh • [esc] back"))
+		}
+	}
+	if b.err != "" {
+		lines = append(lines, red.Render("! "+b.err))
+	}
+	return strings.Join(lines, "\n")
+}
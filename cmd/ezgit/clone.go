@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// enterRepo makes dir the working repository, as after a clone.
+func (m *model) enterRepo(dir string) {
+	if err := os.Chdir(dir); err != nil {
+		m.statusLines = append(m.statusLines, "[could not switch to "+dir+": "+err.Error()+"]")
+		return
+	}
+	abs, _ := os.Getwd()
+	m.statusLines = append(m.statusLines, "[now working in "+filepath.Clean(abs)+"]")
+	if err := m.loadPolicy(); err != nil {
+		m.statusLines = append(m.statusLines, "[policy: "+err.Error()+"]")
+	}
+	m.watchHead()
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"ezgit/internal/commitmsg"
+	"ezgit/internal/config"
+	"ezgit/internal/repo"
+	"ezgit/internal/tui"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	focusType = iota
+	focusScope
+	focusSubject
+	focusBody
+	focusCount
+)
+
+// composer is the commit screen: conventional type/scope, subject, a
+// multi-line body and trailers, linted before "git commit -F".
+type composer struct {
+	types    []string
+	typeIdx  int
+	scope    textinput.Model
+	subject  textinput.Model
+	body     textarea.Model
+	breaking bool
+	all      bool
+	trailers []commitmsg.Trailer
+	focus    int
+	rules    config.CommitLint
+	identity string
+	issues   []commitmsg.Issue
+	// warned is set once warnings have been shown; committing again
+	// accepts them.
+	warned  bool
+	authors *tui.OptionList
+	err     string
+}
+
+func (m *model) openComposer() tea.Cmd {
+	ctx := m.ctx()
+	c := &composer{focus: focusSubject}
+	if cfg, err := config.Load(config.Path()); err == nil {
+		c.rules = cfg.CommitLint
+	}
+	c.types = append([]string{""}, commitmsg.ConventionalTypes...)
+	if len(c.rules.Types) > 0 {
+		c.types = append([]string{""}, c.rules.Types...)
+	}
+
+	c.scope = textinput.New()
+	c.scope.Prompt = ""
+	c.scope.Placeholder = "scope (optional)"
+	c.scope.Width = 20
+	c.subject = textinput.New()
+	c.subject.Prompt = ""
+	c.subject.Placeholder = "Summarize the change in the imperative mood"
+	c.subject.Width = commitmsg.Width
+	c.body = textarea.New()
+	c.body.Placeholder = "Why is this change needed? What does it do?"
+	c.body.ShowLineNumbers = false
+	c.body.CharLimit = 0
+	c.body.SetWidth(c.bodyWidth() + 2)
+	c.body.SetHeight(8)
+
+	name, _ := repo.Git(ctx, "config", "user.name")
+	email, _ := repo.Git(ctx, "config", "user.email")
+	if name != "" && email != "" {
+		c.identity = name + " <" + email + ">"
+	}
+	if tmpl, err := repo.Git(ctx, "config", "--path", "commit.template"); err == nil && tmpl != "" {
+		top, _ := repo.Toplevel(ctx)
+		if text, err := commitmsg.ReadTemplate(tmpl, top); err == nil {
+			subject, body := commitmsg.FromTemplate(text)
+			c.subject.SetValue(subject)
+			c.body.SetValue(body)
+		} else {
+			c.err = "commit.template: " + err.Error()
+		}
+	}
+
+	m.composer = c
+	m.mode = "commit"
+	return c.setFocus(focusSubject)
+}
+
+func (c *composer) setFocus(f int) tea.Cmd {
+	c.focus = (f + focusCount) % focusCount
+	c.scope.Blur()
+	c.subject.Blur()
+	c.body.Blur()
+	switch c.focus {
+	case focusScope:
+		return c.scope.Focus()
+	case focusSubject:
+		return c.subject.Focus()
+	case focusBody:
+		return c.body.Focus()
+	}
+	return nil
+}
+
+func (c *composer) message() commitmsg.Message {
+	return commitmsg.Message{
+		Type:      c.types[c.typeIdx],
+		Scope:     c.scope.Value(),
+		Breaking:  c.breaking,
+		Subject:   c.subject.Value(),
+		Body:      c.body.Value(),
+		Trailers:  c.trailers,
+		BodyWidth: c.bodyWidth(),
+	}
+}
+
+// bodyWidth is where the body is wrapped: the configured width, else
+// commitmsg.Width.
+func (c *composer) bodyWidth() int {
+	if c.rules.BodyWidth > 0 {
+		return c.rules.BodyWidth
+	}
+	return commitmsg.Width
+}
+
+func (c *composer) toggleTrailer(t commitmsg.Trailer) {
+	for i, x := range c.trailers {
+		if x == t {
+			c.trailers = append(c.trailers[:i], c.trailers[i+1:]...)
+			return
+		}
+	}
+	c.trailers = append(c.trailers, t)
+}
+
+func (m *model) updateComposer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.composer
+	k := msg.String()
+
+	if c.authors != nil {
+		switch k {
+		case "enter":
+			if sel := c.authors.Selected(); len(sel) > 0 {
+				t := commitmsg.Trailer{Key: "Co-authored-by", Value: sel[0]}
+				if !c.message().HasTrailer(t) {
+					c.trailers = append(c.trailers, t)
+				}
+			}
+			c.authors = nil
+		case "esc":
+			c.authors = nil
+		default:
+			c.authors.Update(msg)
+		}
+		return m, nil
+	}
+
+	switch k {
+	case "esc":
+		m.composer = nil
+		m.mode = "verbs"
+		return m, nil
+	case "tab":
+		return m, c.setFocus(c.focus + 1)
+	case "shift+tab":
+		return m, c.setFocus(c.focus - 1)
+	case "ctrl+s":
+		return m.commitFromComposer()
+	case "ctrl+a":
+		c.all = !c.all
+		return m, nil
+	case "ctrl+g":
+		if c.identity == "" {
+			c.err = "set user.name and user.email to sign off"
+			return m, nil
+		}
+		c.toggleTrailer(commitmsg.Trailer{Key: "Signed-off-by", Value: c.identity})
+		return m, nil
+	case "ctrl+o":
+		authors := recentAuthors(m.ctx(), c.identity)
+		if len(authors) == 0 {
+			c.err = "no other authors in this repository's history"
+			return m, nil
+		}
+		c.authors = tui.NewOptionList(authors, false, "")
+		return m, nil
+	case "ctrl+k":
+		if n := len(c.trailers); n > 0 {
+			c.trailers = c.trailers[:n-1]
+		}
+		return m, nil
+	case "ctrl+w":
+		c.body.SetValue(commitmsg.Wrap(c.body.Value(), c.bodyWidth()))
+		return m, nil
+	}
+	c.warned = false
+
+	var cmd tea.Cmd
+	switch c.focus {
+	case focusType:
+		switch k {
+		case "left", "h":
+			c.typeIdx = (c.typeIdx + len(c.types) - 1) % len(c.types)
+		case "right", "l", " ":
+			c.typeIdx = (c.typeIdx + 1) % len(c.types)
+		case "!":
+			c.breaking = !c.breaking
+		case "enter":
+			return m, c.setFocus(focusSubject)
+		}
+	case focusScope:
+		if k == "enter" {
+			return m, c.setFocus(focusSubject)
+		}
+		c.scope, cmd = c.scope.Update(msg)
+	case focusSubject:
+		if k == "enter" {
+			return m, c.setFocus(focusBody)
+		}
+		c.subject, cmd = c.subject.Update(msg)
+	case focusBody:
+		c.body, cmd = c.body.Update(msg)
+	}
+	return m, cmd
+}
+
+// commitFromComposer lints the message and, if nothing blocks, writes it
+// next to git's own COMMIT_EDITMSG and commits with -F; the file goes once
+// the commit finished. Warnings are shown once and accepted by committing
+// again.
+func (m *model) commitFromComposer() (tea.Model, tea.Cmd) {
+	c := m.composer
+	msg := c.message()
+	c.issues = commitmsg.Lint(msg, c.rules)
+	c.err = ""
+	if commitmsg.Blocking(c.issues) {
+		c.warned = false
+		return m, nil
+	}
+	if len(c.issues) > 0 && !c.warned {
+		c.warned = true
+		return m, nil
+	}
+	ctx := m.ctx()
+	path, err := repo.Git(ctx, "rev-parse", "--git-path", "EZGIT_COMMIT_EDITMSG")
+	if err != nil {
+		c.err = err.Error()
+		return m, nil
+	}
+	if err := os.WriteFile(path, []byte(msg.String()), 0o644); err != nil {
+		c.err = err.Error()
+		return m, nil
+	}
+	args := []string{"commit"}
+	if c.all {
+		args = append(args, "-a")
+	}
+	args = append(args, "-F", path)
+	m.removeAfter = path
+	m.afterRun = "verbs"
+	m.composer = nil
+	return m.launch("git", args, false)
+}
+
+// recentAuthors lists the distinct authors of recent history, most recent
+// first, leaving out the committer themselves.
+func recentAuthors(ctx context.Context, self string) []string {
+	out, err := repo.Git(ctx, "log", "-n", "500", "--format=%aN <%aE>")
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{self: true}
+	var authors []string
+	for _, a := range repo.Lines(out) {
+		if !seen[a] {
+			seen[a] = true
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
+
+func (m model) renderComposer() string {
+	c := m.composer
+	if c == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	marker := func(f int) string {
+		if c.focus == f {
+			return m.activeStyle.Render(">")
+		}
+		return " "
+	}
+
+	msg := c.message()
+	max, warn := c.rules.SubjectMax, c.rules.SubjectWarn
+	if max == 0 {
+		max = commitmsg.Width
+	}
+	if warn == 0 {
+		warn = 50
+	}
+	n := utf8.RuneCountInString(msg.Header())
+	counterStyle := okStyle
+	switch {
+	case n > max:
+		counterStyle = errStyle
+	case n > warn:
+		counterStyle = warnStyle
+	}
+
+	typ := c.types[c.typeIdx]
+	if typ == "" {
+		typ = "(none)"
+	}
+	if c.breaking {
+		typ += " !breaking"
+	}
+	lines := []string{
+		bold.Render("Commit"),
+		marker(focusType) + " Type:  ‹ " + typ + " ›",
+		marker(focusScope) + " Scope: " + c.scope.View(),
+		marker(focusSubject) + " Subject " + counterStyle.Render(fmt.Sprintf("%d/%d", n, max)),
+		"  " + c.subject.View(),
+		marker(focusBody) + " Body (wrapped at 72 on commit)",
+		c.body.View(),
+	}
+	if len(c.trailers) > 0 {
+		lines = append(lines, bold.Render("Trailers"))
+		for _, t := range c.trailers {
+			lines = append(lines, "  "+t.String())
+		}
+	}
+	if c.all {
+		lines = append(lines, okStyle.Render("[x] commit all tracked changes (-a)"))
+	}
+	if c.authors != nil {
+		lines = append(lines, bold.Render("Co-author"), c.authors.View(), dim.Render("[enter] add • [esc] cancel"))
+	}
+	for _, i := range c.issues {
+		st := warnStyle
+		if i.Error {
+			st = errStyle
+		}
+		lines = append(lines, st.Render(i.String()))
+	}
+	if c.warned {
+		lines = append(lines, warnStyle.Render("press ctrl+s again to commit anyway"))
+	}
+	if c.err != "" {
+		lines = append(lines, errStyle.Render(c.err))
+	}
+	lines = append(lines,
+		"",
+		bold.Render("Message"),
+		strings.TrimRight(msg.String(), "\n"),
+		"",
+		dim.Render("[tab] next field • [←/→] type • [!] breaking • [ctrl+s] commit • [esc] cancel"),
+		dim.Render("[ctrl+o] co-author • [ctrl+g] sign off • [ctrl+k] drop trailer • [ctrl+a] all tracked • [ctrl+w] rewrap"),
+	)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	execpkg "ezgit/internal/exec"
+)
+
+func TestComposerRemovesItsMessageFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	gitT(t, "init", "-q", "-b", "main")
+	gitT(t, "config", "user.name", "Ada")
+	gitT(t, "config", "user.email", "ada@example.com")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	gitT(t, "add", "a.txt")
+
+	m := testModel(&execpkg.Runner{})
+	m.openComposer()
+	m.composer.subject.SetValue("Add a")
+	m.commitFromComposer()
+	finishRun(t, m)
+	if got := gitOut(t, "log", "--format=%s"); got != "Add a" {
+		t.Fatalf("log = %q", got)
+	}
+	if _, err := os.Stat(gitOut(t, "rev-parse", "--git-path", "EZGIT_COMMIT_EDITMSG")); !os.IsNotExist(err) {
+		t.Errorf("message file left behind: %v", err)
+	}
+}
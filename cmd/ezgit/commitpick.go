@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"ezgit/internal/tui"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitPick is the "pick a commit" step of the history screens: a filter
+// line over a fuzzy list of recent commits. Its refs arrive as a
+// refsLoadedMsg with Prompt -1.
+type commitPick struct {
+	filter textinput.Model
+	picker *tui.RefPicker
+}
+
+func newCommitPick(ctx context.Context) (*commitPick, tea.Cmd) {
+	p := &commitPick{filter: textinput.New(), picker: tui.NewRefPicker(nil)}
+	p.filter.Prompt = "> "
+	p.filter.Placeholder = "filter commits"
+	return p, tea.Batch(p.filter.Focus(), func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return refsLoadedMsg{Prompt: -1, Items: tui.LoadRefs(ctx, tui.RefCommit)}
+	})
+}
+
+// update feeds a key to the list or the filter and reports whether the
+// highlighted commit may have changed.
+func (p *commitPick) update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if p.picker.Update(msg) {
+		return true, nil
+	}
+	var cmd tea.Cmd
+	before := p.filter.Value()
+	p.filter, cmd = p.filter.Update(msg)
+	if p.filter.Value() == before {
+		return false, cmd
+	}
+	p.picker.SetFilter(p.filter.Value())
+	return true, cmd
+}
+
+func (p *commitPick) choice() string {
+	return p.picker.Choice(p.filter.Value())
+}
+
+func (p *commitPick) view() string {
+	return p.filter.View() + "\n" + p.picker.View()
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// conflictScreen walks through the unmerged files of a stopped rebase or
+// merge: keep one side, edit, mark resolved, then continue or abort. It
+// closes by itself once nothing is in progress any more.
+type conflictScreen struct {
+	// kind is "rebase" or "merge".
+	kind  string
+	files []string
+	top   string
+	// back is the screen to return to; sync picks up from there.
+	back    string
+	cursor  int
+	aborted bool
+	err     string
+}
+
+type conflictsLoadedMsg struct {
+	Kind  string
+	Files []string
+	Top   string
+}
+
+func loadConflictsCmd(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		msg := conflictsLoadedMsg{Files: repo.Conflicts(ctx)}
+		msg.Top, _ = repo.Toplevel(ctx)
+		switch {
+		case repo.RebaseInProgress(ctx):
+			msg.Kind = "rebase"
+		case repo.MergeInProgress(ctx):
+			msg.Kind = "merge"
+		}
+		return msg
+	}
+}
+
+func (m *model) openConflicts(back string) tea.Cmd {
+	m.conflicts = &conflictScreen{back: back}
+	m.mode = "conflicts"
+	return loadConflictsCmd(m.ctx())
+}
+
+// sides returns the checkout flags for "mine" and "theirs". During a
+// rebase git's "ours" is the upstream being rebased onto.
+func (c *conflictScreen) sides() (mine, theirs string) {
+	if c.kind == "rebase" {
+		return "--theirs", "--ours"
+	}
+	return "--ours", "--theirs"
+}
+
+func (m *model) handleConflicts(msg conflictsLoadedMsg) (tea.Model, tea.Cmd) {
+	c := m.conflicts
+	if c == nil {
+		return m, nil
+	}
+	if msg.Kind == "" {
+		return m.closeConflicts()
+	}
+	c.kind, c.files, c.top = msg.Kind, msg.Files, msg.Top
+	if c.cursor >= len(c.files) {
+		c.cursor = max(0, len(c.files)-1)
+	}
+	return m, nil
+}
+
+func (m *model) closeConflicts() (tea.Model, tea.Cmd) {
+	c := m.conflicts
+	m.conflicts = nil
+	m.mode = c.back
+	if c.back == "sync" && m.sync != nil {
+		return m.syncAfterConflicts(c.aborted)
+	}
+	if c.aborted {
+		m.statusLines = append(m.statusLines, "[aborted; back where you were before the "+c.kind+"]")
+	} else if c.kind != "" {
+		m.statusLines = append(m.statusLines, "[conflicts resolved; "+c.kind+" finished]")
+	}
+	return m, nil
+}
+
+func (m *model) updateConflicts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.conflicts
+	k := msg.String()
+	c.err = ""
+	git := func(args ...string) action.Step { return action.Step{Cmd: "git", Args: args} }
+	switch k {
+	case "esc":
+		c.kind = ""
+		return m.closeConflicts()
+	case "up", "k":
+		if c.cursor > 0 {
+			c.cursor--
+		}
+		return m, nil
+	case "down", "j":
+		if c.cursor < len(c.files)-1 {
+			c.cursor++
+		}
+		return m, nil
+	case "R":
+		return m, loadConflictsCmd(m.ctx())
+	case "c":
+		if n := len(c.files); n > 0 {
+			c.err = fmt.Sprintf("resolve %d file(s) first", n)
+			return m, nil
+		}
+		if c.kind == "rebase" {
+			return m.runSteps([]action.Step{git("-c", "core.editor=true", "rebase", "--continue")})
+		}
+		return m.runSteps([]action.Step{git("commit", "--no-edit")})
+	case "x":
+		c.aborted = true
+		return m.runSteps([]action.Step{git(c.kind, "--abort")})
+	}
+	if c.cursor >= len(c.files) {
+		return m, nil
+	}
+	f := c.files[c.cursor]
+	spec := ":(top)" + f
+	mine, theirs := c.sides()
+	switch k {
+	case "m":
+		return m.runSteps([]action.Step{git("checkout", mine, "--", spec), git("add", "--", spec)})
+	case "t":
+		return m.runSteps([]action.Step{git("checkout", theirs, "--", spec), git("add", "--", spec)})
+	case "a":
+		if repo.HasConflictMarkers(filepath.Join(c.top, f)) {
+			c.err = f + " still has conflict markers; edit it first"
+			return m, nil
+		}
+		return m.runSteps([]action.Step{git("add", "--", spec)})
+	case "e":
+		editor, err := repo.Git(m.ctx(), "var", "GIT_EDITOR")
+		if err != nil {
+			c.err = err.Error()
+			return m, nil
+		}
+		m.pendingTerminal = true
+		return m.runSteps([]action.Step{{Cmd: "sh", Args: []string{"-c", editor + ` "$1"`, "sh", filepath.Join(c.top, f)}}})
+	}
+	return m, nil
+}
+
+func (m model) renderConflicts() string {
+	c := m.conflicts
+	if c == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+
+	lines := []string{bold.Render("Conflicts")}
+	if c.kind == "" {
+		lines = append(lines, dim.Render("(looking for conflicts...)"))
+		return strings.Join(lines, "\n")
+	}
+	if c.kind == "rebase" {
+		lines = append(lines, dim.Render("A rebase stopped: \"mine\" is the commit being replayed, \"theirs\" is what it is replayed onto."))
+	} else {
+		lines = append(lines, dim.Render("A merge stopped: \"mine\" is your branch, \"theirs\" is the branch being merged in."))
+	}
+	lines = append(lines, "")
+	if len(c.files) == 0 {
+		lines = append(lines, green.Render("All conflicts resolved. Press c to continue the "+c.kind+"."))
+	}
+	for i, f := range c.files {
+		line := red.Render("both modified ") + f
+		if i == c.cursor {
+			line = m.activeStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "",
+		dim.Render("[m] keep mine • [t] keep theirs • [e] edit • [a] mark resolved"),
+		dim.Render("[c] continue the "+c.kind+" • [x] abort the "+c.kind+" • [R] refresh • [esc] back"))
+	if c.err != "" {
+		lines = append(lines, red.Render("! "+c.err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stoppedOnConflict reports whether args, which just failed, is a command
+// that leaves a rebase or merge stopped on conflicts.
+func stoppedOnConflict(ctx context.Context, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "rebase", "merge", "pull":
+		return len(repo.Conflicts(ctx)) > 0
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"ezgit/internal/action"
+	"ezgit/internal/config"
+	"ezgit/internal/console"
+	"ezgit/internal/repo"
+	"ezgit/internal/safety"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// consoleState is the expert-mode console: one command line with per-repo
+// history, reverse search and tab completion.
+type consoleState struct {
+	input     textinput.Model
+	history   *console.History
+	completer *console.Completer
+	searching bool
+	query     string
+	match     int
+	cands     []string
+	err       string
+}
+
+type consoleDataMsg struct {
+	refs    []string
+	remotes []string
+}
+
+func (m *model) openConsole() tea.Cmd {
+	ti := textinput.New()
+	ti.Prompt = "git> "
+	ti.Placeholder = "status -sb"
+	ti.CharLimit = 2048
+	ti.Width = 60
+	ti.Focus()
+
+	top, err := repo.Toplevel(m.ctx())
+	if err != nil {
+		top, _ = os.Getwd()
+	}
+	m.console = &consoleState{
+		input:     ti,
+		history:   console.LoadHistory(console.HistoryPath(config.Dir(), top)),
+		completer: &console.Completer{Actions: m.actions},
+		match:     -1,
+	}
+	m.mode = "console"
+	ctx := m.ctx()
+	return func() tea.Msg { return loadConsoleData(ctx) }
+}
+
+func loadConsoleData(ctx context.Context) tea.Msg {
+	var msg consoleDataMsg
+	if out, err := repo.Git(ctx, "for-each-ref", "--format=%(refname:short)", "refs/heads", "refs/tags", "refs/remotes"); err == nil {
+		msg.refs = repo.Lines(out)
+	}
+	if out, err := repo.Git(ctx, "remote"); err == nil {
+		msg.remotes = repo.Lines(out)
+	}
+	return msg
+}
+
+func (m *model) updateConsole(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.console
+	k := msg.String()
+	if c.searching {
+		return m.updateConsoleSearch(msg)
+	}
+	c.err = ""
+	switch k {
+	case "esc":
+		m.console = nil
+		m.mode = "verbs"
+		return m, nil
+	case "enter":
+		line := c.input.Value()
+		args, err := action.RawArgs(line)
+		if err != nil {
+			c.err = err.Error()
+			return m, nil
+		}
+		if len(args) == 0 {
+			return m, nil
+		}
+		_ = c.history.Add(line)
+		c.input.SetValue("")
+		c.cands = nil
+		m.afterRun = "console"
+		return m.launch("git", args, false)
+	case "up":
+		if v, ok := c.history.Prev(c.input.Value()); ok {
+			c.input.SetValue(v)
+			c.input.CursorEnd()
+		}
+		return m, nil
+	case "down":
+		if v, ok := c.history.Next(); ok {
+			c.input.SetValue(v)
+			c.input.CursorEnd()
+		}
+		return m, nil
+	case "ctrl+r":
+		c.searching = true
+		c.query = ""
+		c.match = -1
+		return m, nil
+	case "tab":
+		line := c.input.Value()
+		cands, start := c.completer.Complete(line)
+		c.cands = cands
+		if len(cands) == 0 {
+			return m, nil
+		}
+		repl := console.CommonPrefix(cands)
+		if len(cands) == 1 && !strings.HasSuffix(repl, "/") {
+			repl += " "
+		}
+		if len(repl) > len(line)-start {
+			c.input.SetValue(line[:start] + repl)
+			c.input.CursorEnd()
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	c.input, cmd = c.input.Update(msg)
+	c.history.Reset()
+	c.cands = nil
+	return m, cmd
+}
+
+// updateConsoleSearch handles ctrl+r reverse search: typing narrows the
+// query, ctrl+r again jumps to the next older match.
+func (m *model) updateConsoleSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.console
+	entries := c.history.Entries()
+	switch k := msg.String(); k {
+	case "esc", "ctrl+g":
+		c.searching = false
+		return m, nil
+	case "enter", "right", "left":
+		if c.match >= 0 {
+			c.input.SetValue(entries[c.match])
+			c.input.CursorEnd()
+		}
+		c.searching = false
+		return m, nil
+	case "ctrl+r":
+		from := len(entries)
+		if c.match >= 0 {
+			from = c.match
+		}
+		if i := c.history.Search(c.query, from); i >= 0 {
+			c.match = i
+		}
+		return m, nil
+	case "backspace":
+		if c.query != "" {
+			c.query = c.query[:len(c.query)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			c.query += string(msg.Runes)
+			if msg.Type == tea.KeySpace {
+				c.query += " "
+			}
+		}
+	}
+	c.match = c.history.Search(c.query, len(entries))
+	return m, nil
+}
+
+func (m model) renderConsole() string {
+	c := m.console
+	if c == nil {
+		return ""
+	}
+	dim := m.footerStyle
+	lines := []string{lipgloss.NewStyle().Bold(true).Render("Raw git console")}
+	if c.searching {
+		found := ""
+		if c.match >= 0 {
+			found = c.history.Entries()[c.match]
+		}
+		lines = append(lines, "(reverse-i-search)`"+c.query+"': "+found)
+	} else {
+		lines = append(lines, c.input.View())
+	}
+	line := c.input.Value()
+	if h := m.console.completer.Help(line); h != "" {
+		lines = append(lines, dim.Render(h))
+	}
+	if args, err := action.RawArgs(line); err == nil && len(args) > 0 {
+		for _, l := range safety.Classify(args).Lines() {
+			lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render("• "+l))
+		}
+	}
+	if c.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render("Error: "+c.err))
+	}
+	if len(c.cands) > 1 {
+		shown := c.cands
+		if len(shown) > 12 {
+			shown = shown[:12]
+		}
+		lines = append(lines, "", dim.Render(strings.Join(shown, "  ")))
+	}
+	lines = append(lines, "", dim.Render("[enter] run • [↑/↓] history • [ctrl+r] search • [tab] complete • [esc] back"))
+	return lipgloss.NewStyle().Width(64).Render(strings.Join(lines, "\n"))
+}
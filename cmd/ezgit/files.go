@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ezgit/internal/action"
+	"ezgit/internal/ignore"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type filesLoadedMsg struct {
+	Prompt int
+	Items  []repo.FileStatus
+	Err    error
+}
+
+func loadFilesCmd(ctx context.Context, prompt int, t action.PromptType) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		var items []repo.FileStatus
+		var err error
+		if t == action.PromptTracked {
+			items, err = repo.TrackedFiles(ctx)
+		} else {
+			items, err = repo.Status(ctx)
+		}
+		return filesLoadedMsg{Prompt: prompt, Items: items, Err: err}
+	}
+}
+
+// updateFileList drives a file prompt: "/" filters, space toggles, the
+// letter keys run a bulk operation on the selection right away and enter
+// hands the selection to the action being built.
+func (m *model) updateFileList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	k := msg.String()
+	if m.filtering {
+		switch k {
+		case "esc":
+			m.filtering = false
+			m.input.SetValue("")
+			m.input.Blur()
+			m.files.SetFilter("")
+			return m, nil
+		case "enter":
+			m.filtering = false
+			m.input.Blur()
+			return m, nil
+		case "up", "down":
+			m.files.Update(msg)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		m.files.SetFilter(m.input.Value())
+		return m, cmd
+	}
+	switch k {
+	case "/":
+		m.filtering = true
+		return m, m.input.Focus()
+	case "s", "u", "d", "i":
+		return m.bulkFiles(k)
+	case "enter":
+		return m.answerPrompt(m.fileAnswer(m.currentAction.Prompts[m.promptIndex]))
+	case "esc":
+		m.files = nil
+		m.mode = "verbs"
+		return m, nil
+	}
+	m.files.Update(msg)
+	return m, nil
+}
+
+// bulkFiles stages, unstages, discards or ignores the selected files
+// without leaving the list. Discard goes through launch like any other
+// command, so the classifier puts it behind the typed confirmation.
+func (m *model) bulkFiles(op string) (tea.Model, tea.Cmd) {
+	sel := m.files.Targets()
+	if len(sel) == 0 {
+		return m, nil
+	}
+	m.wizardErr = ""
+	ctx := m.ctx()
+	var args []string
+	switch op {
+	case "s":
+		args = append([]string{"add", "--"}, selectedPaths(sel)...)
+	case "u":
+		var staged []repo.FileStatus
+		for _, f := range sel {
+			if f.Staged() {
+				staged = append(staged, f)
+			}
+		}
+		if len(staged) == 0 {
+			m.wizardErr = "none of the selected files are staged"
+			return m, nil
+		}
+		if _, err := repo.RevParse(ctx, "HEAD"); err != nil {
+			// nothing to restore from before the first commit
+			args = append([]string{"rm", "--cached", "--quiet", "--"}, selectedPaths(staged)...)
+		} else {
+			args = append([]string{"restore", "--staged", "--"}, selectedPaths(staged)...)
+		}
+	case "d":
+		var tracked, untracked []repo.FileStatus
+		for _, f := range sel {
+			if f.Untracked() {
+				untracked = append(untracked, f)
+			} else {
+				tracked = append(tracked, f)
+			}
+		}
+		switch {
+		case len(tracked) > 0 && len(untracked) > 0:
+			m.wizardErr = "discard tracked and untracked files separately"
+			return m, nil
+		case len(untracked) > 0:
+			args = append([]string{"clean", "-f", "--"}, selectedPaths(untracked)...)
+		default:
+			args = append([]string{"restore", "--source=HEAD", "--staged", "--worktree", "--"}, selectedPaths(tracked)...)
+		}
+	case "i":
+		m.ignoreFiles(ctx, sel)
+		return m, loadFilesCmd(m.ctx(), m.promptIndex, m.currentAction.Prompts[m.promptIndex].Type)
+	}
+	m.afterRun = "wizard"
+	return m.launch("git", args, false)
+}
+
+func (m *model) ignoreFiles(ctx context.Context, sel []repo.FileStatus) {
+	top, err := repo.Toplevel(ctx)
+	if err != nil {
+		m.wizardErr = err.Error()
+		return
+	}
+	var patterns []string
+	tracked := 0
+	for _, f := range sel {
+		patterns = append(patterns, ignore.PathPattern(f.Root))
+		if !f.Untracked() {
+			tracked++
+		}
+	}
+	added, err := ignore.Append(filepath.Join(top, ".gitignore"), patterns)
+	if err != nil {
+		m.wizardErr = err.Error()
+		return
+	}
+	m.statusLines = append(m.statusLines, fmt.Sprintf("[added %d pattern(s) to .gitignore]", len(added)))
+	if tracked > 0 {
+		m.statusLines = append(m.statusLines, fmt.Sprintf("[%d of them are tracked; ignore rules only apply once they are removed with rm --cached]", tracked))
+	}
+}
+
+// fileAnswer is what a file prompt answers: the checked files. An optional
+// prompt answers nothing when nothing is checked, which for add means
+// everything; a required one falls back to the highlighted file.
+func (m model) fileAnswer(p action.Prompt) string {
+	sel := m.files.Selected()
+	if len(sel) == 0 && p.Required {
+		sel = m.files.Targets()
+	}
+	return strings.Join(selectedPaths(sel), "\n")
+}
+
+func selectedPaths(files []repo.FileStatus) []string {
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.Path
+	}
+	return out
+}
+
+func (m model) renderFileList() []string {
+	var parts []string
+	if m.filtering || m.input.Value() != "" {
+		parts = append(parts, "/"+m.input.View())
+	}
+	parts = append(parts, m.files.View())
+	keys := "[space] toggle • [a] all • [/] filter • [enter] use selection"
+	if m.filtering {
+		keys = "type to filter • [enter] done • [esc] clear"
+	}
+	parts = append(parts,
+		m.footerStyle.Render(keys),
+		m.footerStyle.Render("[s] stage • [u] unstage • [d] discard • [i] add to .gitignore"))
+	return parts
+}
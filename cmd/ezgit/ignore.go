@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/ignore"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var ignoreTargets = []string{repo.IgnoreRepo, repo.IgnoreExclude, repo.IgnoreGlobal}
+
+// ignoreScreen lists untracked files to ignore with one key each, and the
+// ignored ones with the rule responsible. Rules go to the top-level
+// .gitignore, this clone's info/exclude or the global excludes file.
+type ignoreScreen struct {
+	files []string
+	// showIgnored switches the list to ignored files.
+	showIgnored bool
+	why         map[string]repo.IgnoreRule
+	target      int
+	cursor      int
+	// templates is non-nil while picking a template to merge.
+	templates []string
+	tcursor   int
+	note      string
+	err       string
+}
+
+type ignoreLoadedMsg struct {
+	Files []string
+	Why   map[string]repo.IgnoreRule
+	Err   error
+}
+
+func loadIgnoreCmd(ctx context.Context, ignored bool) tea.Cmd {
+	return func() tea.Msg {
+		files, err := repo.Untracked(ctx, ignored)
+		msg := ignoreLoadedMsg{Files: files, Err: err}
+		if ignored {
+			msg.Why = repo.WhyIgnored(ctx, files)
+		}
+		return msg
+	}
+}
+
+func (m *model) openIgnore() tea.Cmd {
+	m.ignore = &ignoreScreen{}
+	m.mode = "ignore"
+	return loadIgnoreCmd(m.ctx(), false)
+}
+
+func (m *model) handleIgnoreLoaded(msg ignoreLoadedMsg) (tea.Model, tea.Cmd) {
+	s := m.ignore
+	if s == nil {
+		return m, nil
+	}
+	if msg.Err != nil {
+		s.err = msg.Err.Error()
+		return m, nil
+	}
+	s.files, s.why = msg.Files, msg.Why
+	if s.cursor >= len(s.files) {
+		s.cursor = max(0, len(s.files)-1)
+	}
+	return m, nil
+}
+
+func (s *ignoreScreen) global() bool { return ignoreTargets[s.target] == repo.IgnoreGlobal }
+
+// ignoreCurrent writes the kind of pattern for the highlighted file to the
+// chosen target.
+func (m *model) ignoreCurrent(kind string) (tea.Model, tea.Cmd) {
+	s := m.ignore
+	if s.showIgnored || s.cursor >= len(s.files) {
+		return m, nil
+	}
+	p := ignore.Pattern(s.files[s.cursor], kind, s.global())
+	if p == "" {
+		s.err = "no " + kind + " to ignore for " + s.files[s.cursor]
+		return m, nil
+	}
+	file, err := repo.IgnoreFile(m.ctx(), ignoreTargets[s.target])
+	if err != nil {
+		s.err = err.Error()
+		return m, nil
+	}
+	added, err := ignore.Append(file, []string{p})
+	if err != nil {
+		s.err = err.Error()
+		return m, nil
+	}
+	if len(added) == 0 {
+		s.note = p + " is already in " + file
+	} else {
+		s.note = "added " + p + " to " + file
+	}
+	m.statusLines = append(m.statusLines, "["+s.note+"]")
+	return m, loadIgnoreCmd(m.ctx(), false)
+}
+
+func (m *model) updateIgnore(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.ignore
+	k := msg.String()
+	s.err, s.note = "", ""
+
+	if s.templates != nil {
+		switch k {
+		case "esc":
+			s.templates = nil
+		case "up", "k":
+			if s.tcursor > 0 {
+				s.tcursor--
+			}
+		case "down", "j":
+			if s.tcursor < len(s.templates)-1 {
+				s.tcursor++
+			}
+		case "enter":
+			name := s.templates[s.tcursor]
+			s.templates = nil
+			t, err := ignore.Template(name)
+			if err != nil {
+				s.err = err.Error()
+				return m, nil
+			}
+			file, err := repo.IgnoreFile(m.ctx(), ignoreTargets[s.target])
+			if err != nil {
+				s.err = err.Error()
+				return m, nil
+			}
+			added, err := ignore.Merge(file, name, t)
+			if err != nil {
+				s.err = err.Error()
+				return m, nil
+			}
+			s.note = fmt.Sprintf("merged %s into %s: %d new line(s)", name, file, len(added))
+			m.statusLines = append(m.statusLines, "["+s.note+"]")
+			return m, loadIgnoreCmd(m.ctx(), s.showIgnored)
+		}
+		return m, nil
+	}
+
+	switch k {
+	case "esc":
+		m.ignore = nil
+		m.mode = "verbs"
+		return m, nil
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.files)-1 {
+			s.cursor++
+		}
+	case "tab", "w":
+		s.target = (s.target + 1) % len(ignoreTargets)
+	case "v":
+		s.showIgnored = !s.showIgnored
+		s.cursor = 0
+		s.files = nil
+		return m, loadIgnoreCmd(m.ctx(), s.showIgnored)
+	case "R":
+		return m, loadIgnoreCmd(m.ctx(), s.showIgnored)
+	case "t":
+		s.templates = ignore.Templates()
+		s.tcursor = 0
+	case "f":
+		return m.ignoreCurrent(ignore.KindFile)
+	case "e":
+		return m.ignoreCurrent(ignore.KindExt)
+	case "d":
+		return m.ignoreCurrent(ignore.KindDir)
+	}
+	return m, nil
+}
+
+func (m model) renderIgnore() string {
+	s := m.ignore
+	if s == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+
+	var tabs []string
+	for i, t := range ignoreTargets {
+		if i == s.target {
+			tabs = append(tabs, m.activeStyle.Render("["+t+"]"))
+		} else {
+			tabs = append(tabs, dim.Render(" "+t+" "))
+		}
+	}
+	lines := []string{bold.Render("Ignore files"), "Write rules to: " + strings.Join(tabs, " "), ""}
+
+	if s.templates != nil {
+		lines = append(lines, bold.Render("Merge a template into "+ignoreTargets[s.target]))
+		for i, t := range s.templates {
+			if i == s.tcursor {
+				lines = append(lines, m.activeStyle.Render("> ")+t)
+			} else {
+				lines = append(lines, "  "+t)
+			}
+		}
+		lines = append(lines, "", dim.Render("lines already present are skipped • [enter] merge • [esc] back"))
+		return strings.Join(lines, "\n")
+	}
+
+	title := fmt.Sprintf("Untracked (%d)", len(s.files))
+	if s.showIgnored {
+		title = fmt.Sprintf("Ignored (%d)", len(s.files))
+	}
+	lines = append(lines, bold.Render(title))
+	if len(s.files) == 0 {
+		lines = append(lines, dim.Render("(nothing here)"))
+	}
+	start := max(0, s.cursor-15)
+	for i := start; i < len(s.files) && i < start+20; i++ {
+		line := s.files[i]
+		if s.showIgnored {
+			if r, ok := s.why[line]; ok {
+				line += dim.Render("  " + r.Pattern)
+			}
+		}
+		if i == s.cursor {
+			line = m.activeStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+	if s.cursor < len(s.files) {
+		cur := s.files[s.cursor]
+		if s.showIgnored {
+			if r, ok := s.why[cur]; ok {
+				lines = append(lines, fmt.Sprintf("ignored by %s line %s: %s", r.Source, r.Line, bold.Render(r.Pattern)))
+			}
+		} else {
+			for _, kind := range []string{ignore.KindFile, ignore.KindExt, ignore.KindDir} {
+				if p := ignore.Pattern(cur, kind, s.global()); p != "" {
+					lines = append(lines, dim.Render(fmt.Sprintf("[%c] %-9s → %s", kind[0], kind, p)))
+				}
+			}
+		}
+	}
+	if s.showIgnored {
+		lines = append(lines, "", dim.Render("[v] untracked • [t] merge template • [tab] target • [R] refresh • [esc] back"))
+	} else {
+		lines = append(lines, "", dim.Render("[f/e/d] ignore file/extension/directory • [t] merge template • [tab] target • [v] ignored • [esc] back"))
+	}
+	if s.note != "" {
+		lines = append(lines, green.Render(s.note))
+	}
+	if s.err != "" {
+		lines = append(lines, red.Render("! "+s.err))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"ezgit/internal/action"
+	execpkg "ezgit/internal/exec"
+	"ezgit/internal/safety"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// initPreview opens the preview of "init" into proj with a README.
+func initPreview(t *testing.T) *model {
+	t.Helper()
+	m := testModel(&execpkg.Runner{})
+	m.currentAction, _ = m.actions.Get("init")
+	m.wizardInputs = action.ActionInput{"path": "proj", "readme": "true", "license": "none", "commit": "false"}
+	m.enterPreview()
+	m.Update(m.refreshPlan()())
+	if m.currentPlan() == nil {
+		t.Fatal("no plan")
+	}
+	return m
+}
+
+func TestInitWritesFilesOnlyOnceLaunched(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m := initPreview(t)
+	m.guard.SetPolicy(&safety.Policy{Rules: []safety.Rule{{Name: "no-init", Command: "init", Severity: safety.SeverityDeny}}})
+	m.Update(enter)
+	if _, err := os.Stat("proj/README.md"); err == nil {
+		t.Fatal("a denied init left its README behind")
+	}
+
+	m = initPreview(t)
+	m.guard.SetPolicy(&safety.Policy{Rules: []safety.Rule{{Name: "ask", Command: "init", Severity: safety.SeverityConfirm}}})
+	m.Update(enter)
+	if m.mode != "confirm" {
+		t.Fatalf("mode %q, want confirm", m.mode)
+	}
+	m.input.SetValue("no")
+	m.Update(enter)
+	if _, err := os.Stat("proj/README.md"); err == nil {
+		t.Fatal("an unconfirmed init left its README behind")
+	}
+
+	m = initPreview(t)
+	m.guard.SetPolicy(nil)
+	_, run := m.Update(enter)
+	if _, err := os.Stat("proj/README.md"); err != nil {
+		t.Fatalf("launching init did not write the README: %v", err)
+	}
+	if run == nil {
+		t.Fatal("init did not start")
+	}
+	for {
+		if done, ok := run().(actionDoneMsg); ok {
+			if done.Exit != 0 || done.Err != nil {
+				t.Fatalf("init failed: %v %s", done.Err, done.ErrOut)
+			}
+			break
+		}
+	}
+	if _, err := os.Stat("proj/.git"); err != nil {
+		t.Fatalf("init did not create the repository: %v", err)
+	}
+}
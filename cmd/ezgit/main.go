@@ -5,15 +5,24 @@ import (
 	"ezgit/internal/combos"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"ezgit/internal/action"
 	"ezgit/internal/audit"
+	"ezgit/internal/config"
 	execpkg "ezgit/internal/exec"
+	"ezgit/internal/parser"
+	"ezgit/internal/repo"
+	"ezgit/internal/safety"
+	"ezgit/internal/scaffold"
+	"ezgit/internal/tui"
+	"ezgit/internal/watch"
 	"ezgit/internal/windows"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -57,12 +66,68 @@ type model struct {
 	previewParams    []string
 	previewSelected  int
 	editingParamKey  string
+	pendingCmd       string
+	pendingArgs      []string
+	watcher          *watch.Watcher
+	banner           string
+	guard            *safety.Safety
+	confirmReasons   []string
+	impact           *safety.Impact
+	console          *consoleState
+	afterRun         string
+	picker           *tui.RefPicker
+	options          *tui.OptionList
+	area             textarea.Model
+	wizardErr        string
+	files            *tui.FileList
+	filtering        bool
+	composer         *composer
+	split            *splitter
+	reword           *rewordState
+	branches         *branchMgr
+	offer            *offer
+	pull             *puller
+	sync             *syncer
+	conflicts        *conflictScreen
+	ignore           *ignoreScreen
+	progress         *runProgress
+	// plan is the cached preview of the current action; planWant is the
+	// key of the answers it was last asked for.
+	plan     *actionPlan
+	planWant string
+	// chdirAfter is the directory to work in once the queued run succeeds.
+	chdirAfter string
+	// pendingFiles are written just before the first command runs, once
+	// it has got past the policy and any confirmation.
+	pendingFiles    *pendingFiles
+	pendingTerminal bool
+	// exec runs every command of the session: the ones the user launches,
+	// on the terminal or not, and the screens' queries through ctx. actions
+	// run their own queries through the same one.
+	exec    execpkg.Executor
+	actions *action.Registry
+	queue   []action.Step
+	// removeAfter is a file the running command reads, deleted once it
+	// finished or was refused.
+	removeAfter string
+	// undo is what puts things back if the running step fails.
+	undo     []action.Step
+	warnings []string
+
+	// lastBranch and headPath drive the restore offer after a switch.
+	lastBranch string
+	headPath   string
+	// policyPath is the watched policy file of the current repository.
+	policyPath string
 }
 
 type streamLineMsg struct {
 	Line  string
 	IsErr bool
 }
+
+// progressMsg is one update of a progress meter of the running command.
+type progressMsg execpkg.Progress
 type actionDoneMsg struct {
 	Exit   int
 	Out    string
@@ -78,10 +143,6 @@ type Category struct {
 
 var registry *action.Registry
 
-func actionRegistryGet(name string) (*action.ActionDef, bool) {
-	return action.DefaultRegistry.Get(name)
-}
-
 var categories = []Category{
 	{ID: 0, Title: "Repository"},
 	{ID: 1, Title: "Work on changes"},
@@ -113,12 +174,17 @@ var itemsByCategory = map[int][]string{
 	},
 }
 
-func initialModel() *model {
+func initialModel(actions *action.Registry) *model {
 	ti := textinput.New()
 	ti.Placeholder = ""
 	ti.CharLimit = 512
 	ti.Width = 60
 
+	ta := textarea.New()
+	ta.SetWidth(72)
+	ta.SetHeight(6)
+	ta.ShowLineNumbers = false
+
 	m := model{
 		items: []string{
 			"init", "clone", "add", "commit", "status", "push", "branch", "merge", "rebase",
@@ -128,6 +194,7 @@ func initialModel() *model {
 		currentCategory:  0,
 		mode:             "home",
 		input:            ti,
+		area:             ta,
 		wizardInputs:     make(action.ActionInput),
 
 		headStyle:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205")),
@@ -141,12 +208,37 @@ func initialModel() *model {
 		validationErrors: make(map[string]string),
 
 		termWidth: 80,
+
+		guard:   safety.New(safety.Config{RequireTypedConfirmation: true}),
+		exec:    actions.Executor(),
+		actions: actions,
 	}
 
 	return &m
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m model) Init() tea.Cmd { return watchTick() }
+
+// ctx is what the model's git queries run under: through its executor.
+func (m model) ctx() context.Context {
+	return repo.WithExecutor(context.Background(), m.exec)
+}
+
+func (m model) verbsForCategory(cat int) []string {
+	actions := m.actions.List()
+	var list []string
+	for _, a := range actions {
+		if a.Category == cat {
+			list = append(list, a.Name)
+		}
+	}
+	if len(list) == 0 {
+		for _, a := range actions {
+			list = append(list, a.Name)
+		}
+	}
+	return list
+}
 func (m *model) validateFlagForKey(spec combos.CommandSpec, paramKey string) {
 	if spec.Flags == nil {
 		return
@@ -167,6 +259,10 @@ func (m *model) validateFlagForKey(spec combos.CommandSpec, paramKey string) {
 					m.validationErrors[paramKey] = "required"
 					return
 				}
+				if e, ok := combos.ArgErrors(spec, m.comboSelection(spec))[paramKey]; ok {
+					m.validationErrors[paramKey] = e
+					return
+				}
 				if f.Type == "int" && v != "" {
 					if _, err := strconv.Atoi(v); err != nil {
 						m.validationErrors[paramKey] = "must be integer"
@@ -190,13 +286,85 @@ func (m *model) validateFlagForKey(spec combos.CommandSpec, paramKey string) {
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if p, ok := msg.(planMsg); ok {
+		m.handlePlan(p)
+		return m, nil
+	}
+	next, cmd := m.update(msg)
+	if plan := m.refreshPlan(); plan != nil {
+		cmd = tea.Batch(cmd, plan)
+	}
+	return next, cmd
+}
+
+func (m *model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.termWidth = msg.Width
 		return m, nil
+	case watchTickMsg:
+		return m, m.handleWatchTick()
+	case refsLoadedMsg:
+		if msg.Prompt < 0 {
+			switch {
+			case m.split != nil:
+				m.split.pick.picker.SetItems(msg.Items)
+			case m.reword != nil:
+				m.reword.pick.picker.SetItems(msg.Items)
+			}
+			return m, nil
+		}
+		if m.picker != nil && m.mode == "wizard" && msg.Prompt == m.promptIndex {
+			m.picker.SetItems(msg.Items)
+			m.picker.SetFilter(m.input.Value())
+		}
+		return m, nil
+	case splitStartedMsg, splitDiffMsg, splitCommittedMsg, splitFinishedMsg:
+		return m.handleSplit(msg)
+	case rewordLoadedMsg, rewordDoneMsg:
+		return m.handleReword(msg)
+	case divergenceMsg:
+		return m.handleDivergence(msg)
+	case syncLoadedMsg:
+		return m.handleSyncLoaded(msg)
+	case conflictsLoadedMsg:
+		return m.handleConflicts(msg)
+	case ignoreLoadedMsg:
+		return m.handleIgnoreLoaded(msg)
+	case branchesLoadedMsg:
+		if b := m.branches; b != nil {
+			b.items, b.base = msg.Items, msg.Base
+			if msg.Err != nil {
+				b.err = msg.Err.Error()
+			}
+			if b.cursor >= len(b.items) {
+				b.cursor = intMax(0, len(b.items)-1)
+			}
+		}
+		return m, nil
+	case filesLoadedMsg:
+		if m.files != nil && m.mode == "wizard" && msg.Prompt == m.promptIndex {
+			if msg.Err != nil {
+				m.wizardErr = msg.Err.Error()
+			}
+			m.files.SetItems(msg.Items)
+		}
+		return m, nil
+	case consoleDataMsg:
+		if m.console != nil {
+			m.console.completer.Refs = msg.refs
+			m.console.completer.Remotes = msg.remotes
+		}
+		return m, nil
+	case impactMsg:
+		if m.mode == "confirm" && strings.Join(msg.Args, "\x00") == strings.Join(m.pendingArgs, "\x00") {
+			im := msg.Impact
+			m.impact = &im
+		}
+		return m, nil
 	case tea.KeyMsg:
 		k := msg.String()
-		if k == "q" || k == "ctrl+c" {
+		if k == "ctrl+c" || (k == "q" && !m.typing()) {
 			if m.runCancel != nil && m.mode == "running" {
 				m.runCancel()
 				return m, nil
@@ -227,6 +395,37 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.mode == "console" && m.console != nil {
+			return m.updateConsole(msg)
+		}
+		if m.mode == "commit" && m.composer != nil {
+			return m.updateComposer(msg)
+		}
+		if m.mode == "split" && m.split != nil {
+			return m.updateSplit(msg)
+		}
+		if m.mode == "reword" && m.reword != nil {
+			return m.updateReword(msg)
+		}
+		if m.mode == "branches" && m.branches != nil {
+			return m.updateBranches(msg)
+		}
+		if m.mode == "pull" && m.pull != nil {
+			return m.updatePull(msg)
+		}
+		if m.mode == "sync" && m.sync != nil {
+			return m.updateSync(msg)
+		}
+		if m.mode == "conflicts" && m.conflicts != nil {
+			return m.updateConflicts(msg)
+		}
+		if m.mode == "ignore" && m.ignore != nil {
+			return m.updateIgnore(msg)
+		}
+		if m.mode == "offer" && m.offer != nil {
+			return m.updateOffer(msg)
+		}
+
 		if m.mode == "home" {
 			switch k {
 			case "up", "k":
@@ -242,23 +441,35 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				m.mode = "verbs"
 				m.cursor = 0
-				actions := action.DefaultRegistry.List()
-				var list []string
-				for _, a := range actions {
-					if a.Category == m.selectedCategory {
-						list = append(list, a.Name)
-					}
+				m.items = m.verbsForCategory(m.selectedCategory)
+				return m, nil
+			case "esc":
+				return m, nil
+			}
+		}
+
+		if m.mode == "verbs" && m.input.Focused() && m.input.Value() != "" {
+			switch k {
+			case "enter":
+				fields, err := action.RawArgs(m.input.Value())
+				if err != nil {
+					m.statusLines = append(m.statusLines, "[cannot parse command: "+err.Error()+"]")
+					return m, nil
 				}
-				if len(list) == 0 {
-					for _, a := range actions {
-						list = append(list, a.Name)
-					}
+				m.input.SetValue("")
+				m.input.Blur()
+				if len(fields) == 0 {
+					return m, nil
 				}
-				m.items = list
-				return m, nil
+				return m.launch("git", fields, false)
 			case "esc":
+				m.input.SetValue("")
+				m.input.Blur()
 				return m, nil
 			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
 		}
 
 		if m.mode == "verbs" {
@@ -275,44 +486,48 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case "enter":
 				name := m.items[m.cursor]
-				if a, ok := actionRegistryGet(name); ok {
+				if a, ok := m.actions.Get(name); ok && a.Name == "raw" {
+					m.currentAction = a
+					return m, m.openConsole()
+				} else if ok && a.Screen == "commit" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openComposer()
+				} else if ok && a.Screen == "split" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openSplit()
+				} else if ok && a.Screen == "reword" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openReword()
+				} else if ok && a.Screen == "branches" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openBranches()
+				} else if ok && a.Screen == "pull" {
+					m.currentAction = a
+					m.input.Blur()
+					return m.openPull()
+				} else if ok && a.Screen == "sync" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openSync()
+				} else if ok && a.Screen == "conflicts" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openConflicts("verbs")
+				} else if ok && a.Screen == "ignore" {
+					m.currentAction = a
+					m.input.Blur()
+					return m, m.openIgnore()
+				} else if ok {
 					m.currentAction = a
 					m.wizardInputs = make(action.ActionInput)
 					m.promptIndex = 0
 					m.input.SetValue("")
 					if spec, ok := combos.Get(a.Name); ok {
-						if m.comboInputs == nil {
-							m.comboInputs = make(map[string]*textinput.Model)
-						}
-						if m.includedFlags == nil {
-							m.includedFlags = make(map[string]bool)
-						}
-						for _, f := range spec.Flags {
-							if f.ManualOnly {
-								if _, exists := m.comboInputs[f.ParamKey]; !exists {
-									ti := textinput.New()
-									ti.Placeholder = f.Example
-									ti.CharLimit = 512
-									ti.Width = 36
-									ti.Prompt = ""
-									if f.Default != nil {
-										ti.SetValue(fmt.Sprintf("%v", f.Default))
-									}
-									ti.Blur()
-									m.comboInputs[f.ParamKey] = &ti
-								}
-							} else {
-								if _, ok := m.includedFlags[f.ParamKey]; !ok {
-									m.includedFlags[f.ParamKey] = false
-								}
-							}
-						}
-						m.comboOrder = nil
-						for _, f := range spec.Flags {
-							if f.ManualOnly {
-								m.comboOrder = append(m.comboOrder, f.ParamKey)
-							}
-						}
+						m.syncComboInputs(spec)
 						if len(m.comboOrder) > 0 {
 							m.comboFocusIndex = 0
 							if ti := m.comboInputs[m.comboOrder[0]]; ti != nil {
@@ -321,45 +536,40 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						} else {
 							m.comboFocusIndex = -1
 						}
-						m.previewParams = nil
-						for _, f := range spec.Flags {
-							if f.Advanced && !m.advancedVisible {
-								continue
-							}
-							m.previewParams = append(m.previewParams, f.ParamKey)
-						}
 						m.previewSelected = 0
 						m.editingParamKey = ""
 						m.mode = "preview"
 						m.input.Blur()
 					} else if len(a.Prompts) == 0 {
-						m.mode = "preview"
-						m.input.Blur()
+						m.enterPreview()
 					} else {
 						m.mode = "wizard"
+						return m, m.beginPrompt()
 					}
 				} else {
 					a := &action.ActionDef{
 						Name: name,
 						Prompts: []action.Prompt{
 							{
-								Key:      "extra",
-								Label:    "Extra args (optional)",
-								Default:  "",
-								Required: false,
+								Key:         "extra",
+								Label:       "Extra args (optional)",
+								Default:     "",
+								Placeholder: "optional args (e.g. -a --force)",
+								Required:    false,
 							},
 						},
 						BuildFunc: func(inputs action.ActionInput) (string, []string, string) {
 							args := []string{name}
-							if v, ok := inputs["extra"]; ok {
-								v = strings.TrimSpace(v)
-								if v != "" {
-									fields := strings.Fields(v)
-									args = append(args, fields...)
-								}
+							fields, err := parser.SplitArgs(inputs["extra"])
+							if err != nil {
+								return "git", args, "git " + parser.JoinArgs(args) + " (" + err.Error() + ")"
 							}
-							preview := "git " + strings.Join(args, " ")
-							return "git", args, preview
+							args = append(args, fields...)
+							return "git", args, "git " + parser.JoinArgs(args)
+						},
+						ValidateFunc: func(inputs action.ActionInput) error {
+							_, err := parser.SplitArgs(inputs["extra"])
+							return err
 						},
 						IsDestructive: func(inputs action.ActionInput) bool {
 							return false
@@ -368,9 +578,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.currentAction = a
 					m.wizardInputs = make(action.ActionInput)
 					m.promptIndex = 0
-					m.input.SetValue("")
-					m.input.Placeholder = "optional args (e.g. -a --force)"
 					m.mode = "wizard"
+					return m, m.beginPrompt()
 				}
 				return m, nil
 			case "esc":
@@ -390,28 +599,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		if m.mode == "wizard" {
-			var cmd tea.Cmd
-			m.input, cmd = m.input.Update(msg)
-			if k == "enter" {
-				if m.currentAction == nil || m.promptIndex >= len(m.currentAction.Prompts) {
-					m.mode = "preview"
-					m.input.Blur()
-					return m, cmd
-				}
-				p := m.currentAction.Prompts[m.promptIndex]
-				m.wizardInputs[p.Key] = strings.TrimSpace(m.input.Value())
-				m.input.SetValue("")
-				m.promptIndex++
-				if m.promptIndex >= len(m.currentAction.Prompts) {
-					m.mode = "preview"
-					m.input.Blur()
-				}
-			}
-			if k == "esc" {
-				m.mode = "verbs"
-				return m, cmd
-			}
-			return m, cmd
+			return m.updateWizard(msg)
 		}
 
 		if m.mode == "preview" {
@@ -584,28 +772,42 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			switch k {
 			case "enter":
-				if m.currentAction != nil && m.currentAction.IsDestructive != nil && m.currentAction.IsDestructive(m.wizardInputs) {
-					m.mode = "confirm"
-					m.input.SetValue("")
-					m.input.Placeholder = "type yes-I-mean-it to proceed"
-					m.input.Focus()
-					return m, nil
-				}
 				if m.currentAction != nil {
-					cmdName, args, _ := m.currentAction.Build(m.wizardInputs)
-					cmd, cancel := runActionCmdWithCancel(cmdName, args)
-					m.runCancel = cancel
-					m.streamLines = nil
-					m.mode = "running"
-					m.currentRunCmd = cmd
-					m.running = true
-					return m, cmd
+					if err := m.currentAction.Validate(m.wizardInputs); err != nil {
+						m.statusLines = append(m.statusLines, "[invalid input: "+err.Error()+"]")
+						return m, nil
+					}
+					plan := m.currentPlan()
+					if plan == nil {
+						return m, nil
+					}
+					steps := plan.steps
+					if len(steps) == 0 {
+						return m, nil
+					}
+					m.pendingFiles = nil
+					if m.currentAction.Files != nil {
+						dir, files := m.currentAction.Files(m.wizardInputs)
+						m.pendingFiles = &pendingFiles{dir: dir, files: files}
+					}
+					needTyped := plan.destructive
+					m.chdirAfter = ""
+					if m.currentAction.Chdir != nil {
+						m.chdirAfter = m.currentAction.Chdir(m.wizardInputs)
+					}
+					m.pendingTerminal = m.currentAction.NeedsTerminal != nil && m.currentAction.NeedsTerminal(m.wizardInputs)
+					m.queue, m.undo = steps[1:], steps[0].Undo
+					return m.launch(steps[0].Cmd, steps[0].Args, needTyped)
 				}
 				return m, nil
 			case "esc":
+				if m.currentAction == nil || len(m.currentAction.Prompts) == 0 {
+					m.mode = "verbs"
+					return m, nil
+				}
 				m.mode = "wizard"
-				m.promptIndex = intMax(0, len(m.currentAction.Prompts)-1)
-				return m, nil
+				m.promptIndex = m.lastAskedPrompt()
+				return m, m.beginPrompt()
 			}
 		}
 
@@ -670,43 +872,22 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if k == "enter" {
 				if strings.TrimSpace(m.input.Value()) == "yes-I-mean-it" {
 					backup := "preop/" + time.Now().Format("20060102-150405")
-					_, _, _, _ = (&execpkg.Runner{}).Run(context.Background(), "git", []string{"branch", backup}, nil, 0)
-
-					cmdName, args, _ := m.currentAction.Build(m.wizardInputs)
-					cmdRun, cancel := runActionCmdWithCancel(cmdName, args)
-					m.runCancel = cancel
-					m.streamLines = nil
-					m.mode = "running"
-					m.currentRunCmd = cmdRun
-					m.running = true
-					return m, cmdRun
+					_, _ = m.exec.Exec(m.ctx(), execpkg.Cmd{Name: "git", Args: []string{"branch", backup}})
+
+					return m, m.startRun()
 				}
 				m.statusLines = append(m.statusLines, "[typed confirmation failed; aborting]")
-				m.mode = "preview"
+				m.dropRemoveAfter()
+				m.queue = nil
+				m.undo = nil
+				m.chdirAfter = ""
+				m.pendingFiles = nil
+				m.mode = m.finishMode()
 				m.input.Blur()
-				return m, nil
-			}
-			return m, cmd
-		}
-
-		if m.mode == "verbs" && m.input.Focused() {
-			var cmd tea.Cmd
-			m.input, cmd = m.input.Update(msg)
-			if k == "enter" {
-				raw := strings.TrimSpace(m.input.Value())
-				if strings.TrimSpace(m.input.Value()) != "" {
-					fields := strings.Fields(raw)
-					if fields[0] == "git" {
-						fields = fields[1:]
-					}
-					cmdRun, cancel := runActionCmdWithCancel("git", fields)
-					m.runCancel = cancel
-					m.streamLines = nil
-					m.mode = "running"
-					m.currentRunCmd = cmdRun
-					m.running = true
-					return m, cmdRun
+				if m.mode == "sync" && m.sync != nil && m.sync.started && !m.sync.finished {
+					m.sync.fail("not confirmed")
 				}
+				return m, nil
 			}
 			return m, cmd
 		}
@@ -718,9 +899,27 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case progressMsg:
+		m.trackProgress(execpkg.Progress(msg))
+		if m.currentRunCmd != nil {
+			return m, m.currentRunCmd
+		}
+		return m, nil
+
+	case terminalDoneMsg:
+		return m.handleTerminalDone(msg)
+
 	case actionDoneMsg:
+		if m.pendingCmd == "git" && strings.Contains(msg.ErrOut, execpkg.CredentialPromptError) {
+			m.statusLines = append(m.statusLines, "[git needs to ask for credentials; running it in the terminal]")
+			return m, m.execInTerminal()
+		}
+		undo := m.undo
+		m.undo = nil
+		m.dropRemoveAfter()
 		m.running = false
-		m.mode = "preview"
+		m.forgetPlan()
+		m.mode = m.finishMode()
 		m.input.Blur()
 		m.currentRunCmd = nil
 		m.runCancel = nil
@@ -732,9 +931,24 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		if msg.Err != nil {
 			m.statusLines = append(m.statusLines, fmt.Sprintf("[process finished with error: %v]", msg.Err))
+		} else if msg.Exit != 0 {
+			m.statusLines = append(m.statusLines, fmt.Sprintf("[process exited with status %d]", msg.Exit))
 		} else {
 			m.statusLines = append(m.statusLines, "[process finished successfully]")
 		}
+		var reload tea.Cmd
+		if m.mode == "wizard" && m.files != nil {
+			reload = loadFilesCmd(m.ctx(), m.promptIndex, m.currentAction.Prompts[m.promptIndex].Type)
+		}
+		if m.mode == "branches" && m.branches != nil {
+			reload = loadBranchesCmd(m.ctx())
+		}
+		if m.mode == "pull" && m.pull != nil {
+			reload = loadDivergenceCmd(m.ctx(), m.pull.upstream)
+		}
+		if m.mode == "conflicts" && m.conflicts != nil {
+			reload = loadConflictsCmd(m.ctx())
+		}
 		_ = audit.AppendAudit(true, audit.Entry{
 			Timestamp: time.Now(),
 			Action: func() string {
@@ -746,12 +960,134 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Command: "", Args: nil,
 			ExitCode: msg.Exit, Stdout: msg.Out, Stderr: msg.ErrOut,
 		})
-		return m, nil
+		if len(m.queue) > 0 {
+			if msg.Err == nil && msg.Exit == 0 {
+				next := m.queue[0]
+				m.queue, m.undo = m.queue[1:], next.Undo
+				m.afterRun = m.mode
+				next2, cmd := m.launch(next.Cmd, next.Args, false)
+				return next2, tea.Batch(cmd, reload)
+			}
+			m.statusLines = append(m.statusLines, fmt.Sprintf("[stopped: %d remaining step(s) not run]", len(m.queue)))
+			m.queue = nil
+		}
+		if (msg.Err != nil || msg.Exit != 0) && len(undo) > 0 {
+			m.chdirAfter = ""
+			m.statusLines = append(m.statusLines, "[putting back what the earlier steps did]")
+			next, cmd := m.runSteps(undo)
+			return next, tea.Batch(cmd, reload)
+		}
+		if dir := m.chdirAfter; dir != "" {
+			m.chdirAfter = ""
+			if msg.Err == nil && msg.Exit == 0 {
+				m.enterRepo(dir)
+			}
+		}
+		if m.mode == "sync" && m.sync != nil {
+			return m.syncStepDone(msg.Err == nil && msg.Exit == 0)
+		}
+		if msg.Exit != 0 && m.mode != "conflicts" && m.pendingCmd == "git" && stoppedOnConflict(m.ctx(), m.pendingArgs) {
+			m.statusLines = append(m.statusLines, "[the "+m.pendingArgs[0]+" stopped on conflicts]")
+			return m, m.openConflicts(m.mode)
+		}
+		if msg.Exit != 0 && m.pendingCmd == "git" && len(m.pendingArgs) > 0 && m.pendingArgs[0] == "push" && action.LeaseStale(msg.ErrOut) {
+			m.showOffer(&offer{
+				title: "Someone pushed to the branch since you last fetched",
+				lines: []string{"The force push was refused so their commits are not lost.", "Fetch them and see what a force push would drop?"},
+				steps: action.FetchReviewSteps(m.pendingArgs),
+				yes:   "fetch and review",
+				no:    "leave it",
+			})
+			return m, reload
+		}
+		if msg.Exit != 0 && m.pendingCmd == "git" && len(m.pendingArgs) > 0 && m.pendingArgs[0] == "push" && action.PushRejected(msg.ErrOut) {
+			m.showOffer(&offer{
+				title: "The remote has commits you don't have yet",
+				lines: []string{"Rebase your commits on top of them and push again?"},
+				steps: action.PullRetrySteps(m.pendingArgs),
+				yes:   "pull --rebase and retry",
+				no:    "leave it",
+			})
+			return m, reload
+		}
+		m.checkBranchReturn()
+		return m, reload
 	}
 
 	return m, nil
 }
 
+// syncComboInputs creates inputs for any flag of spec that has none yet and
+// recomputes the preview ordering. Existing inputs keep their typed values,
+// which is what lets a combos reload refresh an open preview in place.
+func (m *model) syncComboInputs(spec combos.CommandSpec) {
+	if m.comboInputs == nil {
+		m.comboInputs = make(map[string]*textinput.Model)
+	}
+	if m.includedFlags == nil {
+		m.includedFlags = make(map[string]bool)
+	}
+	for _, f := range spec.Flags {
+		if f.ManualOnly {
+			if _, exists := m.comboInputs[f.ParamKey]; !exists {
+				ti := textinput.New()
+				ti.Placeholder = f.Example
+				ti.CharLimit = 512
+				ti.Width = 36
+				ti.Prompt = ""
+				if f.Default != nil {
+					ti.SetValue(fmt.Sprintf("%v", f.Default))
+				}
+				ti.Blur()
+				m.comboInputs[f.ParamKey] = &ti
+			}
+		} else {
+			if _, ok := m.includedFlags[f.ParamKey]; !ok {
+				m.includedFlags[f.ParamKey] = false
+			}
+		}
+	}
+	m.comboOrder = nil
+	for _, f := range spec.Flags {
+		if f.ManualOnly {
+			m.comboOrder = append(m.comboOrder, f.ParamKey)
+		}
+	}
+	m.previewParams = nil
+	for _, f := range spec.Flags {
+		if f.Advanced && !m.advancedVisible {
+			continue
+		}
+		m.previewParams = append(m.previewParams, f.ParamKey)
+	}
+	if m.previewSelected >= len(m.previewParams) {
+		m.previewSelected = max(0, len(m.previewParams)-1)
+	}
+}
+
+// comboSelection collects the toggled flags and manual inputs of the
+// Layer-3 preview, keyed by ParamKey.
+func (m *model) comboSelection(spec combos.CommandSpec) combos.Selection {
+	sel := combos.Selection{Included: map[string]bool{}, Values: map[string]string{}}
+	for _, f := range spec.Flags {
+		if f.ManualOnly {
+			if ti, ok := m.comboInputs[f.ParamKey]; ok {
+				sel.Values[f.ParamKey] = strings.TrimSpace((*ti).Value())
+			}
+			continue
+		}
+		if m.includedFlags[f.ParamKey] {
+			sel.Included[f.ParamKey] = true
+		}
+	}
+	return sel
+}
+
+// comboArgs is the argv shown in the preview and passed to the runner.
+func (m *model) comboArgs(spec combos.CommandSpec) []string {
+	return combos.BuildArgs(spec, m.comboSelection(spec))
+}
+
 func (m *model) previewEnterHandler(spec combos.CommandSpec) (tea.Model, tea.Cmd) {
 	m.validationErrors = map[string]string{}
 	for _, f := range spec.Flags {
@@ -773,53 +1109,16 @@ func (m *model) previewEnterHandler(spec combos.CommandSpec) (tea.Model, tea.Cmd
 			}
 		}
 	}
+	for k, e := range combos.ArgErrors(spec, m.comboSelection(spec)) {
+		if _, ok := m.validationErrors[k]; !ok {
+			m.validationErrors[k] = e
+		}
+	}
 	if len(m.validationErrors) > 0 {
 		return m, nil
 	}
 
-	m.wizardInputs = make(action.ActionInput)
-	for _, f := range spec.Flags {
-		if f.ManualOnly {
-			if ti, ok := m.comboInputs[f.ParamKey]; ok {
-				m.wizardInputs[f.ParamKey] = strings.TrimSpace((*ti).Value())
-			}
-			continue
-		}
-
-		if !m.includedFlags[f.ParamKey] {
-			continue
-		}
-
-		isBool := false
-		if strings.ToLower(f.Type) == "bool" {
-			isBool = true
-		}
-
-		if f.Default != nil {
-			switch dv := f.Default.(type) {
-			case bool:
-				isBool = true
-			case float64:
-
-				if dv == 0.0 || dv == 1.0 {
-					isBool = true
-				}
-			}
-		}
-
-		if isBool {
-
-			m.wizardInputs[f.ParamKey] = "true"
-		} else {
-
-			if f.Default != nil {
-				m.wizardInputs[f.ParamKey] = fmt.Sprintf("%v", f.Default)
-			} else {
-
-				m.wizardInputs[f.ParamKey] = "true"
-			}
-		}
-	}
+	m.wizardInputs = action.ActionInput(m.comboSelection(spec).Inputs(spec))
 	needTyped := false
 	if m.currentAction.IsDestructive != nil && m.currentAction.IsDestructive(m.wizardInputs) {
 		needTyped = true
@@ -839,21 +1138,168 @@ func (m *model) previewEnterHandler(spec combos.CommandSpec) (tea.Model, tea.Cmd
 		}
 	}
 
+	return m.launch("git", m.comboArgs(spec), needTyped)
+}
+
+// launch is the single way a command gets run. The repository policy is
+// evaluated on the final argv: deny blocks, confirm forces the typed
+// confirmation screen and warn is reported but lets the command through.
+func (m *model) launch(cmdName string, args []string, needTyped bool) (tea.Model, tea.Cmd) {
+	m.pendingCmd, m.pendingArgs = cmdName, args
+	m.confirmReasons = nil
+	if cmdName == "git" {
+		if v := safety.Classify(args); v.Destructive() {
+			needTyped = true
+			m.confirmReasons = append(m.confirmReasons, v.Lines()...)
+		}
+		if pub := safety.RewritesPublished(m.ctx(), args); len(pub) > 0 {
+			needTyped = true
+			m.confirmReasons = append(m.confirmReasons, fmt.Sprintf("rewrites %d commit(s) already pushed upstream; others will need to recover after your force push:", len(pub)))
+			for i, c := range pub {
+				if i == 5 {
+					m.confirmReasons = append(m.confirmReasons, fmt.Sprintf("  ... and %d more", len(pub)-5))
+					break
+				}
+				m.confirmReasons = append(m.confirmReasons, "  "+c)
+			}
+		}
+	}
+	for _, f := range m.guard.Evaluate(cmdName, args, safety.GitRepoContext(m.ctx())) {
+		switch f.Severity {
+		case safety.SeverityDeny:
+			m.statusLines = append(m.statusLines, "[blocked by policy] "+f.Message)
+			m.dropRemoveAfter()
+			m.afterRun = ""
+			m.pendingTerminal = false
+			m.queue = nil
+			m.chdirAfter = ""
+			m.pendingFiles = nil
+			if undo := m.undo; len(undo) > 0 {
+				m.undo = nil
+				m.statusLines = append(m.statusLines, "[putting back what the earlier steps did]")
+				return m.runSteps(undo)
+			}
+			return m, nil
+		case safety.SeverityConfirm:
+			needTyped = true
+			m.confirmReasons = append(m.confirmReasons, f.Message)
+		case safety.SeverityWarn:
+			m.statusLines = append(m.statusLines, "[policy warning] "+f.Message)
+		}
+	}
 	if needTyped {
 		m.mode = "confirm"
 		m.input.SetValue("")
 		m.input.Placeholder = "type yes-I-mean-it to proceed"
 		m.input.Focus()
-		return m, nil
+		m.impact = nil
+		return m, computeImpactCmd(m.ctx(), cmdName, args)
+	}
+	return m, m.startRun()
+}
+
+type impactMsg struct {
+	Args   []string
+	Impact safety.Impact
+}
+
+// computeImpactCmd works out what a destructive command would lose while
+// the confirmation screen is already showing.
+func computeImpactCmd(ctx context.Context, cmdName string, args []string) tea.Cmd {
+	if cmdName != "git" {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return impactMsg{Args: args, Impact: safety.ComputeImpact(ctx, args)}
+	}
+}
+
+// pendingFiles are files an action creates in dir before its steps run.
+type pendingFiles struct {
+	dir   string
+	files []scaffold.File
+}
+
+// writePendingFiles writes the pending files, if any, and reports whether
+// the run may go ahead.
+func (m *model) writePendingFiles() bool {
+	p := m.pendingFiles
+	m.pendingFiles = nil
+	if p == nil {
+		return true
+	}
+	written, kept, err := scaffold.Write(p.dir, p.files)
+	for _, f := range written {
+		m.statusLines = append(m.statusLines, "[created "+filepath.Join(p.dir, f)+"]")
 	}
-	cmdName, args, _ := m.currentAction.Build(m.wizardInputs)
-	cmd, cancel := runActionCmdWithCancel(cmdName, args)
+	for _, f := range kept {
+		m.statusLines = append(m.statusLines, "[kept existing "+filepath.Join(p.dir, f)+"]")
+	}
+	if err != nil {
+		m.statusLines = append(m.statusLines, "[could not write files: "+err.Error()+"]")
+		return false
+	}
+	return true
+}
+
+// finishMode is the screen to return to once a run ends or is aborted.
+func (m *model) finishMode() string {
+	next := m.afterRun
+	m.afterRun = ""
+	m.pendingTerminal = false
+	if next == "" {
+		next = "preview"
+	}
+	return next
+}
+
+// typing reports whether keys currently go into a text field, in which case
+// "q" is a character rather than quit.
+func (m *model) typing() bool {
+	switch m.mode {
+	case "console", "wizard", "confirm", "preview-edit", "commit", "split", "reword":
+		return true
+	case "verbs":
+		return m.input.Focused() && m.input.Value() != ""
+	case "branches":
+		return m.branches != nil && m.branches.editing != ""
+	case "sync":
+		return m.sync != nil && m.sync.msg.Focused()
+	}
+	return m.editingParamKey != ""
+}
+
+func (m *model) dropRemoveAfter() {
+	if m.removeAfter != "" {
+		os.Remove(m.removeAfter)
+		m.removeAfter = ""
+	}
+}
+
+// startRun runs the pending command, streaming its output, or on the real
+// terminal when it needs one.
+func (m *model) startRun() tea.Cmd {
+	if !m.writePendingFiles() {
+		m.queue = nil
+		m.undo = nil
+		m.chdirAfter = ""
+		m.mode = m.finishMode()
+		m.input.Blur()
+		return nil
+	}
+	if m.wantsTerminal() {
+		return m.execInTerminal()
+	}
+	cmd, cancel := runActionCmdWithCancel(m.exec, m.pendingCmd, m.pendingArgs)
 	m.runCancel = cancel
 	m.streamLines = nil
+	m.progress = nil
 	m.mode = "running"
 	m.currentRunCmd = cmd
 	m.running = true
-	return m, cmd
+	return cmd
 }
 
 func (m *model) View() string {
@@ -876,6 +1322,26 @@ func (m *model) View() string {
 		left = m.renderPreview()
 	case "confirm":
 		left = m.renderConfirm()
+	case "console":
+		left = m.renderConsole()
+	case "commit":
+		left = m.renderComposer()
+	case "split":
+		left = m.renderSplit()
+	case "reword":
+		left = m.renderReword()
+	case "branches":
+		left = m.renderBranches()
+	case "pull":
+		left = m.renderPull()
+	case "sync":
+		left = m.renderSync()
+	case "conflicts":
+		left = m.renderConflicts()
+	case "ignore":
+		left = m.renderIgnore()
+	case "offer":
+		left = m.renderOffer()
 	default:
 		left = m.renderCategoriesBox()
 	}
@@ -883,6 +1349,9 @@ func (m *model) View() string {
 	outputBox := m.renderOutputWithStream()
 	main := lipgloss.JoinHorizontal(lipgloss.Top, m.panelStyle.Render(left), lipgloss.NewStyle().PaddingLeft(1).Render(outputBox))
 
+	if m.banner != "" {
+		head = lipgloss.JoinVertical(lipgloss.Left, head, lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render(m.banner))
+	}
 	return lipgloss.JoinVertical(lipgloss.Left, head, main, "", help)
 }
 
@@ -912,49 +1381,6 @@ func (m model) renderVerbsPane() string {
 	return lipgloss.NewStyle().Width(40).Render(body)
 }
 
-func (m model) renderWizard() string {
-	if m.currentAction == nil {
-		return lipgloss.NewStyle().Render("(no action selected)")
-	}
-	if len(m.currentAction.Prompts) == 0 {
-		return lipgloss.NewStyle().Render("(no prompts for this action)")
-	}
-	p := m.currentAction.Prompts[m.promptIndex]
-	hdr := lipgloss.NewStyle().Bold(true).Render("Prompt")
-	label := p.Label
-	def := ""
-	if p.Default != "" {
-		def = fmt.Sprintf(" (default: %s)", p.Default)
-	}
-	tempInputs := make(action.ActionInput)
-	for k, v := range m.wizardInputs {
-		tempInputs[k] = v
-	}
-	if strings.TrimSpace(m.input.Value()) != "" {
-		tempInputs[p.Key] = strings.TrimSpace(m.input.Value())
-	}
-
-	previewLines := []string{}
-	if m.currentAction != nil {
-		previewLines = m.currentAction.Preview(tempInputs)
-	}
-
-	previewHdr := lipgloss.NewStyle().Bold(true).Render("Preview")
-	previewText := "(no preview)"
-	if len(previewLines) > 0 {
-		previewText = strings.Join(previewLines, "\n")
-	}
-
-	return lipgloss.JoinVertical(lipgloss.Left,
-		hdr,
-		label+def,
-		m.input.View(),
-		"",
-		previewHdr,
-		previewText,
-	)
-}
-
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -978,6 +1404,7 @@ func (m model) renderPreview() string {
 	labelStyle := lipgloss.NewStyle().Bold(true)
 	valueStyle := lipgloss.NewStyle()
 	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 	checkOn := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Render("[x]")
 	checkOff := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("[ ]")
 	requiredBadge := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("⚠")
@@ -992,11 +1419,23 @@ func (m model) renderPreview() string {
 
 	spec, hasCombos := combos.Get(m.currentAction.Name)
 	if !hasCombos {
-		previews := m.currentAction.Preview(m.wizardInputs)
-		for _, v := range previews {
-			lines = append(lines, v)
+		plan := m.currentPlan()
+		if plan == nil {
+			lines = append(lines, m.footerStyle.Render("(working out the plan...)"))
+			return lipgloss.NewStyle().Width(panelWidth).Render(strings.Join(lines, "\n"))
 		}
-		if m.currentAction.IsDestructive != nil && m.currentAction.IsDestructive(m.wizardInputs) {
+		lines = append(lines, plan.lines...)
+		for _, w := range m.warnings {
+			lines = append(lines, warnStyle.Render("⚠ "+w))
+		}
+		verdict := safety.Verdict{}
+		if plan.cmd == "git" {
+			verdict = safety.Classify(plan.args)
+			for _, l := range verdict.Lines() {
+				lines = append(lines, errStyle.Render("• "+l))
+			}
+		}
+		if verdict.Destructive() || plan.destructive {
 			lines = append(lines, "", "[This operation is DESTRUCTIVE. Press Enter → typed confirmation required]")
 		} else {
 			lines = append(lines, "", "[Press Enter to Run, Esc to go back]")
@@ -1181,65 +1620,10 @@ func (m model) renderPreview() string {
 		}
 	}
 
-	previewParts := []string{"git", m.currentAction.Name}
-	for _, f := range visible {
-		if f.ManualOnly {
-			if ti, ok := m.comboInputs[f.ParamKey]; ok {
-				v := strings.TrimSpace((*ti).Value())
-				if v != "" {
-					if strings.HasPrefix(f.Key, "-") {
-						previewParts = append(previewParts, f.Key, v)
-					} else {
-						previewParts = append(previewParts, v)
-					}
-				} else if f.Default != nil {
-					if strings.HasPrefix(f.Key, "-") {
-						previewParts = append(previewParts, f.Key, fmt.Sprintf("%v", f.Default))
-					} else {
-						previewParts = append(previewParts, fmt.Sprintf("%v", f.Default))
-					}
-				}
-			}
-		} else {
-			if !m.includedFlags[f.ParamKey] {
-
-				continue
-			}
-
-			isBool := false
-			if strings.ToLower(f.Type) == "bool" {
-				isBool = true
-			} else if f.Default != nil {
-				switch f.Default.(type) {
-				case bool:
-					isBool = true
-				}
-			}
-
-			if isBool {
-
-				previewParts = append(previewParts, f.Key)
-				continue
-			}
-
-			if f.Default != nil {
-				if strings.HasPrefix(f.Key, "-") {
-					previewParts = append(previewParts, f.Key, fmt.Sprintf("%v", f.Default))
-				} else {
-					previewParts = append(previewParts, fmt.Sprintf("%v", f.Default))
-				}
-			} else if f.ParamKey != "" {
-
-				if strings.HasPrefix(f.Key, "-") {
-					previewParts = append(previewParts, f.Key)
-				} else {
-					previewParts = append(previewParts, f.Key)
-				}
-			} else {
-
-				previewParts = append(previewParts, f.Key)
-			}
-		}
+	comboArgs := m.comboArgs(spec)
+	previewParts := []string{"git"}
+	for _, a := range comboArgs {
+		previewParts = append(previewParts, parser.QuoteArg(a))
 	}
 	maxLine := panelWidth - 6
 	cur := ""
@@ -1267,13 +1651,30 @@ func (m model) renderPreview() string {
 	for _, pl := range previewLines {
 		lines = append(lines, "  "+pl)
 	}
+	for _, l := range safety.Classify(comboArgs).Lines() {
+		lines = append(lines, errStyle.Render("  • "+l))
+	}
 
 	return lipgloss.NewStyle().Width(panelWidth).Render(strings.Join(lines, "\n"))
 }
 
 func (m model) renderConfirm() string {
 	hdr := lipgloss.NewStyle().Bold(true).Render("Confirm (type yes-I-mean-it)")
-	return lipgloss.JoinVertical(lipgloss.Left, hdr, m.input.View())
+	lines := []string{hdr, m.pendingCmd + " " + parser.JoinArgs(m.pendingArgs)}
+	for _, r := range m.confirmReasons {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render("• "+r))
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Bold(true).Render("Impact"))
+	switch {
+	case m.impact == nil:
+		lines = append(lines, m.footerStyle.Render("(computing what would be lost...)"))
+	case m.impact.Empty():
+		lines = append(lines, m.footerStyle.Render("(nothing found that would be lost)"))
+	default:
+		lines = append(lines, m.impact.Lines()...)
+	}
+	lines = append(lines, "", m.input.View())
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (m model) renderOutputWithStream() string {
@@ -1284,6 +1685,9 @@ func (m model) renderOutputWithStream() string {
 	} else {
 		content = strings.Join(m.statusLines, "\n")
 	}
+	if bars := m.renderProgress(); bars != "" {
+		head = lipgloss.JoinVertical(lipgloss.Left, head, bars, "")
+	}
 	if strings.TrimSpace(content) == "" {
 		content = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("(no output yet)")
 	}
@@ -1319,22 +1723,33 @@ func intMax(a, b int) int {
 	return b
 }
 
-func runActionCmdWithCancel(cmdName string, args []string) (tea.Cmd, context.CancelFunc) {
+func runActionCmdWithCancel(ex execpkg.Executor, cmdName string, args []string) (tea.Cmd, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	lineCh := make(chan streamLineMsg, 512)
+	lineCh := make(chan tea.Msg, 512)
 	doneCh := make(chan actionDoneMsg, 1)
 
 	go func() {
-		runner := &execpkg.Runner{}
-		exit, out, errOut, err := runner.Run(ctx, cmdName, args, func(line string, isErr bool) {
+		c := execpkg.Cmd{Name: cmdName, Args: args}
+		if cmdName == "git" {
+			c.Env = execpkg.NoPromptEnv
+			c.Args = execpkg.WithProgress(args)
+			c.Progress = func(p execpkg.Progress) {
+				select {
+				case lineCh <- progressMsg(p):
+				default:
+				}
+			}
+		}
+		c.Stream = func(line string, isErr bool) {
 			select {
 			case lineCh <- streamLineMsg{Line: line, IsErr: isErr}:
 			default:
 			}
-		}, 0)
+		}
+		res, err := ex.Exec(ctx, c)
 
-		doneCh <- actionDoneMsg{Exit: exit, Out: out, ErrOut: errOut, Err: err}
+		doneCh <- actionDoneMsg{Exit: res.Exit, Out: res.Stdout, ErrOut: res.Stderr, Err: err}
 		close(lineCh)
 	}()
 
@@ -1393,9 +1808,34 @@ func main() {
 		}
 	}
 	action.RegisterBuiltins(action.DefaultRegistry)
+	if cfg, err := config.LoadOrCreate(); err == nil {
+		if err := action.DefaultRegistry.ReplaceCustom(action.CustomActions(cfg.CustomActions)); err != nil {
+			fmt.Println("config:", err)
+		}
+	}
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if err := p.Start(); err != nil {
+	// EZGIT_RECORD saves every command of the session as a replay fixture.
+	var recorder *execpkg.Recorder
+	if path := os.Getenv("EZGIT_RECORD"); path != "" {
+		recorder = &execpkg.Recorder{Next: action.DefaultRegistry.Executor()}
+		action.DefaultRegistry.SetExecutor(recorder)
+	}
+
+	m := initialModel(action.DefaultRegistry)
+	anchorCombos()
+	m.watcher = newWatcher()
+	m.watchHead()
+	if err := m.loadPolicy(); err != nil {
+		fmt.Println("policy:", err)
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	err := p.Start()
+	if recorder != nil {
+		if err := recorder.Save(os.Getenv("EZGIT_RECORD")); err != nil {
+			fmt.Println("record:", err)
+		}
+	}
+	if err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"ezgit/internal/action"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// offer is a yes/no follow-up shown after something happened: restoring
+// stashed work on a branch, retrying a rejected push. Yes runs steps and
+// returns to the screen the offer interrupted.
+type offer struct {
+	title   string
+	lines   []string
+	steps   []action.Step
+	yes, no string
+	// declined is added to the output when the offer is turned down.
+	declined string
+	back     string
+}
+
+func (m *model) showOffer(o *offer) {
+	o.back = m.mode
+	m.offer = o
+	m.mode = "offer"
+}
+
+func (m *model) updateOffer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	o := m.offer
+	switch msg.String() {
+	case "y", "Y", "enter":
+		m.offer = nil
+		m.mode = o.back
+		m.forgetPlan()
+		return m.runSteps(o.steps)
+	case "n", "N", "esc":
+		m.offer = nil
+		m.mode = o.back
+		m.forgetPlan()
+		if o.declined != "" {
+			m.statusLines = append(m.statusLines, o.declined)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderOffer() string {
+	o := m.offer
+	if o == nil {
+		return ""
+	}
+	lines := append([]string{lipgloss.NewStyle().Bold(true).Render(o.title)}, o.lines...)
+	lines = append(lines, "", "Will run:")
+	for _, s := range o.steps {
+		lines = append(lines, "  "+s.Cmd+" "+strings.Join(s.Args, " "))
+	}
+	lines = append(lines, "", m.footerStyle.Render("[y/enter] "+o.yes+" • [n/esc] "+o.no))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"ezgit/internal/action"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// actionPlan is what the wizard and preview show for one set of answers.
+// Building it may ask git several times (upstreams, merge bases, dirty
+// state), so it is worked out in a tea.Cmd and cached instead of being
+// rebuilt on every View.
+type actionPlan struct {
+	action      string
+	key         string
+	lines       []string
+	cmd         string
+	args        []string
+	steps       []action.Step
+	warnings    []string
+	destructive bool
+}
+
+type planMsg actionPlan
+
+// planInputs are the answers the screen on display previews: the stored
+// ones, plus the answer being typed in the wizard.
+// It is a copy, since the plan is worked out while Update goes on.
+func (m *model) planInputs() action.ActionInput {
+	in := make(action.ActionInput, len(m.wizardInputs)+1)
+	for k, v := range m.wizardInputs {
+		in[k] = v
+	}
+	if m.mode != "wizard" || m.promptIndex >= len(m.currentAction.Prompts) {
+		return in
+	}
+	p := m.currentAction.Prompts[m.promptIndex]
+	if v := m.currentAnswer(p); v != "" {
+		if nv, err := p.Normalize(v); err == nil {
+			in[p.Key] = nv
+		}
+	}
+	return in
+}
+
+func planKey(name string, in action.ActionInput) string {
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("\x00" + k + "=" + in[k])
+	}
+	return b.String()
+}
+
+func planCmd(a *action.ActionDef, in action.ActionInput, key string, warnings bool) tea.Cmd {
+	return func() tea.Msg {
+		p := planMsg{action: a.Name, key: key, lines: a.Preview(in), steps: a.Steps(in)}
+		p.cmd, p.args, _ = a.Build(in)
+		p.destructive = a.IsDestructive != nil && a.IsDestructive(in)
+		if warnings && a.Warnings != nil {
+			p.warnings = a.Warnings(in)
+		}
+		return p
+	}
+}
+
+// refreshPlan starts working out the plan when the answers on screen
+// changed since the last one was asked for.
+func (m *model) refreshPlan() tea.Cmd {
+	if m.currentAction == nil || (m.mode != "wizard" && m.mode != "preview") {
+		return nil
+	}
+	in := m.planInputs()
+	key := planKey(m.currentAction.Name, in)
+	if key == m.planWant {
+		return nil
+	}
+	m.planWant = key
+	return planCmd(m.currentAction, in, key, m.mode == "preview")
+}
+
+// forgetPlan drops the cached plan. A run or an offer may have moved HEAD
+// or the upstream, so the preview works the plan out again from the answers.
+func (m *model) forgetPlan() {
+	m.plan = nil
+	m.planWant = ""
+}
+
+func (m *model) handlePlan(msg planMsg) {
+	if msg.key != m.planWant {
+		return
+	}
+	p := actionPlan(msg)
+	m.plan = &p
+	m.warnings = p.warnings
+}
+
+// currentPlan is the plan for the answers on screen, or nil while it is
+// still being worked out.
+func (m model) currentPlan() *actionPlan {
+	if m.plan == nil || m.plan.key != m.planWant {
+		return nil
+	}
+	return m.plan
+}
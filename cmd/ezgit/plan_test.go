@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"ezgit/internal/action"
+	execpkg "ezgit/internal/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testModel is a model over its own registry of the builtins, with every
+// git command of both running through ex.
+func testModel(ex execpkg.Executor) *model {
+	r := action.NewRegistry()
+	r.SetExecutor(ex)
+	action.RegisterBuiltins(r)
+	return initialModel(r)
+}
+
+// countingExec counts the commands run through it.
+type countingExec struct {
+	next execpkg.Executor
+	n    atomic.Int32
+}
+
+func (c *countingExec) Exec(ctx context.Context, cmd execpkg.Cmd) (execpkg.Result, error) {
+	c.n.Add(1)
+	return c.next.Exec(ctx, cmd)
+}
+
+func gitT(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestPreviewPlanIsCached(t *testing.T) {
+	t.Chdir(t.TempDir())
+	gitT(t, "init", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	gitT(t, "add", "a.txt")
+	gitT(t, "commit", "-qm", "root")
+
+	count := &countingExec{next: &execpkg.Runner{}}
+	m := testModel(count)
+	m.currentAction, _ = m.actions.Get("push")
+	m.enterPreview()
+	cmd := m.refreshPlan()
+	if cmd == nil {
+		t.Fatal("entering the preview did not ask for a plan")
+	}
+	if v := m.View(); !strings.Contains(v, "working out the plan") {
+		t.Fatalf("preview before the plan arrived:\n%s", v)
+	}
+	m.Update(cmd())
+	if m.currentPlan() == nil {
+		t.Fatal("plan not stored")
+	}
+
+	count.n.Store(0)
+	v := m.View()
+	for i := 0; i < 5; i++ {
+		m.View()
+		m.Update(watchTickMsg{})
+	}
+	if n := count.n.Load(); n != 0 {
+		t.Errorf("rendering the preview ran %d git command(s)", n)
+	}
+	if !strings.Contains(v, "git push") {
+		t.Errorf("preview does not show the push:\n%s", v)
+	}
+	if m.refreshPlan() != nil {
+		t.Error("an unchanged preview asked for the plan again")
+	}
+}
+
+// finishRun feeds the running command's messages to m until it and any
+// queued steps are done, returning what the model asked for last.
+func finishRun(t *testing.T, m *model) tea.Cmd {
+	t.Helper()
+	var next tea.Cmd
+	for i := 0; m.running; i++ {
+		if i > 1000 {
+			t.Fatal("run never finished")
+		}
+		_, next = m.Update(m.currentRunCmd())
+	}
+	return next
+}
+
+// confirmPush feeds m the plan plan works out, launches the force push and
+// confirms it, returning what the model asked for once it finished.
+func confirmPush(t *testing.T, m *model, plan tea.Cmd) tea.Cmd {
+	t.Helper()
+	if plan == nil {
+		t.Fatal("the preview did not ask for a plan")
+	}
+	m.Update(plan())
+	if m.currentPlan() == nil {
+		t.Fatal("no plan")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != "confirm" {
+		t.Fatalf("mode %q, want confirm", m.mode)
+	}
+	m.input.SetValue("yes-I-mean-it")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return finishRun(t, m)
+}
+
+func TestPushAfterFetchReviewUsesNewLease(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "ada")
+	}
+	remote, other := t.TempDir(), t.TempDir()
+	gitT(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	gitT(t, "init", "-q", "-b", "main")
+	gitT(t, "commit", "-q", "--allow-empty", "-m", "root")
+	gitT(t, "remote", "add", "origin", remote)
+	gitT(t, "push", "-q", "-u", "origin", "main")
+	gitT(t, "clone", "-q", "-b", "main", remote, other)
+	gitT(t, "-C", other, "commit", "-q", "--allow-empty", "-m", "theirs")
+	gitT(t, "-C", other, "push", "-q", "origin", "main")
+	gitT(t, "commit", "-q", "--allow-empty", "-m", "mine")
+
+	m := testModel(&execpkg.Runner{})
+	m.currentAction, _ = m.actions.Get("push")
+	m.wizardInputs = action.ActionInput{"force": "true"}
+	m.enterPreview()
+	confirmPush(t, m, m.refreshPlan())
+	if m.mode != "offer" {
+		t.Fatalf("stale lease did not offer a fetch, mode %q:\n%s", m.mode, strings.Join(m.statusLines, "\n"))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	plan := finishRun(t, m)
+	if m.mode != "preview" {
+		t.Fatalf("fetch and review left mode %q", m.mode)
+	}
+
+	confirmPush(t, m, plan)
+	if want := "--force-with-lease=refs/heads/main:" + gitOut(t, "rev-parse", "origin/main^"); strings.Contains(strings.Join(m.pendingArgs, " "), want) {
+		t.Errorf("push kept the lease from before the fetch: %q", m.pendingArgs)
+	}
+	if got := gitOut(t, "ls-remote", remote, "main"); !strings.HasPrefix(got, gitOut(t, "rev-parse", "HEAD")) {
+		t.Errorf("push after the review did not go through:\n%s", strings.Join(m.statusLines, "\n"))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	execpkg "ezgit/internal/exec"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runProgress holds the progress meters of the running command, one bar
+// per phase ("Counting objects", "Receiving objects", ...) in the order
+// git started them.
+type runProgress struct {
+	phases []execpkg.Progress
+	bars   []progress.Model
+}
+
+// trackProgress records an update, starting a new bar for a new phase.
+func (m *model) trackProgress(p execpkg.Progress) {
+	if m.progress == nil {
+		m.progress = &runProgress{}
+	}
+	rp := m.progress
+	for i := range rp.phases {
+		if rp.phases[i].Phase == p.Phase {
+			rp.phases[i] = p
+			return
+		}
+	}
+	rp.phases = append(rp.phases, p)
+	rp.bars = append(rp.bars, progress.New(progress.WithDefaultGradient(), progress.WithWidth(30), progress.WithoutPercentage()))
+}
+
+func (m model) renderProgress() string {
+	if m.progress == nil || !m.running {
+		return ""
+	}
+	width := 0
+	for _, p := range m.progress.phases {
+		width = max(width, len(p.Phase))
+	}
+	var lines []string
+	for i, p := range m.progress.phases {
+		label := fmt.Sprintf("%-*s ", width, p.Phase)
+		switch {
+		case p.Total == 0 && p.Done:
+			lines = append(lines, label+m.footerStyle.Render(fmt.Sprintf("%d, done", p.Current)))
+		case p.Total == 0:
+			lines = append(lines, label+fmt.Sprintf("%d", p.Current))
+		default:
+			frac := float64(p.Percent) / 100
+			if p.Done {
+				frac = 1
+			}
+			lines = append(lines, label+m.progress.bars[i].ViewAs(frac)+fmt.Sprintf(" %3d%% (%d/%d)", p.Percent, p.Current, p.Total))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var pullChoices = []struct{ name, help string }{
+	{action.PullFastForward, "move your branch forward to theirs; only possible without local commits"},
+	{action.PullRebase, "replay your commits on top of theirs; linear history, your commits get new ids"},
+	{action.PullMerge, "join both lines with a merge commit; nothing is rewritten"},
+}
+
+// puller is the pull screen: fetch the upstream, show what is only here
+// and what is only there, recommend a strategy and run the chosen one. The
+// fetch and the integration both go through launch.
+type puller struct {
+	branch   string
+	remote   string
+	upstream string
+	div      *repo.Divergence
+	// recommended is "" when there is nothing to pull.
+	recommended string
+	why         string
+	choice      int
+	save        bool
+	// current describes the repository's pull.rebase / pull.ff setting.
+	current string
+	err     string
+}
+
+type divergenceMsg struct {
+	Div     repo.Divergence
+	Current string
+	Err     error
+}
+
+func loadDivergenceCmd(ctx context.Context, upstream string) tea.Cmd {
+	return func() tea.Msg {
+		d, err := repo.Diverge(ctx, upstream)
+		var set []string
+		for _, key := range []string{"pull.rebase", "pull.ff"} {
+			if v, err := repo.Git(ctx, "config", "--get", key); err == nil {
+				set = append(set, key+"="+v)
+			}
+		}
+		cur := "none"
+		if len(set) > 0 {
+			cur = strings.Join(set, ", ")
+		}
+		return divergenceMsg{Div: d, Current: cur, Err: err}
+	}
+}
+
+// openPull fetches the current branch's remote; the divergence is loaded
+// once the fetch is done, whether or not it succeeded.
+func (m *model) openPull() (tea.Model, tea.Cmd) {
+	ctx := m.ctx()
+	branch := repo.CurrentBranch(ctx)
+	if branch == "" {
+		m.statusLines = append(m.statusLines, "[pull: HEAD is detached; switch to a branch first]")
+		return m, nil
+	}
+	up := repo.Upstream(ctx, branch)
+	if up == "" {
+		m.statusLines = append(m.statusLines, "[pull: "+branch+" has no upstream; set one in the branch manager or push with set-upstream]")
+		return m, nil
+	}
+	p := &puller{branch: branch, remote: repo.BranchRemote(ctx, branch), upstream: up}
+	m.pull = p
+	m.mode = "pull"
+	return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"fetch", p.remote}}})
+}
+
+func (m *model) handleDivergence(msg divergenceMsg) (tea.Model, tea.Cmd) {
+	p := m.pull
+	if p == nil {
+		return m, nil
+	}
+	if msg.Err != nil {
+		p.err = msg.Err.Error()
+		return m, nil
+	}
+	p.div = &msg.Div
+	p.current = msg.Current
+	p.recommended, p.why = action.RecommendPull(msg.Div)
+	for i, c := range pullChoices {
+		if c.name == p.recommended {
+			p.choice = i
+		}
+	}
+	return m, nil
+}
+
+func (m *model) updatePull(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.pull
+	p.err = ""
+	switch msg.String() {
+	case "esc":
+		m.pull = nil
+		m.mode = "verbs"
+		return m, nil
+	case "f":
+		p.div = nil
+		return m.runSteps([]action.Step{{Cmd: "git", Args: []string{"fetch", p.remote}}})
+	}
+	if p.div == nil {
+		return m, nil
+	}
+	switch msg.String() {
+	case "up", "k":
+		if p.choice > 0 {
+			p.choice--
+		}
+	case "down", "j":
+		if p.choice < len(pullChoices)-1 {
+			p.choice++
+		}
+	case " ", "s":
+		p.save = !p.save
+	case "enter":
+		if p.recommended == "" {
+			p.err = "nothing to pull"
+			return m, nil
+		}
+		name := pullChoices[p.choice].name
+		if name == action.PullFastForward && p.div.Ahead > 0 {
+			p.err = fmt.Sprintf("can't fast-forward: you have %d local commit(s)", p.div.Ahead)
+			return m, nil
+		}
+		steps := action.PullSteps(name, p.upstream, p.save)
+		m.pull = nil
+		m.mode = "verbs"
+		return m.runSteps(steps)
+	}
+	return m, nil
+}
+
+func (m model) renderPull() string {
+	p := m.pull
+	if p == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+
+	lines := []string{bold.Render("Pull " + p.upstream + " into " + p.branch)}
+	if p.div == nil {
+		lines = append(lines, dim.Render("(fetching "+p.remote+"...)"))
+		if p.err != "" {
+			lines = append(lines, red.Render("! "+p.err))
+		}
+		return strings.Join(lines, "\n")
+	}
+	d := p.div
+
+	column := func(title string, n int, commits []string, style lipgloss.Style) string {
+		col := []string{style.Render(fmt.Sprintf("%s (%d)", title, n))}
+		if n == 0 {
+			col = append(col, dim.Render("(none)"))
+		}
+		for _, c := range commits {
+			if r := []rune(c); len(r) > 36 {
+				c = string(r[:35]) + "…"
+			}
+			col = append(col, c)
+		}
+		if n > len(commits) {
+			col = append(col, dim.Render(fmt.Sprintf("... %d more", n-len(commits))))
+		}
+		return lipgloss.NewStyle().Width(38).Render(strings.Join(col, "\n"))
+	}
+	lines = append(lines, "", lipgloss.JoinHorizontal(lipgloss.Top,
+		column("Only here", d.Ahead, d.Local, green),
+		column("Only on "+p.upstream, d.Behind, d.Remote, red),
+	), "")
+
+	if p.recommended == "" {
+		lines = append(lines, p.why, "", dim.Render("[f] fetch again • [esc] back"))
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, bold.Render("Recommended: "+p.recommended), lipgloss.NewStyle().Width(74).Render(p.why), "")
+	for i, c := range pullChoices {
+		line := fmt.Sprintf("%-13s %s", c.name, dim.Render(c.help))
+		if c.name == action.PullFastForward && d.Ahead > 0 {
+			line = dim.Render(fmt.Sprintf("%-13s not possible with local commits", c.name))
+		}
+		if i == p.choice {
+			line = m.activeStyle.Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	box := "[ ]"
+	if p.save {
+		box = "[x]"
+	}
+	lines = append(lines, "", box+" save as this repository's default for git pull "+dim.Render("(now: "+p.current+")"), "", bold.Render("Will run"))
+	for _, s := range action.PullSteps(pullChoices[p.choice].name, p.upstream, p.save) {
+		lines = append(lines, "git "+strings.Join(s.Args, " "))
+	}
+	lines = append(lines, "", dim.Render("[↑/↓] strategy • [s] save as default • [enter] run • [f] fetch again • [esc] back"))
+	if p.err != "" {
+		lines = append(lines, red.Render("! "+p.err))
+	}
+	return strings.Join(lines, "\n")
+}
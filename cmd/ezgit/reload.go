@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ezgit/internal/action"
+	"ezgit/internal/combos"
+	"ezgit/internal/config"
+	"ezgit/internal/repo"
+	"ezgit/internal/safety"
+	"ezgit/internal/watch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// combosCandidates are tried in order; the first one that exists wins.
+// main anchors them to the starting directory, so that switching into a
+// clone does not make them look deleted.
+var combosCandidates = []string{"combos_updated.json", "combos.json"}
+
+func anchorCombos() {
+	for i, p := range combosCandidates {
+		if abs, err := filepath.Abs(p); err == nil {
+			combosCandidates[i] = abs
+		}
+	}
+}
+
+const watchInterval = time.Second
+
+type watchTickMsg struct{}
+
+func watchTick() tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+func newWatcher() *watch.Watcher {
+	w := watch.New(combosCandidates...)
+	w.Add(config.Path())
+	return w
+}
+
+// reloadCombos re-reads the combos catalog. On a parse error the previous
+// catalog stays registered.
+func reloadCombos() error {
+	for _, p := range combosCandidates {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		doc, err := combos.LoadFromFile(p)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		combos.Replace(doc)
+		return nil
+	}
+	combos.Replace(&combos.CombosFile{})
+	return nil
+}
+
+// reloadCustomActions re-reads the user config and swaps in its custom
+// actions.
+func (m *model) reloadCustomActions() error {
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m.actions.ReplaceCustom(nil)
+		}
+		return fmt.Errorf("%s: %v", config.Path(), err)
+	}
+	return m.actions.ReplaceCustom(action.CustomActions(cfg.CustomActions))
+}
+
+func (m *model) handleWatchTick() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	var changed []string
+	for _, p := range m.watcher.Changed() {
+		if p == m.headPath {
+			m.checkBranchReturn()
+			continue
+		}
+		changed = append(changed, p)
+	}
+	if len(changed) == 0 {
+		return watchTick()
+	}
+	var errs []string
+	if err := reloadCombos(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := m.reloadCustomActions(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := m.loadPolicy(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		m.banner = "Reload failed (keeping previous definitions): " + errs[0]
+	} else {
+		m.banner = ""
+		m.statusLines = append(m.statusLines, fmt.Sprintf("[reloaded %v]", changed))
+	}
+	m.refreshAfterReload()
+	return watchTick()
+}
+
+// loadPolicy (re)loads the policy of the repository in the current
+// directory and watches its file instead of the previous repository's. On
+// a parse error the previous policy stays in force.
+func (m *model) loadPolicy() error {
+	top, err := repo.Toplevel(m.ctx())
+	if err != nil {
+		m.unwatch(&m.policyPath)
+		m.guard.SetPolicy(nil)
+		return nil
+	}
+	p := filepath.Join(top, safety.PolicyFile)
+	m.rewatch(&m.policyPath, p)
+	pol, err := safety.LoadRepoPolicy(top)
+	if err != nil {
+		return fmt.Errorf("%s: %v", p, err)
+	}
+	m.guard.SetPolicy(pol)
+	return nil
+}
+
+// rewatch moves the watch kept in *cur over to p.
+func (m *model) rewatch(cur *string, p string) {
+	if *cur == p {
+		return
+	}
+	m.unwatch(cur)
+	*cur = p
+	if m.watcher != nil {
+		m.watcher.Add(p)
+	}
+}
+
+func (m *model) unwatch(cur *string) {
+	if *cur != "" && m.watcher != nil {
+		m.watcher.Remove(*cur)
+	}
+	*cur = ""
+}
+
+// refreshAfterReload re-resolves the open action and its combos spec without
+// discarding anything the user already typed. An action that is gone, or
+// asks other questions now, is left: the answers no longer fit it.
+func (m *model) refreshAfterReload() {
+	if m.currentAction != nil {
+		name := m.currentAction.Name
+		a, ok := m.actions.Get(name)
+		switch {
+		case ok && samePrompts(a.Prompts, m.currentAction.Prompts):
+			m.currentAction = a
+		case m.mode == "wizard" || m.mode == "preview":
+			m.mode = "verbs"
+			m.currentAction = nil
+			m.promptIndex = 0
+			m.picker, m.options, m.files = nil, nil, nil
+			m.input.Blur()
+			m.area.Blur()
+			m.forgetPlan()
+			m.statusLines = append(m.statusLines, "["+name+" changed on reload; start it again]")
+		}
+	}
+	if m.currentAction != nil {
+		if spec, ok := combos.Get(m.currentAction.Name); ok && (m.mode == "preview" || m.mode == "preview-edit") {
+			m.syncComboInputs(spec)
+		}
+	}
+	if m.mode == "verbs" {
+		m.items = m.verbsForCategory(m.selectedCategory)
+		if m.cursor >= len(m.items) {
+			m.cursor = max(0, len(m.items)-1)
+		}
+	}
+}
+
+func samePrompts(a, b []action.Prompt) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].Type != b[i].Type {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ezgit/internal/combos"
+	"ezgit/internal/config"
+	execpkg "ezgit/internal/exec"
+)
+
+func TestReloadCombos(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Cleanup(func() { combos.Replace(&combos.CombosFile{}) })
+
+	os.WriteFile("combos.json", []byte(`{"commands": [{"action_key": "x"}]}`), 0o644)
+	if err := reloadCombos(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := combos.Get("x"); !ok {
+		t.Fatal("x not registered from combos.json")
+	}
+
+	os.WriteFile("combos_updated.json", []byte(`{"commands": [`), 0o644)
+	if err := reloadCombos(); err == nil {
+		t.Error("a broken combos_updated.json was accepted")
+	}
+	if _, ok := combos.Get("x"); !ok {
+		t.Error("a parse error dropped the previous catalog")
+	}
+
+	os.WriteFile("combos_updated.json", []byte(`{"commands": [{"action_key": "y"}]}`), 0o644)
+	if err := reloadCombos(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := combos.Get("x"); ok {
+		t.Error("combos_updated.json should win over combos.json")
+	}
+
+	os.Remove("combos_updated.json")
+	os.Remove("combos.json")
+	reloadCombos()
+	if keys := combos.RegisteredKeys(); len(keys) != 0 {
+		t.Errorf("with no catalog file left, still registered: %q", keys)
+	}
+}
+
+func TestEnterRepoKeepsWatches(t *testing.T) {
+	start := t.TempDir()
+	t.Chdir(start)
+	saved := append([]string(nil), combosCandidates...)
+	t.Cleanup(func() {
+		combosCandidates = saved
+		combos.Replace(&combos.CombosFile{})
+	})
+	gitT(t, "init", "-q", "-b", "main")
+	os.WriteFile("combos.json", []byte(`{"commands": [{"action_key": "x"}]}`), 0o644)
+	gitT(t, "init", "-q", "-b", "main", "clone")
+
+	anchorCombos()
+	m := testModel(&execpkg.Runner{})
+	m.watcher = newWatcher()
+	m.watchHead()
+	m.loadPolicy()
+	if err := reloadCombos(); err != nil {
+		t.Fatal(err)
+	}
+	oldHead, oldPolicy := m.headPath, m.policyPath
+
+	m.enterRepo("clone")
+	if m.headPath == oldHead || !strings.HasPrefix(m.headPath, filepath.Join(start, "clone")) {
+		t.Errorf("headPath = %s after entering the clone", m.headPath)
+	}
+	if m.policyPath == oldPolicy {
+		t.Errorf("policyPath = %s after entering the clone", m.policyPath)
+	}
+	m.handleWatchTick()
+	if _, ok := combos.Get("x"); !ok {
+		t.Fatal("entering the clone dropped the combos catalog")
+	}
+
+	// the old repository's HEAD and policy are no longer watched
+	os.WriteFile(oldHead, []byte("ref: refs/heads/other\n"), 0o644)
+	os.WriteFile(oldPolicy, []byte("{}"), 0o644)
+	if got := m.watcher.Changed(); len(got) != 0 {
+		t.Errorf("still watching the previous repository: %q", got)
+	}
+}
+
+func TestReloadCustomActions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	os.MkdirAll(config.Dir(), 0o700)
+	write := func(actions string) {
+		t.Helper()
+		if err := os.WriteFile(config.Path(), []byte(`{"custom_actions": [`+actions+`]}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hello := `{"name": "hello", "args": ["log"], "prompts": [{"key": "a"}, {"key": "b"}]}`
+
+	m := testModel(&execpkg.Runner{})
+	write(hello)
+	if err := m.reloadCustomActions(); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := m.actions.Get("hello")
+
+	// one clash keeps every previous definition
+	write(`{"name": "extra", "args": ["status"]}, {"name": "push", "args": ["status"]}`)
+	if err := m.reloadCustomActions(); err == nil || !strings.Contains(err.Error(), "push") {
+		t.Fatalf("shadowing push: %v", err)
+	}
+	if a, _ := m.actions.Get("hello"); a != first {
+		t.Error("a rejected reload dropped hello")
+	}
+	if _, ok := m.actions.Get("extra"); ok {
+		t.Error("a rejected reload registered extra")
+	}
+
+	// the same questions keep the wizard open on the new definition
+	m.currentAction, m.mode, m.promptIndex = first, "wizard", 1
+	write(hello)
+	m.reloadCustomActions()
+	m.refreshAfterReload()
+	if m.mode != "wizard" || m.currentAction == first {
+		t.Fatalf("unchanged prompts: mode %q, action replaced %v", m.mode, m.currentAction != first)
+	}
+
+	// fewer questions leave it
+	write(`{"name": "hello", "args": ["log"], "prompts": [{"key": "a"}]}`)
+	m.reloadCustomActions()
+	m.refreshAfterReload()
+	if m.mode != "verbs" || m.currentAction != nil {
+		t.Fatalf("changed prompts: mode %q, action %v", m.mode, m.currentAction)
+	}
+
+	m.currentAction, m.mode = first, "preview"
+	write(``)
+	m.reloadCustomActions()
+	m.refreshAfterReload()
+	if m.mode != "verbs" || m.currentAction != nil {
+		t.Fatalf("deleted action: mode %q, action %v", m.mode, m.currentAction)
+	}
+}
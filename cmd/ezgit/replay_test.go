@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	execpkg "ezgit/internal/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pushFlow previews "push" on m, launches it and feeds back the result,
+// returning the status lines it left.
+func pushFlow(t *testing.T, m *model) []string {
+	t.Helper()
+	m.currentAction, _ = m.actions.Get("push")
+	m.enterPreview()
+	m.Update(m.refreshPlan()())
+	if m.currentPlan() == nil {
+		t.Fatal("no plan")
+	}
+	_, run := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if run == nil {
+		t.Fatalf("push did not start, mode %q", m.mode)
+	}
+	for {
+		if done, ok := run().(actionDoneMsg); ok {
+			m.Update(done)
+			break
+		}
+	}
+	return m.statusLines
+}
+
+func TestReplayPushFlow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitT(t, "init", "-q", "--bare", "-b", "main", "origin.git")
+	gitT(t, "clone", "-q", filepath.Join(dir, "origin.git"), "work")
+	t.Chdir(filepath.Join(dir, "work"))
+	gitT(t, "checkout", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	gitT(t, "add", "a.txt")
+	gitT(t, "-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "-qm", "root")
+
+	rec := &execpkg.Recorder{Next: &execpkg.Runner{}}
+	want := pushFlow(t, testModel(rec))
+	if !strings.Contains(strings.Join(want, "\n"), "[process finished successfully]") {
+		t.Fatalf("recorded push failed:\n%s", strings.Join(want, "\n"))
+	}
+
+	// the same flow outside any repository, answered from the recording
+	t.Chdir(t.TempDir())
+	p := execpkg.NewReplayer(rec.Calls())
+	if got := pushFlow(t, testModel(p)); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed status lines:\n%s\nrecorded:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if left := p.Unused(); len(left) > 0 {
+		t.Errorf("%d recorded command(s) never asked for, first %q", len(left), left[0].Argv)
+	}
+}
+
+func TestTerminalRunGoesThroughExecutor(t *testing.T) {
+	p := execpkg.NewReplayer([]execpkg.Call{{Argv: []string{"git", "commit"}, Stdout: "[main abc123] hi\n", Exit: 0}})
+	m := testModel(p)
+	c := &terminalCmd{ctx: m.ctx(), exec: m.exec, cmd: execpkg.Cmd{Name: "git", Args: []string{"commit"}}}
+	var out bytes.Buffer
+	c.SetStdout(&out)
+	if err := c.Run(); err != nil || c.res.Exit != 0 {
+		t.Fatalf("exit %d: %v", c.res.Exit, err)
+	}
+	if out.String() != "[main abc123] hi\n" {
+		t.Errorf("terminal got %q", out.String())
+	}
+	if len(p.Unused()) != 0 {
+		t.Error("the terminal run did not reach the executor")
+	}
+}
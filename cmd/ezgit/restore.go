@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+)
+
+// watchHead starts watching HEAD, in place of the previous repository's,
+// so switches made outside EzGit also bring up the restore offer.
+func (m *model) watchHead() {
+	ctx := m.ctx()
+	m.lastBranch = repo.CurrentBranch(ctx)
+	p, err := repo.Git(ctx, "rev-parse", "--git-path", "HEAD")
+	if err != nil {
+		m.unwatch(&m.headPath)
+		return
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		m.rewatch(&m.headPath, abs)
+	}
+}
+
+// checkBranchReturn offers "Restore my work for this branch" when the
+// current branch changed since the last check and has work stashed by a
+// safe switch. It only interrupts screens where nothing is being typed or
+// run.
+func (m *model) checkBranchReturn() {
+	ctx := m.ctx()
+	b := repo.CurrentBranch(ctx)
+	if b == m.lastBranch {
+		return
+	}
+	m.lastBranch = b
+	switch m.mode {
+	case "home", "verbs", "preview", "branches":
+	default:
+		return
+	}
+	ss := repo.SwitchStashes(ctx, b)
+	if len(ss) == 0 {
+		return
+	}
+	stat, _ := repo.Git(ctx, "stash", "show", "--stat", ss[0].Ref)
+	lines := []string{
+		"You stashed uncommitted changes when you last switched away from " + b + ":",
+		"",
+		ss[0].Ref + "  " + ss[0].Subject,
+		stat,
+	}
+	if n := len(ss); n > 1 {
+		lines = append(lines, m.footerStyle.Render(fmt.Sprintf("(%d older entries for this branch stay in the stash)", n-1)))
+	}
+	m.showOffer(&offer{
+		title:    "Restore my work for this branch?",
+		lines:    lines,
+		steps:    []action.Step{{Cmd: "git", Args: []string{"stash", "pop", ss[0].Ref}}},
+		yes:      "restore",
+		no:       "not now",
+		declined: "[your stashed work for " + b + " is kept in " + ss[0].Ref + "]",
+	})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ezgit/internal/audit"
+	"ezgit/internal/repo"
+	"ezgit/internal/safety"
+	"ezgit/internal/undo"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	rewordPick = iota
+	rewordEdit
+)
+
+// rewordState is the reword screen: pick a commit, edit its message in a
+// text area, and rewrite it with a generated rebase. The rewrite is backed
+// up and recorded for undo-rewrite.
+type rewordState struct {
+	step   int
+	pick   *commitPick
+	target string
+	short  string
+	msg    textarea.Model
+	// published lists commits the rewrite would change that are already
+	// upstream; with any, committing needs a second ctrl+s.
+	published []string
+	warned    bool
+	busy      bool
+	err       string
+}
+
+type rewordLoadedMsg struct {
+	Target, Message string
+	Published       []string
+	Err             error
+}
+
+type rewordDoneMsg struct {
+	Entry undo.Entry
+	Err   error
+}
+
+func (m *model) openReword() tea.Cmd {
+	r := &rewordState{}
+	var cmd tea.Cmd
+	r.pick, cmd = newCommitPick(m.ctx())
+	r.msg = textarea.New()
+	r.msg.ShowLineNumbers = false
+	r.msg.CharLimit = 0
+	r.msg.SetWidth(74)
+	r.msg.SetHeight(8)
+	m.reword = r
+	m.mode = "reword"
+	return cmd
+}
+
+func loadReword(ctx context.Context, target string) tea.Cmd {
+	return func() tea.Msg {
+		sha, err := repo.RevParse(ctx, target)
+		if err != nil {
+			return rewordLoadedMsg{Err: fmt.Errorf("%q is not a commit", target)}
+		}
+		if _, err := repo.Git(ctx, "merge-base", "--is-ancestor", sha, "HEAD"); err != nil {
+			return rewordLoadedMsg{Err: fmt.Errorf("%s is not on the current branch", target)}
+		}
+		msg, err := repo.Git(ctx, "log", "-1", "--format=%B", sha)
+		if err != nil {
+			return rewordLoadedMsg{Err: err}
+		}
+		args := []string{"rebase", "--root"}
+		if _, err := repo.RevParse(ctx, sha+"^"); err == nil {
+			args = []string{"rebase", sha + "^"}
+		}
+		return rewordLoadedMsg{Target: sha, Message: msg, Published: safety.RewritesPublished(ctx, args)}
+	}
+}
+
+// runReword backs up HEAD, rewrites the message and records the undo entry.
+func runReword(ctx context.Context, target, message string) tea.Cmd {
+	return func() tea.Msg {
+		before, err := repo.RevParse(ctx, "HEAD")
+		if err != nil {
+			return rewordDoneMsg{Err: err}
+		}
+		backup, err := repo.Backup(ctx, "reword")
+		if err != nil {
+			return rewordDoneMsg{Err: err}
+		}
+		if err := repo.Reword(ctx, target, message); err != nil {
+			return rewordDoneMsg{Err: err}
+		}
+		after, err := repo.RevParse(ctx, "HEAD")
+		if err != nil {
+			return rewordDoneMsg{Err: err}
+		}
+		e := undo.Entry{Action: "reword", Branch: repo.CurrentBranch(ctx), Before: before, After: after, Backup: backup}
+		return rewordDoneMsg{Entry: e, Err: undo.Record(ctx, e)}
+	}
+}
+
+func (m *model) updateReword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.reword
+	k := msg.String()
+	if r.busy {
+		return m, nil
+	}
+	r.err = ""
+	switch r.step {
+	case rewordPick:
+		switch k {
+		case "esc":
+			return m.closeReword("")
+		case "enter":
+			target := r.pick.choice()
+			if target == "" {
+				return m, nil
+			}
+			r.busy = true
+			return m, loadReword(m.ctx(), target)
+		}
+		_, cmd := r.pick.update(msg)
+		return m, cmd
+
+	case rewordEdit:
+		switch k {
+		case "esc":
+			r.msg.Blur()
+			r.step = rewordPick
+			r.warned = false
+			return m, r.pick.filter.Focus()
+		case "ctrl+s":
+			text := strings.TrimSpace(r.msg.Value())
+			if text == "" {
+				r.err = "the message can't be empty"
+				return m, nil
+			}
+			if len(r.published) > 0 && !r.warned {
+				r.warned = true
+				return m, nil
+			}
+			r.busy = true
+			m.statusLines = append(m.statusLines, "[reword: rewriting "+r.short+"]")
+			return m, runReword(m.ctx(), r.target, text+"\n")
+		}
+		r.warned = false
+		var cmd tea.Cmd
+		r.msg, cmd = r.msg.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *model) handleReword(msg tea.Msg) (tea.Model, tea.Cmd) {
+	r := m.reword
+	if r == nil {
+		return m, nil
+	}
+	r.busy = false
+	switch msg := msg.(type) {
+	case rewordLoadedMsg:
+		if msg.Err != nil {
+			r.err = msg.Err.Error()
+			return m, nil
+		}
+		r.target, r.published = msg.Target, msg.Published
+		r.short = r.target[:min(len(r.target), 7)]
+		r.msg.SetValue(msg.Message)
+		r.step = rewordEdit
+		r.pick.filter.Blur()
+		return m, r.msg.Focus()
+
+	case rewordDoneMsg:
+		if msg.Entry.After == "" {
+			r.err = msg.Err.Error()
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.statusLines = append(m.statusLines, "[reword: could not record the undo entry: "+msg.Err.Error()+"]")
+		}
+		_ = audit.AppendAudit(true, audit.Entry{
+			Timestamp: time.Now(),
+			Action:    "reword",
+			Command:   "git",
+			Args:      []string{"reword", r.target},
+			Stdout:    msg.Entry.Before + " -> " + msg.Entry.After,
+		})
+		m.statusLines = append(m.statusLines,
+			"[reworded "+r.short+"; backup at "+msg.Entry.Backup+"]",
+			"[run undo-rewrite to take it back]",
+		)
+		return m.closeReword("")
+	}
+	return m, nil
+}
+
+func (m *model) closeReword(status string) (tea.Model, tea.Cmd) {
+	if status != "" {
+		m.statusLines = append(m.statusLines, status)
+	}
+	m.reword = nil
+	m.mode = "verbs"
+	return m, nil
+}
+
+func (m model) renderReword() string {
+	r := m.reword
+	if r == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	var lines []string
+	switch r.step {
+	case rewordPick:
+		lines = append(lines,
+			bold.Render("Reword a commit: pick the commit"),
+			r.pick.view(),
+			"",
+			m.footerStyle.Render("[↑/↓] pick • [enter] edit message • [esc] cancel"),
+		)
+	case rewordEdit:
+		lines = append(lines, bold.Render("Reword "+r.short))
+		for _, p := range r.published {
+			lines = append(lines, warnStyle.Render("⚠ already upstream: "+p))
+		}
+		lines = append(lines, r.msg.View(), "")
+		if r.warned {
+			lines = append(lines, warnStyle.Render("this rewrites published history and needs a force push; press ctrl+s again to go ahead"))
+		}
+		lines = append(lines, m.footerStyle.Render("[ctrl+s] rewrite • [esc] back"))
+	}
+	if r.busy {
+		lines = append(lines, m.footerStyle.Render("working..."))
+	}
+	if r.err != "" {
+		lines = append(lines, errStyle.Render("! "+r.err))
+	}
+	return strings.Join(lines, "\n")
+}
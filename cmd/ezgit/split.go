@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ezgit/internal/audit"
+	"ezgit/internal/hunks"
+	"ezgit/internal/repo"
+	"ezgit/internal/safety"
+	"ezgit/internal/tui"
+	"ezgit/internal/undo"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	splitPick = iota
+	splitStage
+	splitMessage
+)
+
+// splitter is the split-a-commit screen. Picking a commit stops a
+// generated rebase on it, resets it into the worktree and then loops
+// between choosing hunks and writing a message until nothing is left,
+// when the rebase continues. ctrl+x aborts at any point.
+type splitter struct {
+	step int
+	pick *commitPick
+	// published lists commits the split would rewrite that are already
+	// upstream; starting needs a second enter once it is shown.
+	published []string
+
+	target  string
+	short   string
+	message string
+	backup  string
+	// added are the files the commit created; after the reset they are
+	// untracked and offered whole.
+	added []string
+	hunks *tui.HunkList
+	msg   textarea.Model
+	made  int
+	busy  bool
+	err   string
+}
+
+type splitStartedMsg struct {
+	Target, Backup, Message string
+	Added                   []string
+	Err                     error
+}
+
+type splitDiffMsg struct {
+	Files []hunks.File
+	Err   error
+}
+
+type splitCommittedMsg struct {
+	Subject string
+	Err     error
+}
+
+type splitFinishedMsg struct {
+	Aborted bool
+	Err     error
+}
+
+func (m *model) openSplit() tea.Cmd {
+	s := &splitter{}
+	var cmd tea.Cmd
+	s.pick, cmd = newCommitPick(m.ctx())
+	s.msg = textarea.New()
+	s.msg.ShowLineNumbers = false
+	s.msg.CharLimit = 0
+	s.msg.SetWidth(74)
+	s.msg.SetHeight(6)
+	m.split = s
+	m.mode = "split"
+	return cmd
+}
+
+// startSplit records the backup ref, stops a rebase at target with a
+// generated todo and resets target into the worktree.
+func startSplit(ctx context.Context, target string) tea.Cmd {
+	return func() tea.Msg {
+		fail := func(err error) tea.Msg { return splitStartedMsg{Err: err} }
+		if repo.RebaseInProgress(ctx) {
+			return fail(fmt.Errorf("a rebase is already in progress; finish or abort it first"))
+		}
+		if out, _ := repo.Git(ctx, "status", "--porcelain", "--untracked-files=no"); out != "" {
+			return fail(fmt.Errorf("you have uncommitted changes; commit or stash them first"))
+		}
+		sha, err := repo.RevParse(ctx, target)
+		if err != nil {
+			return fail(fmt.Errorf("%q is not a commit", target))
+		}
+		if _, err := repo.RevParse(ctx, sha+"^"); err != nil {
+			return fail(fmt.Errorf("%s is the first commit and can't be split", target))
+		}
+		todo, err := repo.Todo(ctx, sha+"^")
+		if err != nil {
+			return fail(err)
+		}
+		if len(todo) == 0 || todo[0].Commit != sha {
+			return fail(fmt.Errorf("%s is not on the current branch", target))
+		}
+		todo[0].Action = "edit"
+		message, err := repo.Git(ctx, "log", "-1", "--format=%B", sha)
+		if err != nil {
+			return fail(err)
+		}
+		added, err := repo.GitRaw(ctx, "diff", "--name-only", "-z", "--no-renames", "--diff-filter=A", sha+"^", sha)
+		if err != nil {
+			return fail(err)
+		}
+		backup, err := repo.Backup(ctx, "split")
+		if err != nil {
+			return fail(err)
+		}
+		editor, err := repo.SequenceEditor(ctx, todo)
+		if err != nil {
+			return fail(err)
+		}
+		if _, err := repo.Git(ctx, append(editor, "rebase", "-i", sha+"^")...); err != nil {
+			return splitStartedMsg{Backup: backup, Err: err}
+		}
+		if _, err := repo.Git(ctx, "reset", "--quiet", "HEAD^"); err != nil {
+			return splitStartedMsg{Backup: backup, Err: err}
+		}
+		var files []string
+		for _, f := range strings.Split(added, "\x00") {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+		return splitStartedMsg{Target: sha, Backup: backup, Message: message, Added: files}
+	}
+}
+
+// loadSplitDiff lists what is left to commit: the unstaged hunks plus the
+// commit's new files that are still untracked.
+func loadSplitDiff(ctx context.Context, added []string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := repo.GitRaw(ctx, "diff", "--no-color", "--no-ext-diff", "--no-relative", "--src-prefix=a/", "--dst-prefix=b/")
+		if err != nil {
+			return splitDiffMsg{Err: err}
+		}
+		files := hunks.Parse(out)
+		if len(added) > 0 {
+			top, err := repo.Toplevel(ctx)
+			if err != nil {
+				return splitDiffMsg{Err: err}
+			}
+			args := append([]string{"-C", top, "ls-files", "-z", "--others", "--"}, added...)
+			untracked, err := repo.GitRaw(ctx, args...)
+			if err != nil {
+				return splitDiffMsg{Err: err}
+			}
+			for _, p := range strings.Split(untracked, "\x00") {
+				if p != "" {
+					files = append(files, hunks.File{Path: p, Untracked: true})
+				}
+			}
+		}
+		return splitDiffMsg{Files: files}
+	}
+}
+
+// commitSplit stages the chosen hunks and files and commits them. A failed
+// commit unstages again so the next attempt starts from the same state.
+func commitSplit(ctx context.Context, patch string, whole []string, message string) tea.Cmd {
+	return func() tea.Msg {
+		top, err := repo.Toplevel(ctx)
+		if err != nil {
+			return splitCommittedMsg{Err: err}
+		}
+		unstage := func(err error) tea.Msg {
+			_, _ = repo.Git(ctx, "reset", "--quiet")
+			return splitCommittedMsg{Err: err}
+		}
+		if patch != "" {
+			path, err := repo.Git(ctx, "rev-parse", "--git-path", "EZGIT_SPLIT.patch")
+			if err != nil {
+				return splitCommittedMsg{Err: err}
+			}
+			if err := os.WriteFile(path, []byte(patch), 0o644); err != nil {
+				return splitCommittedMsg{Err: err}
+			}
+			defer os.Remove(path)
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return splitCommittedMsg{Err: err}
+			}
+			if _, err := repo.Git(ctx, "-C", top, "apply", "--cached", abs); err != nil {
+				return unstage(err)
+			}
+		}
+		if len(whole) > 0 {
+			if _, err := repo.Git(ctx, append([]string{"-C", top, "add", "--"}, whole...)...); err != nil {
+				return unstage(err)
+			}
+		}
+		path, err := repo.Git(ctx, "rev-parse", "--git-path", "EZGIT_COMMIT_EDITMSG")
+		if err != nil {
+			return unstage(err)
+		}
+		if err := os.WriteFile(path, []byte(message), 0o644); err != nil {
+			return unstage(err)
+		}
+		if _, err := repo.Git(ctx, "commit", "--quiet", "-F", path); err != nil {
+			return unstage(err)
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		return splitCommittedMsg{Subject: subject}
+	}
+}
+
+// finishSplit continues the rebase once everything is committed, or aborts
+// it, which puts the branch back where it was. Everything left in the
+// worktree came from the commit being split, so aborting first clears it:
+// rebase --abort refuses to overwrite the commit's files while they are
+// untracked.
+func finishSplit(ctx context.Context, abort bool, added []string) tea.Cmd {
+	return func() tea.Msg {
+		if !abort {
+			_, err := repo.Git(ctx, "-c", "core.editor=true", "rebase", "--continue")
+			return splitFinishedMsg{Err: err}
+		}
+		if _, err := repo.Git(ctx, "reset", "--hard", "--quiet"); err != nil {
+			return splitFinishedMsg{Aborted: true, Err: err}
+		}
+		if len(added) > 0 {
+			top, err := repo.Toplevel(ctx)
+			if err != nil {
+				return splitFinishedMsg{Aborted: true, Err: err}
+			}
+			if _, err := repo.Git(ctx, append([]string{"-C", top, "clean", "-f", "-q", "--"}, added...)...); err != nil {
+				return splitFinishedMsg{Aborted: true, Err: err}
+			}
+		}
+		_, err := repo.Git(ctx, "rebase", "--abort")
+		return splitFinishedMsg{Aborted: true, Err: err}
+	}
+}
+
+func (m *model) updateSplit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.split
+	k := msg.String()
+	if s.busy {
+		return m, nil
+	}
+	if k == "ctrl+x" {
+		if s.target == "" {
+			return m.closeSplit("[split cancelled]")
+		}
+		s.busy = true
+		return m, finishSplit(m.ctx(), true, s.added)
+	}
+	s.err = ""
+
+	switch s.step {
+	case splitPick:
+		switch k {
+		case "esc":
+			return m.closeSplit("")
+		case "enter":
+			target := s.pick.choice()
+			if target == "" {
+				return m, nil
+			}
+			if s.published == nil {
+				s.published = safety.RewritesPublished(m.ctx(), []string{"rebase", target + "^"})
+				if len(s.published) > 0 {
+					return m, nil
+				}
+			}
+			s.busy = true
+			m.statusLines = append(m.statusLines, "[split: stopping at "+target+"]")
+			return m, startSplit(m.ctx(), target)
+		}
+		changed, cmd := s.pick.update(msg)
+		if changed {
+			s.published = nil
+		}
+		return m, cmd
+
+	case splitStage:
+		if s.hunks.Update(msg) {
+			return m, nil
+		}
+		if k == "enter" {
+			if s.hunks.Selected() == 0 {
+				s.err = "select at least one hunk with space"
+				return m, nil
+			}
+			s.step = splitMessage
+			return m, s.msg.Focus()
+		}
+		return m, nil
+
+	case splitMessage:
+		switch k {
+		case "esc":
+			s.msg.Blur()
+			s.step = splitStage
+			return m, nil
+		case "ctrl+s":
+			text := strings.TrimSpace(s.msg.Value())
+			if text == "" {
+				s.err = "the commit needs a message"
+				return m, nil
+			}
+			s.busy = true
+			return m, commitSplit(m.ctx(), s.hunks.Patch(), s.hunks.WholeFiles(), text+"\n")
+		}
+		var cmd tea.Cmd
+		s.msg, cmd = s.msg.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// handleSplit processes the results of the split's git steps.
+func (m *model) handleSplit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	s := m.split
+	if s == nil {
+		return m, nil
+	}
+	s.busy = false
+	switch msg := msg.(type) {
+	case splitStartedMsg:
+		if msg.Backup != "" {
+			s.backup = msg.Backup
+			m.statusLines = append(m.statusLines, "[split: backup at "+msg.Backup+"]")
+		}
+		if msg.Err != nil {
+			s.err = msg.Err.Error()
+			if repo.RebaseInProgress(m.ctx()) {
+				// stopped half way: keep the target so ctrl+x aborts
+				s.target = "?"
+			}
+			return m, nil
+		}
+		s.target, s.message, s.added = msg.Target, msg.Message, msg.Added
+		s.short = s.target[:min(len(s.target), 7)]
+		s.msg.SetValue(s.message)
+		s.step = splitStage
+		s.pick.filter.Blur()
+		return m, loadSplitDiff(m.ctx(), s.added)
+
+	case splitDiffMsg:
+		if msg.Err != nil {
+			s.err = msg.Err.Error()
+			return m, nil
+		}
+		if len(msg.Files) == 0 {
+			s.busy = true
+			m.statusLines = append(m.statusLines, "[split: everything committed; continuing the rebase]")
+			return m, finishSplit(m.ctx(), false, nil)
+		}
+		s.hunks = tui.NewHunkList(msg.Files)
+		s.step = splitStage
+		s.msg.Blur()
+		return m, nil
+
+	case splitCommittedMsg:
+		if msg.Err != nil {
+			s.err = msg.Err.Error()
+			return m, nil
+		}
+		s.made++
+		m.statusLines = append(m.statusLines, fmt.Sprintf("[split: commit %d: %s]", s.made, msg.Subject))
+		s.msg.SetValue(s.message)
+		return m, loadSplitDiff(m.ctx(), s.added)
+
+	case splitFinishedMsg:
+		if msg.Err != nil {
+			s.err = msg.Err.Error()
+			return m, nil
+		}
+		result := fmt.Sprintf("[split %s into %d commits; run undo-rewrite to take it back]", s.short, s.made)
+		if msg.Aborted {
+			result = "[split aborted; branch restored]"
+		} else {
+			ctx := m.ctx()
+			before, _ := repo.RevParse(ctx, s.backup)
+			after, _ := repo.RevParse(ctx, "HEAD")
+			e := undo.Entry{Action: "split", Branch: repo.CurrentBranch(ctx), Before: before, After: after, Backup: s.backup}
+			if err := undo.Record(ctx, e); err != nil {
+				m.statusLines = append(m.statusLines, "[split: could not record the undo entry: "+err.Error()+"]")
+			}
+		}
+		_ = audit.AppendAudit(true, audit.Entry{
+			Timestamp: time.Now(),
+			Action:    "split",
+			Command:   "git",
+			Args:      []string{"split", s.target},
+			Stdout:    result,
+		})
+		return m.closeSplit(result)
+	}
+	return m, nil
+}
+
+func (m *model) closeSplit(status string) (tea.Model, tea.Cmd) {
+	if status != "" {
+		m.statusLines = append(m.statusLines, status)
+	}
+	if s := m.split; s != nil && s.backup != "" {
+		m.statusLines = append(m.statusLines, "[the original commits stay reachable from "+s.backup+"]")
+	}
+	m.split = nil
+	m.mode = "verbs"
+	return m, nil
+}
+
+func (m model) renderSplit() string {
+	s := m.split
+	if s == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	var lines []string
+	switch s.step {
+	case splitPick:
+		lines = append(lines, bold.Render("Split a commit: pick the commit"), s.pick.view())
+		for _, p := range s.published {
+			lines = append(lines, warnStyle.Render("⚠ already upstream: "+p))
+		}
+		if len(s.published) > 0 {
+			lines = append(lines, warnStyle.Render("splitting rewrites published history; press enter again to go ahead"))
+		}
+		lines = append(lines, "", m.footerStyle.Render("[↑/↓] pick • [enter] split • [esc] cancel"))
+	case splitStage:
+		lines = append(lines,
+			bold.Render(fmt.Sprintf("Split %s: choose the hunks for commit %d", s.short, s.made+1)),
+			s.hunks.View(),
+			"",
+			m.footerStyle.Render("[space] toggle • [f] whole file • [a] all • [enter] write message • [ctrl+x] abort split"),
+		)
+	case splitMessage:
+		lines = append(lines,
+			bold.Render(fmt.Sprintf("Split %s: message for commit %d (%d hunks)", s.short, s.made+1, s.hunks.Selected())),
+			s.msg.View(),
+			"",
+			m.footerStyle.Render("[ctrl+s] commit • [esc] back to hunks • [ctrl+x] abort split"),
+		)
+	}
+	if s.busy {
+		lines = append(lines, m.footerStyle.Render("working..."))
+	}
+	if s.err != "" {
+		lines = append(lines, errStyle.Render("! "+s.err))
+		if s.target != "" {
+			lines = append(lines, m.footerStyle.Render("[ctrl+x] abort and restore the branch"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
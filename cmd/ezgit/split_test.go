@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	execpkg "ezgit/internal/exec"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// splitRepo makes base, a commit with two hunks in a.txt that also adds
+// b.txt, and one more commit on top. It returns the middle commit.
+func splitRepo(t *testing.T) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "Ada")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "ada@example.com")
+	}
+	gitT(t, "init", "-q", "-b", "main")
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	os.WriteFile("a.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	gitT(t, "add", "a.txt")
+	gitT(t, "commit", "-qm", "base")
+	lines[1], lines[17] = "line two", "line eighteen"
+	os.WriteFile("a.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	os.WriteFile("b.txt", []byte("b\n"), 0o644)
+	gitT(t, "add", "a.txt", "b.txt")
+	gitT(t, "commit", "-qm", "two changes and b")
+	os.WriteFile("c.txt", []byte("c\n"), 0o644)
+	gitT(t, "add", "c.txt")
+	gitT(t, "commit", "-qm", "add c")
+	return gitOut(t, "rev-parse", "HEAD~1")
+}
+
+func gitOut(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// step feeds msg to the model and returns the command it asks for.
+func step(t *testing.T, m *model, msg tea.Msg) tea.Cmd {
+	t.Helper()
+	_, cmd := m.Update(msg)
+	if m.split != nil && m.split.err != "" {
+		t.Fatalf("split: %s", m.split.err)
+	}
+	return cmd
+}
+
+func key(t tea.KeyType) tea.KeyMsg { return tea.KeyMsg{Type: t} }
+
+var space = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+
+// commitPiece checks the entries at idx, writes message and commits them,
+// returning the command that follows the commit.
+func commitPiece(t *testing.T, m *model, message string, idx ...int) tea.Cmd {
+	t.Helper()
+	for i := 0; i < m.split.hunks.Len(); i++ {
+		if len(idx) > 0 && idx[0] == i {
+			step(t, m, space)
+			idx = idx[1:]
+		} else {
+			step(t, m, key(tea.KeyDown))
+		}
+	}
+	step(t, m, key(tea.KeyEnter))
+	if m.split.step != splitMessage {
+		t.Fatalf("enter did not move to the message, step %d", m.split.step)
+	}
+	m.split.msg.SetValue(message)
+	cmd := step(t, m, key(tea.KeyCtrlS))
+	return step(t, m, step(t, m, cmd())())
+}
+
+func TestSplitFlow(t *testing.T) {
+	target := splitRepo(t)
+	before := gitOut(t, "rev-parse", "HEAD^{tree}")
+
+	m := testModel(&execpkg.Runner{})
+	m.openSplit()
+	loadDiff := step(t, m, startSplit(m.ctx(), target)())
+	if !repo.RebaseInProgress(context.Background()) {
+		t.Fatal("start did not stop the rebase on the target")
+	}
+	step(t, m, loadDiff())
+	if m.split.step != splitStage || m.split.hunks.Len() != 3 {
+		t.Fatalf("after start: step %d, %d entries", m.split.step, m.split.hunks.Len())
+	}
+
+	// the second hunk first, then the rest with the new file
+	if next := commitPiece(t, m, "eighteen", 1); next != nil {
+		t.Fatal("a piece left over should not finish the split")
+	}
+	if m.split.made != 1 || m.split.hunks.Len() != 2 {
+		t.Fatalf("after one piece: made %d, %d entries left", m.split.made, m.split.hunks.Len())
+	}
+	finish := commitPiece(t, m, "two and b", 0, 1)
+	if finish == nil {
+		t.Fatal("nothing left but the split did not finish")
+	}
+	step(t, m, finish())
+
+	if m.split != nil || m.mode != "verbs" {
+		t.Fatalf("split screen still open in mode %q", m.mode)
+	}
+	if got := gitOut(t, "log", "--format=%s"); got != "add c\ntwo and b\neighteen\nbase" {
+		t.Fatalf("history after split:\n%s", got)
+	}
+	if got := gitOut(t, "rev-parse", "HEAD^{tree}"); got != before {
+		t.Error("split changed the final tree")
+	}
+	if repo.RebaseInProgress(context.Background()) {
+		t.Error("rebase still in progress")
+	}
+}
+
+func TestSplitAbort(t *testing.T) {
+	target := splitRepo(t)
+	head := gitOut(t, "rev-parse", "HEAD")
+
+	m := testModel(&execpkg.Runner{})
+	m.openSplit()
+	loadDiff := step(t, m, startSplit(m.ctx(), target)())
+	step(t, m, loadDiff())
+	commitPiece(t, m, "eighteen", 1)
+
+	abort := step(t, m, key(tea.KeyCtrlX))
+	if abort == nil {
+		t.Fatal("ctrl+x did not abort")
+	}
+	step(t, m, abort())
+	if m.split != nil {
+		t.Fatal("split screen still open after abort")
+	}
+	if got := gitOut(t, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD = %s after abort, want %s", got, head)
+	}
+	if repo.RebaseInProgress(context.Background()) {
+		t.Error("rebase still in progress after abort")
+	}
+	if st := gitOut(t, "status", "--porcelain"); st != "" {
+		t.Errorf("worktree not clean after abort:\n%s", st)
+	}
+	if !strings.Contains(strings.Join(m.statusLines, "\n"), "split aborted") {
+		t.Errorf("status lines: %q", m.statusLines)
+	}
+}
+
+func TestSplitRefusesDirtyTree(t *testing.T) {
+	target := splitRepo(t)
+	os.WriteFile("a.txt", []byte("dirty\n"), 0o644)
+
+	m := testModel(&execpkg.Runner{})
+	m.openSplit()
+	m.Update(startSplit(m.ctx(), target)())
+	if m.split.err == "" || !strings.Contains(m.split.err, "uncommitted") {
+		t.Fatalf("split error = %q", m.split.err)
+	}
+	if repo.RebaseInProgress(context.Background()) {
+		t.Error("a refused split started a rebase")
+	}
+}
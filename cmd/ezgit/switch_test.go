@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+	execpkg "ezgit/internal/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFailedSwitchPopsTheStash(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	gitT(t, "init", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	gitT(t, "add", "a.txt")
+	gitT(t, "-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "-qm", "root")
+	gitT(t, "switch", "-q", "-c", "other")
+	os.WriteFile("u.txt", []byte("theirs\n"), 0o644)
+	gitT(t, "add", "u.txt")
+	gitT(t, "-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "-qm", "add u")
+	gitT(t, "switch", "-q", "main")
+	// the untracked u.txt is in the switch's way and stays out of the stash
+	os.WriteFile("u.txt", []byte("mine\n"), 0o644)
+	os.WriteFile("a.txt", []byte("a changed\n"), 0o644)
+	gitT(t, "add", "a.txt")
+
+	m := testModel(&execpkg.Runner{})
+	m.currentAction, _ = m.actions.Get("switch")
+	m.wizardInputs = action.ActionInput{"branch": "other", "dirty": "stash them (offered back when you return)"}
+	m.enterPreview()
+	m.Update(m.refreshPlan()())
+	if m.currentPlan() == nil {
+		t.Fatal("no plan")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	finishRun(t, m)
+
+	if b := gitOut(t, "branch", "--show-current"); b != "main" {
+		t.Fatalf("on %s, want the switch to have failed", b)
+	}
+	if st := gitOut(t, "status", "--porcelain"); st != "M  a.txt\n?? u.txt" {
+		t.Errorf("changes not put back:\n%s", st)
+	}
+	if l := gitOut(t, "stash", "list"); l != "" {
+		t.Errorf("stash left behind: %s", l)
+	}
+	if out := strings.Join(m.statusLines, "\n"); !strings.Contains(out, "putting back") {
+		t.Errorf("status lines:\n%s", out)
+	}
+}
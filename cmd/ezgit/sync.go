@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	syncPending = iota
+	syncRunning
+	syncDone
+	syncFailed
+)
+
+// syncer is "sync my work": commit what is dirty, rebase onto the
+// upstream, push. Each step goes through launch on its own so its result
+// is known; HEAD is backed up first and is the rollback point if a step
+// fails.
+type syncer struct {
+	target action.SyncTarget
+	files  []repo.FileStatus
+	msg    textinput.Model
+	loaded bool
+
+	steps  []action.SyncStep
+	state  []int
+	active int
+	// before is HEAD when sync started, backup the ref that keeps it and
+	// committed the commit sync made of the local changes.
+	before    string
+	backup    string
+	committed string
+	started   bool
+	finished  bool
+	// note says why the active step failed.
+	note string
+	err  string
+}
+
+type syncLoadedMsg struct {
+	Target action.SyncTarget
+	Files  []repo.FileStatus
+	Err    error
+}
+
+func loadSyncCmd(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		t, err := action.ResolveSync(ctx)
+		if err != nil {
+			return syncLoadedMsg{Err: err}
+		}
+		files, err := repo.Status(ctx)
+		return syncLoadedMsg{Target: t, Files: files, Err: err}
+	}
+}
+
+func (m *model) openSync() tea.Cmd {
+	s := &syncer{msg: textinput.New()}
+	s.msg.Prompt = "message> "
+	s.msg.Placeholder = "what did you change?"
+	s.msg.CharLimit = 200
+	s.msg.Width = 60
+	m.sync = s
+	m.mode = "sync"
+	return loadSyncCmd(m.ctx())
+}
+
+func (m *model) handleSyncLoaded(msg syncLoadedMsg) (tea.Model, tea.Cmd) {
+	s := m.sync
+	if s == nil {
+		return m, nil
+	}
+	s.loaded = true
+	if msg.Err != nil {
+		s.err = msg.Err.Error()
+		return m, nil
+	}
+	s.target, s.files = msg.Target, msg.Files
+	if len(s.files) > 0 {
+		return m, s.msg.Focus()
+	}
+	return m, nil
+}
+
+// startSync records the rollback point and runs the first step.
+func (m *model) startSync() (tea.Model, tea.Cmd) {
+	s := m.sync
+	message := strings.TrimSpace(s.msg.Value())
+	if len(s.files) > 0 && message == "" {
+		s.err = "write a commit message for your changes"
+		return m, nil
+	}
+	ctx := m.ctx()
+	if repo.RebaseInProgress(ctx) || repo.MergeInProgress(ctx) {
+		s.err = "a rebase or merge is already in progress; finish it first"
+		return m, nil
+	}
+	if head, err := repo.RevParse(ctx, "HEAD"); err == nil {
+		s.before = head
+		backup, err := repo.Backup(ctx, "sync")
+		if err != nil {
+			s.err = err.Error()
+			return m, nil
+		}
+		s.backup = backup
+	}
+	s.msg.Blur()
+	s.steps = action.SyncSteps(s.target, message)
+	s.state = make([]int, len(s.steps))
+	s.active = 0
+	s.started = true
+	return m.syncNext()
+}
+
+func (m *model) syncNext() (tea.Model, tea.Cmd) {
+	s := m.sync
+	st := s.steps[s.active]
+	s.state[s.active] = syncRunning
+	s.note = ""
+	m.statusLines = append(m.statusLines, fmt.Sprintf("[sync %d/%d: %s]", s.active+1, len(s.steps), st.Name))
+	next, cmd := m.runSteps([]action.Step{st.Step})
+	if m.mode == "sync" && !m.running {
+		s.fail("blocked by policy")
+	}
+	return next, cmd
+}
+
+func (s *syncer) fail(note string) {
+	s.state[s.active] = syncFailed
+	s.note = note
+}
+
+// syncStepDone is called when the running step's command ends.
+func (m *model) syncStepDone(ok bool) (tea.Model, tea.Cmd) {
+	s := m.sync
+	if !s.started || s.finished || s.state[s.active] != syncRunning {
+		return m, nil
+	}
+	ctx := m.ctx()
+	if !ok {
+		if s.steps[s.active].Name == "rebase" && len(repo.Conflicts(ctx)) > 0 {
+			m.statusLines = append(m.statusLines, "[sync: the rebase hit conflicts; resolve them to go on]")
+			return m, m.openConflicts("sync")
+		}
+		s.fail(s.steps[s.active].Name + " failed; see the output")
+		return m, nil
+	}
+	if s.steps[s.active].Name == "commit" {
+		s.committed, _ = repo.RevParse(ctx, "HEAD")
+	}
+	return m.syncAdvance()
+}
+
+func (m *model) syncAdvance() (tea.Model, tea.Cmd) {
+	s := m.sync
+	s.state[s.active] = syncDone
+	s.active++
+	if s.active < len(s.steps) {
+		return m.syncNext()
+	}
+	s.finished = true
+	m.statusLines = append(m.statusLines, "[sync: your work is on "+s.target.Remote+"]")
+	return m, nil
+}
+
+// syncAfterConflicts picks up once the conflict screen closes.
+func (m *model) syncAfterConflicts(aborted bool) (tea.Model, tea.Cmd) {
+	s := m.sync
+	switch {
+	case aborted:
+		s.fail("rebase aborted; your commits are as they were before it")
+	case repo.RebaseInProgress(m.ctx()):
+		s.fail("the rebase is still stopped on conflicts")
+	default:
+		return m.syncAdvance()
+	}
+	return m, nil
+}
+
+func (m *model) updateSync(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.sync
+	k := msg.String()
+	s.err = ""
+	if !s.started {
+		switch k {
+		case "esc":
+			m.sync = nil
+			m.mode = "verbs"
+			return m, nil
+		case "enter":
+			if !s.loaded || s.target.Branch == "" {
+				return m, nil
+			}
+			return m.startSync()
+		}
+		var cmd tea.Cmd
+		s.msg, cmd = s.msg.Update(msg)
+		return m, cmd
+	}
+	failed := !s.finished && s.state[s.active] == syncFailed
+	switch k {
+	case "esc", "enter":
+		if failed && s.backup != "" {
+			m.statusLines = append(m.statusLines, "[sync stopped at "+s.steps[s.active].Name+"; rollback point "+s.backup+"]")
+		}
+		m.sync = nil
+		m.mode = "verbs"
+		return m, nil
+	case "r":
+		if failed {
+			if s.steps[s.active].Name == "rebase" && repo.RebaseInProgress(m.ctx()) {
+				return m, m.openConflicts("sync")
+			}
+			return m.syncNext()
+		}
+	case "u":
+		if failed && s.before != "" {
+			steps := action.SyncRollback(m.ctx(), s.before, s.committed)
+			m.statusLines = append(m.statusLines, "[sync: rolling back to "+s.before[:7]+"]")
+			m.sync = nil
+			m.mode = "verbs"
+			return m.runSteps(steps)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderSync() string {
+	s := m.sync
+	if s == nil {
+		return ""
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	dim := m.footerStyle
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	yellow := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	lines := []string{bold.Render("Sync my work")}
+	if !s.loaded {
+		return strings.Join(append(lines, dim.Render("(looking at your changes...)")), "\n")
+	}
+	if s.target.Branch == "" {
+		return strings.Join(append(lines, red.Render("! "+s.err), "", dim.Render("[esc] back")), "\n")
+	}
+	dest := s.target.Upstream
+	if dest == "" {
+		dest = s.target.Remote + "/" + s.target.Branch + " (new)"
+	}
+	lines = append(lines, dim.Render(s.target.Branch+" → "+dest), "")
+
+	if !s.started {
+		if len(s.files) == 0 {
+			lines = append(lines, "Nothing to commit; your existing commits will be rebased and pushed.")
+		} else {
+			lines = append(lines, fmt.Sprintf("%d changed file(s) will be committed:", len(s.files)))
+			for i, f := range s.files {
+				if i == 15 {
+					lines = append(lines, dim.Render(fmt.Sprintf("  ... and %d more", len(s.files)-15)))
+					break
+				}
+				lines = append(lines, "  "+yellow.Render(f.Code())+" "+f.Path)
+			}
+			lines = append(lines, "", s.msg.View())
+		}
+		lines = append(lines, "", dim.Render("[enter] sync • [esc] back"))
+		if s.err != "" {
+			lines = append(lines, red.Render("! "+s.err))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	done := 0
+	for _, st := range s.state {
+		if st == syncDone {
+			done++
+		}
+	}
+	bar := strings.Repeat("■", done) + strings.Repeat("□", len(s.steps)-done)
+	lines = append(lines, fmt.Sprintf("%s %d/%d", bar, done, len(s.steps)), "")
+	for i, st := range s.steps {
+		mark := dim.Render("·")
+		switch s.state[i] {
+		case syncRunning:
+			mark = yellow.Render("▸")
+		case syncDone:
+			mark = green.Render("✓")
+		case syncFailed:
+			mark = red.Render("✗")
+		}
+		lines = append(lines, fmt.Sprintf("%s %-7s %s", mark, st.Name, dim.Render("git "+strings.Join(st.Args, " "))))
+	}
+	if s.backup != "" {
+		lines = append(lines, "", "Rollback point: "+s.before[:7]+dim.Render(" ("+s.backup+")"))
+	}
+	switch {
+	case s.finished:
+		lines = append(lines, "", green.Render("Synced."), dim.Render("[enter/esc] close"))
+	case s.state[s.active] == syncFailed:
+		lines = append(lines, "", red.Render("✗ "+s.note))
+		keys := "[r] retry • [esc] leave it as it is"
+		if s.before != "" {
+			keys = "[u] roll back to the rollback point • " + keys
+		}
+		lines = append(lines, dim.Render(keys))
+	}
+	if s.err != "" {
+		lines = append(lines, red.Render("! "+s.err))
+	}
+	return strings.Join(lines, "\n")
+}
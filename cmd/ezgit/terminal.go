@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	execpkg "ezgit/internal/exec"
+	"ezgit/internal/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// terminalDoneMsg reports a command that ran attached to the terminal.
+type terminalDoneMsg struct {
+	Label string
+	Exit  int
+	Err   error
+}
+
+// wantsTerminal reports whether the pending command has to run on the real
+// terminal: its action said so, or its argv opens an editor or prompt.
+func (m *model) wantsTerminal() bool {
+	return m.pendingTerminal || (m.pendingCmd == "git" && execpkg.NeedsTerminal(m.pendingArgs))
+}
+
+// execInTerminal suspends the TUI and runs the pending command through the
+// model's executor with the real stdin, stdout and stderr. Nothing is
+// captured; the exit status is all that comes back.
+func (m *model) execInTerminal() tea.Cmd {
+	m.streamLines = nil
+	m.mode = "running"
+	m.running = true
+	m.runCancel = nil
+	m.currentRunCmd = nil
+	label := m.pendingCmd + " " + parser.JoinArgs(m.pendingArgs)
+	c := &terminalCmd{ctx: m.ctx(), exec: m.exec, cmd: execpkg.Cmd{Name: m.pendingCmd, Args: m.pendingArgs}}
+	return tea.Exec(c, func(err error) tea.Msg {
+		return terminalDoneMsg{Label: label, Exit: c.res.Exit, Err: err}
+	})
+}
+
+// terminalCmd is a command for tea.Exec that runs through an Executor,
+// attached to the streams the program hands it.
+type terminalCmd struct {
+	ctx  context.Context
+	exec execpkg.Executor
+	cmd  execpkg.Cmd
+	res  execpkg.Result
+}
+
+func (c *terminalCmd) SetStdin(r io.Reader)  { c.cmd.Stdin = r }
+func (c *terminalCmd) SetStdout(w io.Writer) { c.cmd.Stdout = w }
+func (c *terminalCmd) SetStderr(w io.Writer) { c.cmd.Stderr = w }
+
+func (c *terminalCmd) Run() error {
+	var err error
+	c.res, err = c.exec.Exec(c.ctx, c.cmd)
+	return err
+}
+
+func (m *model) handleTerminalDone(msg terminalDoneMsg) (tea.Model, tea.Cmd) {
+	m.statusLines = append(m.statusLines, fmt.Sprintf("[ran %s in the terminal]", msg.Label))
+	done := actionDoneMsg{Exit: msg.Exit}
+	if msg.Exit < 0 {
+		// never started or was killed by a signal
+		done.Err = msg.Err
+	}
+	return m.Update(done)
+}
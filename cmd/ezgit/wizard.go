@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ezgit/internal/action"
+	"ezgit/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type refsLoadedMsg struct {
+	Prompt int
+	Items  []tui.RefItem
+}
+
+// beginPrompt readies the widget for the prompt at promptIndex: an option
+// list for bool/enum/multi-select, the text area for multiline, otherwise
+// the line input, with a ref picker loading in the background for ref
+// types.
+func (m *model) beginPrompt() tea.Cmd {
+	m.input.SetValue("")
+	m.input.Focus()
+	m.picker = nil
+	m.options = nil
+	m.files = nil
+	m.filtering = false
+	m.wizardErr = ""
+	m.area.Blur()
+	if m.currentAction == nil {
+		return nil
+	}
+	for m.promptIndex < len(m.currentAction.Prompts) && !m.currentAction.Prompts[m.promptIndex].Asked(m.wizardInputs) {
+		delete(m.wizardInputs, m.currentAction.Prompts[m.promptIndex].Key)
+		m.promptIndex++
+	}
+	if m.promptIndex >= len(m.currentAction.Prompts) {
+		m.enterPreview()
+		return nil
+	}
+	p := m.currentAction.Prompts[m.promptIndex]
+	m.input.Placeholder = p.Placeholder
+	switch p.Type {
+	case action.PromptBool:
+		def := "no"
+		if b, _ := action.ParseBool(p.Default); b {
+			def = "yes"
+		}
+		m.options = tui.NewOptionList([]string{"yes", "no"}, false, def)
+	case action.PromptEnum:
+		m.options = tui.NewOptionList(p.Options, false, p.Default)
+	case action.PromptMultiSelect:
+		m.options = tui.NewOptionList(p.Options, true, p.Default)
+	case action.PromptMultiline:
+		m.input.Blur()
+		m.area.SetValue(p.Default)
+		m.area.Placeholder = p.Placeholder
+		return m.area.Focus()
+	}
+	if m.options != nil {
+		m.input.Blur()
+		return nil
+	}
+	if p.Type.IsFiles() {
+		m.input.Blur()
+		m.input.Placeholder = "filter files"
+		m.files = tui.NewFileList(nil)
+		return loadFilesCmd(m.ctx(), m.promptIndex, p.Type)
+	}
+	kinds := tui.RefKindsFor(p.Type)
+	if len(kinds) == 0 {
+		return nil
+	}
+	m.picker = tui.NewRefPicker(nil)
+	idx := m.promptIndex
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx(), 5*time.Second)
+		defer cancel()
+		return refsLoadedMsg{Prompt: idx, Items: tui.LoadRefs(ctx, kinds...)}
+	}
+}
+
+func (m *model) updateWizard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.currentAction == nil || m.promptIndex >= len(m.currentAction.Prompts) {
+		m.enterPreview()
+		return m, nil
+	}
+	p := m.currentAction.Prompts[m.promptIndex]
+	k := msg.String()
+	if m.files != nil {
+		return m.updateFileList(msg)
+	}
+	if k == "esc" {
+		m.mode = "verbs"
+		m.picker, m.options = nil, nil
+		m.input.Blur()
+		m.area.Blur()
+		return m, nil
+	}
+
+	switch {
+	case m.options != nil:
+		if p.Type == action.PromptBool && (k == "y" || k == "n") {
+			m.options.Cursor = map[string]int{"y": 0, "n": 1}[k]
+			return m.answerPrompt(m.options.Value())
+		}
+		if k == "enter" {
+			return m.answerPrompt(m.options.Value())
+		}
+		m.options.Update(msg)
+		return m, nil
+	case p.Type == action.PromptMultiline:
+		if k == "ctrl+s" || k == "ctrl+d" {
+			return m.answerPrompt(m.area.Value())
+		}
+		var cmd tea.Cmd
+		m.area, cmd = m.area.Update(msg)
+		return m, cmd
+	}
+
+	if m.picker != nil {
+		if m.picker.Update(msg) {
+			return m, nil
+		}
+		if k == "tab" {
+			if it, ok := m.picker.Selected(); ok {
+				m.input.SetValue(it.Name)
+				m.input.CursorEnd()
+				m.picker.SetFilter(it.Name)
+			}
+			return m, nil
+		}
+	}
+	if k == "tab" && p.Type == action.PromptPath {
+		m.input.SetValue(tui.CompletePath(m.input.Value()))
+		m.input.CursorEnd()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	before := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.picker != nil && m.input.Value() != before {
+		m.picker.SetFilter(m.input.Value())
+	}
+	if k != "enter" {
+		return m, cmd
+	}
+	v := strings.TrimSpace(m.input.Value())
+	if m.picker != nil {
+		v = m.picker.Choice(v)
+	}
+	if v == "" {
+		v = p.Default
+	}
+	next, next2 := m.answerPrompt(v)
+	return next, tea.Batch(cmd, next2)
+}
+
+// answerPrompt validates v for the current prompt, stores its normalized
+// form and moves on to the next prompt or the preview.
+func (m *model) answerPrompt(v string) (tea.Model, tea.Cmd) {
+	p := m.currentAction.Prompts[m.promptIndex]
+	nv, err := p.Normalize(v)
+	if err == nil && p.Required && strings.TrimSpace(nv) == "" {
+		err = fmt.Errorf("required")
+	}
+	if err != nil {
+		m.wizardErr = err.Error()
+		return m, nil
+	}
+	m.wizardInputs[p.Key] = nv
+	m.promptIndex++
+	return m, m.beginPrompt()
+}
+
+// enterPreview leaves the wizard for the preview. The plan and its
+// warnings are worked out by refreshPlan, since they may need to ask git.
+func (m *model) enterPreview() {
+	m.picker, m.options, m.files = nil, nil, nil
+	m.wizardErr = ""
+	m.mode = "preview"
+	m.input.Blur()
+	m.area.Blur()
+	m.warnings = nil
+	m.planWant = ""
+}
+
+// lastAskedPrompt is the index of the last prompt that applies to the
+// current answers, for going back from the preview.
+func (m *model) lastAskedPrompt() int {
+	ps := m.currentAction.Prompts
+	for i := len(ps) - 1; i > 0; i-- {
+		if ps[i].Asked(m.wizardInputs) {
+			return i
+		}
+	}
+	return 0
+}
+
+// currentAnswer is what the prompt on screen would store if accepted now,
+// for the live preview.
+func (m model) currentAnswer(p action.Prompt) string {
+	switch {
+	case m.files != nil:
+		return m.fileAnswer(p)
+	case m.options != nil:
+		return m.options.Value()
+	case p.Type == action.PromptMultiline:
+		return m.area.Value()
+	}
+	return strings.TrimSpace(m.input.Value())
+}
+
+func (m model) renderWizard() string {
+	if m.currentAction == nil {
+		return lipgloss.NewStyle().Render("(no action selected)")
+	}
+	if len(m.currentAction.Prompts) == 0 || m.promptIndex >= len(m.currentAction.Prompts) {
+		return lipgloss.NewStyle().Render("(no prompts for this action)")
+	}
+	p := m.currentAction.Prompts[m.promptIndex]
+	hdr := lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Prompt %d/%d", m.promptIndex+1, len(m.currentAction.Prompts)))
+	label := p.Label
+	if label == "" {
+		label = p.Key
+	}
+	def := ""
+	if p.Default != "" && m.options == nil && m.files == nil && p.Type != action.PromptMultiline {
+		def = fmt.Sprintf(" (default: %s)", p.Default)
+	}
+	var previewLines []string
+	if m.plan != nil && m.plan.action == m.currentAction.Name {
+		// the last plan stands in while the next one is worked out
+		previewLines = m.plan.lines
+	}
+	previewHdr := lipgloss.NewStyle().Bold(true).Render("Preview")
+	previewText := "(no preview)"
+	if len(previewLines) > 0 {
+		previewText = strings.Join(previewLines, "\n")
+	}
+
+	parts := []string{hdr, label + def}
+	switch {
+	case m.files != nil:
+		parts = append(parts, m.renderFileList()...)
+	case m.options != nil:
+		keys := "[↑/↓] move • [enter] accept"
+		if p.Type == action.PromptBool {
+			keys = "[y/n] answer • [↑/↓] move • [enter] accept"
+		} else if m.options.Multi {
+			keys = "[space] toggle • [a] all • [enter] accept"
+		}
+		parts = append(parts, m.options.View(), m.footerStyle.Render(keys))
+	case p.Type == action.PromptMultiline:
+		parts = append(parts, m.area.View(), m.footerStyle.Render("[ctrl+s] accept • [esc] cancel"))
+	default:
+		parts = append(parts, m.input.View())
+		if p.Type == action.PromptPath {
+			parts = append(parts, m.footerStyle.Render("[tab] complete path"))
+		}
+	}
+	if m.picker != nil {
+		parts = append(parts, "", m.picker.View(), m.footerStyle.Render("[↑/↓] pick • [tab] fill • [enter] accept"))
+	}
+	if m.wizardErr != "" {
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Render("! "+m.wizardErr))
+	}
+	parts = append(parts, "", previewHdr, previewText)
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
@@ -1,9 +1,16 @@
 package action
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+
+	execpkg "ezgit/internal/exec"
+	"ezgit/internal/parser"
+	"ezgit/internal/repo"
+	"ezgit/internal/scaffold"
 )
 
 type ActionInput map[string]string
@@ -16,6 +23,26 @@ type ActionDef struct {
 	BuildFunc     func(ActionInput) (cmd string, args []string, preview string)
 	ValidateFunc  func(ActionInput) error
 	IsDestructive func(ActionInput) bool
+	// NeedsTerminal reports that the command opens an editor or prompts,
+	// so it must run on the real terminal instead of with piped output.
+	// Commands are also detected from their argv; this is for the ones
+	// that cannot be.
+	NeedsTerminal func(ActionInput) bool
+	// PlanFunc, if set, returns every command the action runs, in order;
+	// BuildFunc then describes the first one.
+	PlanFunc func(ActionInput) []Step
+	// Warnings are shown on the preview before anything runs.
+	Warnings func(ActionInput) []string
+	Custom   bool
+	// Screen names a dedicated TUI screen that replaces the prompt wizard
+	// for this action. Line-mode front ends still use Prompts.
+	Screen string
+	// Files, if set, returns files to create in dir before the steps run.
+	// Files that already exist are kept as they are.
+	Files func(ActionInput) (dir string, files []scaffold.File)
+	// Chdir, if set, names the directory to work in once the action has
+	// succeeded, such as the repository a clone created.
+	Chdir func(ActionInput) string
 }
 
 type Prompt struct {
@@ -24,6 +51,19 @@ type Prompt struct {
 	Default     string
 	Placeholder string
 	Required    bool
+	Type        PromptType
+	// Options are the choices of an enum or multi-select prompt.
+	Options []string
+	// Validate checks the normalized answer after the type check.
+	Validate func(string) error
+	// When, if set, decides from the earlier answers whether the prompt is
+	// asked at all.
+	When func(ActionInput) bool
+}
+
+// Asked reports whether p applies given the answers so far.
+func (p Prompt) Asked(in ActionInput) bool {
+	return p.When == nil || p.When(in)
 }
 
 var DefaultRegistry = NewRegistry()
@@ -36,11 +76,20 @@ func (p Prompt) Ask(inputs ActionInput) string {
 }
 
 func (a *ActionDef) Validate(inputs ActionInput) error {
+	for _, p := range a.Prompts {
+		v, ok := inputs[p.Key]
+		if !ok {
+			continue
+		}
+		if _, err := p.Normalize(v); err != nil {
+			return fmt.Errorf("%s: %v", p.Key, err)
+		}
+	}
 	if a.ValidateFunc != nil {
 		return a.ValidateFunc(inputs)
 	}
 	for _, p := range a.Prompts {
-		if p.Required {
+		if p.Required && p.Asked(inputs) {
 			v := strings.TrimSpace(inputs[p.Key])
 			if v == "" {
 				return fmt.Errorf("missing required input: %s", p.Key)
@@ -58,7 +107,7 @@ func (a *ActionDef) Preview(inputs ActionInput) []string {
 		if preview != "" {
 			previews = append(previews, preview)
 		} else if cmd != "" {
-			previews = append(previews, fmt.Sprintf("%s %s", cmd, strings.Join(args, " ")))
+			previews = append(previews, cmd+" "+parser.JoinArgs(args))
 		}
 		return previews
 	}
@@ -76,28 +125,77 @@ func (a *ActionDef) Build(inputs ActionInput) (string, []string, string) {
 	return "", nil, ""
 }
 
+// Step is one command of a multi-command action. Undo puts back what the
+// earlier steps did when this one fails, e.g. pops the stash a failed
+// switch was meant to carry.
+type Step struct {
+	Cmd  string
+	Args []string
+	Undo []Step
+}
+
+// Steps returns the commands to run for inputs: the plan when the action
+// has one, otherwise the single command from Build.
+func (a *ActionDef) Steps(inputs ActionInput) []Step {
+	if a.PlanFunc != nil {
+		return a.PlanFunc(inputs)
+	}
+	cmd, args, _ := a.Build(inputs)
+	if cmd == "" {
+		return nil
+	}
+	return []Step{{Cmd: cmd, Args: args}}
+}
+
 type Registry struct {
+	mu      sync.RWMutex
 	actions map[string]*ActionDef
+	exec    execpkg.Executor
 }
 
 func NewRegistry() *Registry {
-	return &Registry{actions: make(map[string]*ActionDef)}
+	return &Registry{actions: make(map[string]*ActionDef), exec: &execpkg.Runner{}}
+}
+
+// SetExecutor makes the git queries of the registered builtins, for their
+// previews, plans and checks, run through e.
+func (r *Registry) SetExecutor(e execpkg.Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exec = e
+}
+
+func (r *Registry) Executor() execpkg.Executor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.exec
+}
+
+// Context is what the builtins run their git queries under.
+func (r *Registry) Context() context.Context {
+	return repo.WithExecutor(context.Background(), r.Executor())
 }
 
 func (r *Registry) Register(a *ActionDef) error {
 	if a == nil || a.Name == "" {
 		return errors.New("invalid action")
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.actions[a.Name] = a
 	return nil
 }
 
 func (r *Registry) Get(name string) (*ActionDef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	a, ok := r.actions[name]
 	return a, ok
 }
 
 func (r *Registry) List() []*ActionDef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	res := make([]*ActionDef, 0, len(r.actions))
 	for _, v := range r.actions {
 		res = append(res, v)
@@ -105,6 +203,39 @@ func (r *Registry) List() []*ActionDef {
 	return res
 }
 
+// ReplaceCustom drops every previously registered custom action and
+// registers defs instead. Custom actions may not shadow builtins; if any
+// does, nothing is replaced and the clashes are reported in the error.
+func (r *Registry) ReplaceCustom(defs []*ActionDef) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var clashes []string
+	for _, a := range defs {
+		if a == nil || a.Name == "" {
+			continue
+		}
+		if old, taken := r.actions[a.Name]; taken && !old.Custom {
+			clashes = append(clashes, a.Name)
+		}
+	}
+	if len(clashes) > 0 {
+		return fmt.Errorf("custom actions shadow builtins: %s", strings.Join(clashes, ", "))
+	}
+	for name, a := range r.actions {
+		if a.Custom {
+			delete(r.actions, name)
+		}
+	}
+	for _, a := range defs {
+		if a == nil || a.Name == "" {
+			continue
+		}
+		a.Custom = true
+		r.actions[a.Name] = a
+	}
+	return nil
+}
+
 type AuditEntry struct {
 	Timestamp   any
 	Command     string
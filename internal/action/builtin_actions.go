@@ -3,6 +3,8 @@ package action
 import (
 	"fmt"
 	"strings"
+
+	"ezgit/internal/parser"
 )
 
 const (
@@ -22,35 +24,38 @@ func registerPassthrough(r *Registry, cat int, name, help string, prompts []Prom
 		Prompts:  prompts,
 		BuildFunc: func(in ActionInput) (string, []string, string) {
 			args := append([]string{}, defaultArgs...)
-			if extra, ok := in["args"]; ok && strings.TrimSpace(extra) != "" {
-				parts := strings.Fields(extra)
-				args = append(args, parts...)
+			extra, err := userArgs(in["args"])
+			if err != nil {
+				return "git", args, previewArgs(args) + " (args: " + err.Error() + ")"
 			}
-			preview := "git " + strings.Join(args, " ")
-			return "git", args, preview
+			args = append(args, extra...)
+			return "git", args, previewArgs(args)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			_, err := userArgs(in["args"])
+			if err != nil {
+				return fmt.Errorf("args: %v", err)
+			}
+			return nil
 		},
 	})
 }
 
+// userArgs tokenizes argument text typed by the user with shell quoting
+// rules, so `-m "fix the bug"` stays one argument.
+func userArgs(s string) ([]string, error) {
+	return parser.SplitArgs(strings.TrimSpace(s))
+}
+
+// previewArgs shows argv exactly as it will be passed to git.
+func previewArgs(args []string) string {
+	return "git " + parser.JoinArgs(args)
+}
+
+var resetModes = []string{"soft", "mixed", "hard"}
+
 func RegisterBuiltins(r *Registry) {
-	r.Register(&ActionDef{
-		Name:     "init",
-		Help:     "Initialize a new git repository and optional README",
-		Category: CatRepository,
-		Prompts: []Prompt{
-			{Key: "path", Label: "Directory", Default: ".", Required: true},
-			{Key: "readme", Label: "Create README? (y/N)", Default: "y"},
-		},
-		BuildFunc: func(in ActionInput) (string, []string, string) {
-			path := in["path"]
-			readme := strings.ToLower(in["readme"])
-			preview := fmt.Sprintf("git init %s", path)
-			if readme == "y" || readme == "yes" {
-				preview = preview + " && echo \"# Project\" > README.md && git add README.md && git commit -m \"initial commit\""
-			}
-			return "git", []string{"init", path}, preview
-		},
-	})
+	registerInit(r)
 
 	r.Register(&ActionDef{
 		Name:     "status",
@@ -62,105 +67,114 @@ func RegisterBuiltins(r *Registry) {
 		},
 	})
 
+	registerIgnore(r)
+
 	r.Register(&ActionDef{
 		Name:     "add",
 		Help:     "Stage files",
 		Category: CatWork,
 		Prompts: []Prompt{
-			{Key: "paths", Label: "Files to add (comma or space separated)", Default: "-A", Required: true},
+			{Key: "paths", Label: "Files to stage (nothing picked stages everything)", Type: PromptFiles},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			paths := in["paths"]
-			var args []string
-			if paths == "" || paths == "-A" {
-				args = []string{"add", "-A"}
-			} else {
-				parts := splitPaths(paths)
-				args = append([]string{"add"}, parts...)
+			paths := in.Paths("paths")
+			args := []string{"add", "-A"}
+			if len(paths) > 0 {
+				args = append([]string{"add", "--"}, paths...)
 			}
-			return "git", args, "git " + strings.Join(args, " ")
+			return "git", args, previewArgs(args)
 		},
 	})
 
 	r.Register(&ActionDef{
-		Name:     "commit",
-		Help:     "Create a commit",
+		Name:     "restore",
+		Help:     "Restore working tree files (git restore)",
 		Category: CatWork,
 		Prompts: []Prompt{
-			{Key: "message", Label: "Commit message", Default: "", Required: true},
-			{Key: "stage", Label: "Stage all changes first? (y/N)", Default: "y"},
+			{Key: "paths", Label: "Files to restore", Required: true, Type: PromptFiles},
+			{Key: "staged", Label: "Only unstage them (keep the edits)?", Default: "true", Type: PromptBool},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			msg := in["message"]
-			stage := strings.ToLower(in["stage"])
-			preview := ""
-			if stage == "y" || stage == "yes" {
-				preview = fmt.Sprintf("git add -A && git commit -m %q", msg)
-			} else {
-				preview = fmt.Sprintf("git commit -m %q", msg)
+			args := []string{"restore"}
+			if in.Bool("staged") {
+				args = append(args, "--staged")
 			}
-			return "git", []string{"commit", "-m", msg}, preview
+			args = append(append(args, "--"), in.Paths("paths")...)
+			return "git", args, previewArgs(args)
+		},
+		IsDestructive: func(in ActionInput) bool {
+			return !in.Bool("staged")
 		},
 	})
 
 	r.Register(&ActionDef{
-		Name:     "push",
-		Help:     "Push to remote",
-		Category: CatRemotes,
+		Name:     "rm",
+		Help:     "Remove files from tree/index",
+		Category: CatWork,
 		Prompts: []Prompt{
-			{Key: "remote", Label: "Remote name", Default: "origin"},
-			{Key: "branch", Label: "Branch", Default: "main"},
-			{Key: "force", Label: "Force push? (y/N)", Default: "n"},
+			{Key: "paths", Label: "Files to remove", Required: true, Type: PromptTracked},
+			{Key: "cached", Label: "Keep the files on disk (--cached)?", Default: "false", Type: PromptBool},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			remote := in["remote"]
-			branch := in["branch"]
-			force := strings.ToLower(in["force"])
-			if force == "y" || force == "yes" {
-				args := []string{"push", "--force", remote, branch}
-				return "git", args, "git push --force " + remote + " " + branch
+			args := []string{"rm"}
+			if in.Bool("cached") {
+				args = append(args, "--cached")
 			}
-			args := []string{"push", remote, branch}
-			return "git", args, "git push " + remote + " " + branch
+			args = append(append(args, "--"), in.Paths("paths")...)
+			return "git", args, previewArgs(args)
+		},
+	})
+
+	r.Register(&ActionDef{
+		Name:     "mv",
+		Help:     "Move/rename files in index",
+		Category: CatWork,
+		Prompts: []Prompt{
+			{Key: "paths", Label: "Files to move", Required: true, Type: PromptTracked},
+			{Key: "dest", Label: "Destination (new name, or a directory for several files)", Required: true, Type: PromptPath},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			args := append([]string{"mv", "--"}, in.Paths("paths")...)
+			args = append(args, in["dest"])
+			return "git", args, previewArgs(args)
 		},
 		ValidateFunc: func(in ActionInput) error {
-			if strings.ContainsAny(in["remote"], " \t\n\r") {
-				return fmt.Errorf("invalid remote name")
-			}
-			if strings.ContainsAny(in["branch"], " \t\n\r") {
-				return fmt.Errorf("invalid branch name")
+			if len(in.Paths("paths")) == 0 || strings.TrimSpace(in["dest"]) == "" {
+				return fmt.Errorf("pick files to move and a destination")
 			}
 			return nil
 		},
-		IsDestructive: func(in ActionInput) bool {
-			force := strings.ToLower(in["force"])
-			return force == "y" || force == "yes"
-		},
 	})
 
 	r.Register(&ActionDef{
-		Name:     "clone",
-		Help:     "Clone a repository",
-		Category: CatRepository,
+		Name:     "commit",
+		Help:     "Create a commit",
+		Category: CatWork,
+		Screen:   "commit",
 		Prompts: []Prompt{
-			{Key: "url", Label: "Repository URL", Required: true},
-			{Key: "path", Label: "Directory (optional)", Default: ""},
+			{Key: "message", Label: "Commit message", Default: "", Required: true, Type: PromptMultiline},
+			{Key: "all", Label: "Commit all tracked changes (-a)?", Default: "false", Type: PromptBool},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			args := []string{"clone", in["url"]}
-			if p := in["path"]; p != "" {
-				args = append(args, p)
+			args := []string{"commit"}
+			if in.Bool("all") {
+				args = append(args, "-a")
 			}
-			return "git", args, "git " + strings.Join(args, " ")
+			args = append(args, "-m", in["message"])
+			return "git", args, previewArgs(args)
 		},
 	})
 
+	registerPush(r)
+
+	registerClone(r)
+
 	r.Register(&ActionDef{
 		Name:     "undo",
 		Help:     "Undo last commit (soft/mixed/hard) — convenience wrapper for HEAD~1",
 		Category: CatHistory,
 		Prompts: []Prompt{
-			{Key: "mode", Label: "Mode (soft/mixed/hard)", Default: "mixed", Required: true},
+			{Key: "mode", Label: "Mode", Default: "mixed", Required: true, Type: PromptEnum, Options: resetModes},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
 			mode := in["mode"]
@@ -173,7 +187,7 @@ func RegisterBuiltins(r *Registry) {
 			default:
 				args = append(args, "--mixed", "HEAD~1")
 			}
-			return "git", args, "git " + strings.Join(args, " ")
+			return "git", args, previewArgs(args)
 		},
 		IsDestructive: func(in ActionInput) bool {
 			return strings.ToLower(in["mode"]) == "hard"
@@ -185,8 +199,8 @@ func RegisterBuiltins(r *Registry) {
 		Help:     "Reset current branch (soft/mixed/hard) to a specified ref",
 		Category: CatHistory,
 		Prompts: []Prompt{
-			{Key: "mode", Label: "Mode (soft/mixed/hard)", Default: "mixed", Required: true},
-			{Key: "ref", Label: "Reference (e.g. HEAD~1 or origin/main)", Default: "HEAD~1", Required: true},
+			{Key: "mode", Label: "Mode", Default: "mixed", Required: true, Type: PromptEnum, Options: resetModes},
+			{Key: "ref", Label: "Reference (e.g. HEAD~1 or origin/main)", Default: "HEAD~1", Required: true, Type: PromptRef},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
 			mode := in["mode"]
@@ -200,7 +214,7 @@ func RegisterBuiltins(r *Registry) {
 			default:
 				args = append(args, "--mixed", ref)
 			}
-			return "git", args, "git " + strings.Join(args, " ")
+			return "git", args, previewArgs(args)
 		},
 		IsDestructive: func(in ActionInput) bool {
 			return strings.ToLower(in["mode"]) == "hard"
@@ -215,13 +229,19 @@ func RegisterBuiltins(r *Registry) {
 			{Key: "args", Label: "clean args (e.g. -fd)", Default: "-n"},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			extra := strings.TrimSpace(in["args"])
 			args := []string{"clean"}
-			if extra != "" {
-				parts := strings.Fields(extra)
-				args = append(args, parts...)
+			extra, err := userArgs(in["args"])
+			if err != nil {
+				return "git", args, previewArgs(args) + " (args: " + err.Error() + ")"
+			}
+			args = append(args, extra...)
+			return "git", args, previewArgs(args)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if _, err := userArgs(in["args"]); err != nil {
+				return fmt.Errorf("args: %v", err)
 			}
-			return "git", args, "git " + strings.Join(args, " ")
+			return nil
 		},
 		IsDestructive: func(in ActionInput) bool {
 			a := in["args"]
@@ -234,21 +254,21 @@ func RegisterBuiltins(r *Registry) {
 		Help:     "Merge a branch into current (preview with --no-commit by default)",
 		Category: CatBranch,
 		Prompts: []Prompt{
-			{Key: "branch", Label: "Branch to merge", Default: "", Required: true},
-			{Key: "strategy", Label: "Strategy (default/ours/theirs)", Default: ""},
-			{Key: "no-ff", Label: "Prefer no-ff (y/N)", Default: "y"},
+			{Key: "branch", Label: "Branch to merge", Default: "", Required: true, Type: PromptBranch},
+			{Key: "strategy", Label: "Strategy", Default: "default", Type: PromptEnum, Options: []string{"default", "ort", "recursive", "resolve", "octopus", "ours", "subtree"}},
+			{Key: "no-ff", Label: "Prefer no-ff?", Default: "true", Type: PromptBool},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
 			branch := in["branch"]
 			args := []string{"merge"}
-			if strings.ToLower(in["no-ff"]) == "y" || strings.ToLower(in["no-ff"]) == "yes" {
+			if in.Bool("no-ff") {
 				args = append(args, "--no-commit", "--no-ff")
 			}
-			if s := in["strategy"]; s != "" {
+			if s := in["strategy"]; s != "" && s != "default" {
 				args = append(args, "-s", s)
 			}
 			args = append(args, branch)
-			preview := "git " + strings.Join(args, " ")
+			preview := previewArgs(args)
 			preview += "\n(Preview will run merge with --no-commit so you can inspect conflicts before finalizing.)"
 			return "git", args, preview
 		},
@@ -262,22 +282,25 @@ func RegisterBuiltins(r *Registry) {
 		Help:     "Interactive rebase helper (reorder/squash/edit msgs). Presents commits for editing before running rebase -i.",
 		Category: CatHistory,
 		Prompts: []Prompt{
-			{Key: "base", Label: "Base ref (e.g. HEAD~5)", Default: "HEAD~5", Required: true},
-			{Key: "autosquash", Label: "Autosquash? (y/N)", Default: "n"},
+			{Key: "base", Label: "Base ref (e.g. HEAD~5)", Default: "HEAD~5", Required: true, Type: PromptCommit},
+			{Key: "autosquash", Label: "Autosquash?", Default: "false", Type: PromptBool},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
 			base := in["base"]
 			args := []string{"rebase", "-i", base}
-			if strings.ToLower(in["autosquash"]) == "y" {
+			if in.Bool("autosquash") {
 				args = append(args, "--autosquash")
 			}
-			preview := "git " + strings.Join(args, " ")
+			preview := previewArgs(args)
 			preview += "\n(EzGit will present the commits and let you reorder/squash via a UI; this is a high-risk operation.)"
 			return "git", args, preview
 		},
 		IsDestructive: func(in ActionInput) bool {
 			return true
 		},
+		NeedsTerminal: func(in ActionInput) bool {
+			return true
+		},
 	})
 
 	r.Register(&ActionDef{
@@ -288,26 +311,50 @@ func RegisterBuiltins(r *Registry) {
 			{Key: "command", Label: "Full git command (without leading 'git')", Required: true},
 		},
 		BuildFunc: func(in ActionInput) (string, []string, string) {
-			cmdline := in["command"]
-			parts := strings.Fields(cmdline)
-			preview := "git " + strings.Join(parts, " ")
-			return "git", parts, preview
+			parts, err := rawArgs(in["command"])
+			if err != nil {
+				return "git", nil, "git … (" + err.Error() + ")"
+			}
+			return "git", parts, previewArgs(parts)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			parts, err := rawArgs(in["command"])
+			if err != nil {
+				return err
+			}
+			if len(parts) == 0 {
+				return fmt.Errorf("missing required input: command")
+			}
+			return nil
 		},
 	})
 
-	registerPassthrough(r, CatBranch, "rebase", "Rebase (non-interactive)", []Prompt{{Key: "args", Label: "rebase args (e.g. origin/main)", Default: ""}}, []string{"rebase"})
+	registerFixup(r)
+	registerSplit(r)
+	registerReword(r)
+	registerConflicts(r)
+
+	registerPassthrough(r, CatBranch, "rebase", "Rebase (non-interactive)", []Prompt{{Key: "args", Label: "rebase args (e.g. origin/main)", Default: "", Type: PromptRef}}, []string{"rebase"})
 	registerPassthrough(r, CatWork, "diff", "Show changes", []Prompt{{Key: "args", Label: "diff args (e.g. HEAD~1..HEAD)", Default: ""}}, []string{"diff"})
 	registerPassthrough(r, CatHistory, "log", "Show commit history", []Prompt{{Key: "args", Label: "log args (e.g. --oneline -n 20)", Default: "--oneline -n 20"}}, []string{"log"})
 	registerPassthrough(r, CatHistory, "show", "Show object", []Prompt{{Key: "args", Label: "show args (e.g. HEAD:filename)", Default: ""}}, []string{"show"})
 	registerPassthrough(r, CatBranch, "branch", "Branch operations", []Prompt{{Key: "args", Label: "branch args (e.g. -a / new-branch)", Default: "-a"}}, []string{"branch"})
-	registerPassthrough(r, CatBranch, "checkout", "Switch or restore files (checkout)", []Prompt{{Key: "args", Label: "checkout args (branch or -- file)", Default: ""}}, []string{"checkout"})
-	registerPassthrough(r, CatBranch, "switch", "Switch branches (preferred)", []Prompt{{Key: "args", Label: "switch args (branch)", Default: ""}}, []string{"switch"})
-	registerPassthrough(r, CatWork, "mv", "Move/rename files in index", []Prompt{{Key: "args", Label: "mv args (src dst)", Default: ""}}, []string{"mv"})
-	registerPassthrough(r, CatWork, "restore", "Restore working tree files (git restore)", []Prompt{{Key: "args", Label: "restore args (e.g. --staged file)", Default: ""}}, []string{"restore"})
-	registerPassthrough(r, CatWork, "rm", "Remove files from tree/index", []Prompt{{Key: "args", Label: "rm args (files)", Default: ""}}, []string{"rm"})
+	r.Register(&ActionDef{
+		Name:     "branches",
+		Help:     "Branch manager: upstreams, ahead/behind, rename, delete, clean up",
+		Category: CatBranch,
+		Screen:   "branches",
+		BuildFunc: func(ActionInput) (string, []string, string) {
+			args := []string{"branch", "-vv", "--sort=-committerdate"}
+			return "git", args, previewArgs(args)
+		},
+	})
+	registerPassthrough(r, CatBranch, "checkout", "Switch or restore files (checkout)", []Prompt{{Key: "args", Label: "checkout args (branch or -- file)", Default: "", Type: PromptBranch}}, []string{"checkout"})
+	registerSwitch(r)
 	registerPassthrough(r, CatBranch, "tag", "Create/list/delete tags", []Prompt{{Key: "args", Label: "tag args", Default: "-l"}}, []string{"tag"})
 	registerPassthrough(r, CatRemotes, "fetch", "Fetch from remotes", []Prompt{{Key: "args", Label: "fetch args", Default: ""}}, []string{"fetch"})
-	registerPassthrough(r, CatRemotes, "pull", "Fetch + merge/rebase", []Prompt{{Key: "args", Label: "pull args", Default: ""}}, []string{"pull"})
+	registerPull(r)
+	registerSync(r)
 	registerPassthrough(r, CatRemotes, "remote", "Manage remotes", []Prompt{{Key: "args", Label: "remote args (add/origin url)", Default: "-v"}}, []string{"remote"})
 	registerPassthrough(r, CatRemotes, "ls-remote", "List refs in a remote repo", []Prompt{{Key: "args", Label: "ls-remote args (remote URL)", Default: ""}}, []string{"ls-remote"})
 	registerPassthrough(r, CatRemotes, "credential", "Credential helper interface", []Prompt{{Key: "args", Label: "credential args (fill/get/store)", Default: ""}}, []string{"credential"})
@@ -317,15 +364,15 @@ func RegisterBuiltins(r *Registry) {
 	registerPassthrough(r, CatHistory, "reflog", "Show reference log", []Prompt{{Key: "args", Label: "reflog args (e.g. --decorate -n 50)", Default: "--decorate -n 50"}}, []string{"reflog"})
 	registerPassthrough(r, CatRepository, "help", "Show git help for a command", []Prompt{{Key: "args", Label: "help args (e.g. commit)", Default: ""}}, []string{"help"})
 	registerPassthrough(r, CatHistory, "rerere", "Reuse recorded resolution (git rerere)", []Prompt{{Key: "args", Label: "rerere args (e.g. --no-ff)", Default: ""}}, []string{"rerere"})
-	registerPassthrough(r, CatWork, "stash-pop", "Pop a stash entry", []Prompt{{Key: "args", Label: "pop args (e.g. stash@{0})", Default: "stash@{0}"}}, []string{"stash", "pop"})
-	registerPassthrough(r, CatWork, "stash-drop", "Drop a stash entry", []Prompt{{Key: "args", Label: "drop args (e.g. stash@{0})", Default: "stash@{0}"}}, []string{"stash", "drop"})
+	registerPassthrough(r, CatWork, "stash-pop", "Pop a stash entry", []Prompt{{Key: "args", Label: "pop args (e.g. stash@{0})", Default: "stash@{0}", Type: PromptStash}}, []string{"stash", "pop"})
+	registerPassthrough(r, CatWork, "stash-drop", "Drop a stash entry", []Prompt{{Key: "args", Label: "drop args (e.g. stash@{0})", Default: "stash@{0}", Type: PromptStash}}, []string{"stash", "drop"})
 	registerPassthrough(r, CatWork, "stash-list", "List stash entries", nil, []string{"stash", "list"})
 	registerPassthrough(r, CatWork, "apply", "Apply a patch or stash (git apply)", []Prompt{{Key: "args", Label: "apply args (e.g. path/to/patch)", Default: ""}}, []string{"apply"})
 	registerPassthrough(r, CatWork, "am", "Apply patches from mailbox (git am)", []Prompt{{Key: "args", Label: "am args (patch-file)", Default: ""}}, []string{"am"})
 	registerPassthrough(r, CatHistory, "format-patch", "Create patch files (git format-patch)", []Prompt{{Key: "args", Label: "format-patch args (range)", Default: ""}}, []string{"format-patch"})
 	registerPassthrough(r, CatHistory, "cherry", "Find commits not merged", []Prompt{{Key: "args", Label: "cherry args", Default: ""}}, []string{"cherry"})
-	registerPassthrough(r, CatHistory, "cherry-pick", "Apply the changes introduced by existing commits", []Prompt{{Key: "args", Label: "cherry-pick args (commit...)", Default: ""}}, []string{"cherry-pick"})
-	registerPassthrough(r, CatHistory, "revert", "Create a new commit that reverts earlier commits", []Prompt{{Key: "args", Label: "revert args (commit...)", Default: ""}}, []string{"revert"})
+	registerPassthrough(r, CatHistory, "cherry-pick", "Apply the changes introduced by existing commits", []Prompt{{Key: "args", Label: "cherry-pick args (commit...)", Default: "", Type: PromptCommit}}, []string{"cherry-pick"})
+	registerPassthrough(r, CatHistory, "revert", "Create a new commit that reverts earlier commits", []Prompt{{Key: "args", Label: "revert args (commit...)", Default: "", Type: PromptCommit}}, []string{"revert"})
 	registerPassthrough(r, CatHistory, "filter-branch", "Rewrite branches (dangerous)", []Prompt{{Key: "args", Label: "filter-branch args (e.g. --env-filter ...)", Default: ""}}, []string{"filter-branch"})
 	registerPassthrough(r, CatRepository, "describe", "Describe a commit", []Prompt{{Key: "args", Label: "describe args (e.g. --tags --long)", Default: ""}}, []string{"describe"})
 	registerPassthrough(r, CatMaintenance, "fsck", "Check repository integrity", []Prompt{{Key: "args", Label: "fsck args", Default: ""}}, []string{"fsck"})
@@ -344,14 +391,19 @@ func RegisterBuiltins(r *Registry) {
 	registerPassthrough(r, CatHistory, "blame", "Annotate a file", []Prompt{{Key: "args", Label: "blame args (file)", Default: ""}}, []string{"blame"})
 }
 
-func splitPaths(s string) []string {
-	s = strings.ReplaceAll(s, ",", " ")
-	parts := strings.Fields(s)
-	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		if p != "" {
-			out = append(out, p)
-		}
+// RawArgs tokenizes a full command line typed by the user, dropping a
+// leading "git" if present.
+func RawArgs(cmdline string) ([]string, error) {
+	return rawArgs(cmdline)
+}
+
+func rawArgs(cmdline string) ([]string, error) {
+	parts, err := userArgs(cmdline)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > 0 && parts[0] == "git" {
+		parts = parts[1:]
 	}
-	return out
+	return parts, nil
 }
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cloneHosts are the shorthands ExpandCloneURL knows, e.g. gh:owner/repo.
+var cloneHosts = map[string]string{
+	"gh": "https://github.com/",
+	"gl": "https://gitlab.com/",
+	"bb": "https://bitbucket.org/",
+}
+
+var (
+	shorthandRe = regexp.MustCompile(`^([a-z]+):([\w.-]+/[\w.-]+?)(?:\.git)?/?$`)
+	schemeRe    = regexp.MustCompile(`^(https?|ssh|git|file)://[^/\s]*(/\S*)?$`)
+	// scpRe is the scp-like ssh syntax, user@host:path
+	scpRe = regexp.MustCompile(`^[\w.-]+@[\w.-]+:\S+$`)
+)
+
+// ExpandCloneURL turns a host shorthand such as gh:owner/repo into a full
+// URL and returns anything else unchanged.
+func ExpandCloneURL(u string) string {
+	u = strings.TrimSpace(u)
+	if m := shorthandRe.FindStringSubmatch(u); m != nil {
+		if base, ok := cloneHosts[m[1]]; ok {
+			return base + m[2] + ".git"
+		}
+	}
+	return u
+}
+
+// ValidateCloneURL accepts what git can clone from: a URL with a scheme,
+// scp-like user@host:path, a shorthand, or an existing local repository.
+func ValidateCloneURL(u string) error {
+	u = ExpandCloneURL(u)
+	switch {
+	case u == "":
+		return fmt.Errorf("missing required input: url")
+	case schemeRe.MatchString(u), scpRe.MatchString(u):
+		return nil
+	}
+	if _, err := os.Stat(u); err == nil {
+		return nil
+	}
+	var short []string
+	for k := range cloneHosts {
+		short = append(short, k+":owner/repo")
+	}
+	return fmt.Errorf("%q is not a repository URL; use https://..., git@host:path, a local path or %s", u, strings.Join(short, ", "))
+}
+
+// CloneDir is the directory clone creates: the one given, or the last
+// path element of the URL without ".git", as git picks it.
+func CloneDir(in ActionInput) string {
+	if p := strings.TrimSpace(in["path"]); p != "" {
+		return p
+	}
+	u := strings.TrimRight(ExpandCloneURL(in["url"]), "/")
+	if i := strings.LastIndexAny(u, "/:"); i >= 0 {
+		u = u[i+1:]
+	}
+	u = strings.TrimSuffix(path.Base(u), ".git")
+	if u == "" || u == "." {
+		return ""
+	}
+	return u
+}
+
+func cloneArgs(in ActionInput) []string {
+	args := []string{"clone", "--progress"}
+	if n := in.Int("depth", 0); n > 0 {
+		args = append(args, "--depth", strconv.Itoa(n))
+	}
+	if in.Bool("partial") {
+		args = append(args, "--filter=blob:none")
+	}
+	if b := strings.TrimSpace(in["branch"]); b != "" {
+		args = append(args, "--branch", b)
+	}
+	if in.Bool("single_branch") {
+		args = append(args, "--single-branch")
+	}
+	if in.Bool("submodules") {
+		args = append(args, "--recurse-submodules")
+	}
+	if o := strings.TrimSpace(in["origin"]); o != "" && o != "origin" {
+		args = append(args, "--origin", o)
+	}
+	args = append(args, "--", ExpandCloneURL(in["url"]))
+	if p := strings.TrimSpace(in["path"]); p != "" {
+		args = append(args, p)
+	}
+	return args
+}
+
+func registerClone(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "clone",
+		Help:     "Clone a repository",
+		Category: CatRepository,
+		Prompts: []Prompt{
+			{Key: "url", Label: "Repository URL (or gh:owner/repo, gl:..., bb:...)", Required: true, Validate: ValidateCloneURL},
+			{Key: "path", Label: "Directory (empty: named after the repository)", Type: PromptPath},
+			{Key: "depth", Label: "History depth (0: full history)", Default: "0", Type: PromptInt,
+				Validate: func(v string) error {
+					if n, _ := strconv.Atoi(v); n < 0 {
+						return fmt.Errorf("must be 0 or more")
+					}
+					return nil
+				}},
+			{Key: "partial", Label: "Download file contents on demand (--filter=blob:none)?", Default: "false", Type: PromptBool},
+			{Key: "branch", Label: "Branch to check out (empty: the remote's default)"},
+			{Key: "single_branch", Label: "Fetch only that branch?", Default: "false", Type: PromptBool},
+			{Key: "submodules", Label: "Clone submodules too?", Default: "false", Type: PromptBool},
+			{Key: "origin", Label: "Name for the remote", Default: "origin"},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			args := cloneArgs(in)
+			preview := previewArgs(args)
+			if d := CloneDir(in); d != "" {
+				preview += "\n# then works in " + d
+			}
+			return "git", args, preview
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if err := ValidateCloneURL(in["url"]); err != nil {
+				return err
+			}
+			if d := CloneDir(in); d != "" {
+				if entries, err := os.ReadDir(d); err == nil && len(entries) > 0 {
+					return fmt.Errorf("%s already exists and is not empty", d)
+				}
+			}
+			return nil
+		},
+		Chdir: CloneDir,
+	})
+}
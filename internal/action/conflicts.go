@@ -0,0 +1,16 @@
+package action
+
+// registerConflicts adds "conflicts". The TUI resolves them on its own
+// screen; line-mode front ends just list the unmerged files.
+func registerConflicts(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "conflicts",
+		Help:     "Resolve merge/rebase conflicts",
+		Category: CatHistory,
+		Screen:   "conflicts",
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			args := []string{"diff", "--name-only", "--diff-filter=U"}
+			return "git", args, previewArgs(args)
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"ezgit/internal/config"
+)
+
+// CustomActions turns the user's config entries into actions.
+func CustomActions(defs []config.CustomAction) []*ActionDef {
+	out := make([]*ActionDef, 0, len(defs))
+	for _, c := range defs {
+		c := c
+		prompts := make([]Prompt, 0, len(c.Prompts))
+		for _, p := range c.Prompts {
+			prompts = append(prompts, Prompt{Key: p.Key, Label: p.Label, Default: p.Default, Required: p.Required, Type: PromptType(p.Type), Options: p.Options})
+		}
+		out = append(out, &ActionDef{
+			Name:     c.Name,
+			Help:     c.Help,
+			Category: c.Category,
+			Prompts:  prompts,
+			BuildFunc: func(in ActionInput) (string, []string, string) {
+				args, err := expandCustomArgs(c.Args, in)
+				if err != nil {
+					return "git", args, previewArgs(args) + " (" + err.Error() + ")"
+				}
+				return "git", args, previewArgs(args)
+			},
+			ValidateFunc: func(in ActionInput) error {
+				for _, p := range prompts {
+					if p.Required && strings.TrimSpace(in[p.Key]) == "" {
+						return fmt.Errorf("missing required input: %s", p.Key)
+					}
+				}
+				_, err := expandCustomArgs(c.Args, in)
+				return err
+			},
+			IsDestructive: func(in ActionInput) bool {
+				return c.Destructive
+			},
+			NeedsTerminal: func(in ActionInput) bool {
+				return c.Terminal
+			},
+		})
+	}
+	return out
+}
+
+// expandCustomArgs substitutes "{key}" placeholders. An arg that is only a
+// placeholder expands to the answer tokenized with shell quoting rules, so
+// it can contribute zero or several args; a placeholder inside a longer arg
+// is substituted literally.
+func expandCustomArgs(tmpl []string, in ActionInput) ([]string, error) {
+	args := make([]string, 0, len(tmpl))
+	for _, a := range tmpl {
+		if strings.HasPrefix(a, "{") && strings.HasSuffix(a, "}") && strings.Count(a, "{") == 1 {
+			if v, ok := in[a[1:len(a)-1]]; ok {
+				parts, err := userArgs(v)
+				if err != nil {
+					return args, fmt.Errorf("%s: %v", a, err)
+				}
+				args = append(args, parts...)
+				continue
+			}
+		}
+		for k, v := range in {
+			a = strings.ReplaceAll(a, "{"+k+"}", strings.TrimSpace(v))
+		}
+		args = append(args, a)
+	}
+	return args, nil
+}
@@ -0,0 +1,136 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+const (
+	fixStaged  = "staged changes"
+	fixMessage = "message"
+	fixBoth    = "both"
+)
+
+// autosquashArgs folds fixup!/amend! commits into their targets without
+// opening the todo list: sequence.editor=: accepts it as generated.
+func autosquashArgs(ctx context.Context, target string) []string {
+	args := []string{"-c", "sequence.editor=:", "rebase", "-i", "--autosquash", "--autostash"}
+	if _, err := repo.RevParse(ctx, target+"^"); err != nil {
+		return append(args, "--root")
+	}
+	return append(args, target+"^")
+}
+
+func isHead(ctx context.Context, target string) bool {
+	t, err := repo.RevParse(ctx, target)
+	if err != nil {
+		return false
+	}
+	h, err := repo.RevParse(ctx, "HEAD")
+	return err == nil && t == h
+}
+
+// fixupPlan amends HEAD directly; an older target gets a fixup! or amend!
+// commit that autosquash folds in. The target is pinned to its sha first:
+// a relative name like HEAD~1 means another commit once the fixup! commit
+// has moved HEAD.
+func fixupPlan(ctx context.Context, in ActionInput) []Step {
+	target := strings.TrimSpace(in["target"])
+	if sha, err := repo.RevParse(ctx, target); err == nil {
+		target = sha
+	}
+	msg := strings.TrimSpace(in["message"])
+	fix := in["fix"]
+	if isHead(ctx, target) {
+		args := []string{"commit", "--amend"}
+		switch fix {
+		case fixMessage:
+			args = append(args, "--only", "-m", msg)
+		case fixBoth:
+			args = append(args, "-m", msg)
+		default:
+			args = append(args, "--no-edit")
+		}
+		return []Step{{Cmd: "git", Args: args}}
+	}
+	var commit []string
+	switch fix {
+	case fixMessage, fixBoth:
+		// amend! replaces the target's message with everything after its
+		// own subject line
+		subject, _ := repo.Git(ctx, "log", "-1", "--format=%s", target)
+		commit = []string{"commit", "-m", "amend! " + subject, "-m", msg}
+		if fix == fixMessage {
+			commit = []string{"commit", "--only", "--allow-empty", "-m", "amend! " + subject, "-m", msg}
+		}
+	default:
+		commit = []string{"commit", "--fixup=" + target}
+	}
+	steps := []Step{{Cmd: "git", Args: commit}}
+	if in.Bool("autosquash") {
+		steps = append(steps, Step{Cmd: "git", Args: autosquashArgs(ctx, target)})
+	}
+	return steps
+}
+
+func planPreview(steps []Step) string {
+	var lines []string
+	for _, s := range steps {
+		lines = append(lines, previewArgs(s.Args))
+		for _, u := range s.Undo {
+			lines = append(lines, "# if it fails: "+previewArgs(u.Args))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pushedWarning says so when target is already on the current branch's
+// upstream, where rewriting it means a force push.
+func pushedWarning(ctx context.Context, target string) []string {
+	up := repo.Upstream(ctx, "HEAD")
+	if up == "" || target == "" {
+		return nil
+	}
+	if _, err := repo.Git(ctx, "merge-base", "--is-ancestor", target, up); err != nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s is already pushed to %s: changing it rewrites published history and needs a force push", target, up)}
+}
+
+func registerFixup(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "fixup",
+		Help:     "Amend / fix a previous commit",
+		Category: CatHistory,
+		Prompts: []Prompt{
+			{Key: "target", Label: "Commit to fix", Default: "HEAD", Required: true, Type: PromptCommit},
+			{Key: "fix", Label: "What to change", Default: fixStaged, Required: true, Type: PromptEnum, Options: []string{fixStaged, fixMessage, fixBoth}},
+			{Key: "message", Label: "New commit message", Type: PromptMultiline, When: func(in ActionInput) bool {
+				return in["fix"] == fixMessage || in["fix"] == fixBoth
+			}},
+			{Key: "autosquash", Label: "Fold it into the commit now (autosquash rebase)?", Default: "true", Type: PromptBool, When: func(in ActionInput) bool {
+				return !isHead(r.Context(), in["target"])
+			}},
+		},
+		PlanFunc: func(in ActionInput) []Step { return fixupPlan(r.Context(), in) },
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			steps := fixupPlan(r.Context(), in)
+			return "git", steps[0].Args, planPreview(steps)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if _, err := repo.RevParse(r.Context(), in["target"]); err != nil {
+				return fmt.Errorf("target: %q is not a commit", in["target"])
+			}
+			if (in["fix"] == fixMessage || in["fix"] == fixBoth) && strings.TrimSpace(in["message"]) == "" {
+				return fmt.Errorf("missing required input: message")
+			}
+			return nil
+		},
+		Warnings: func(in ActionInput) []string {
+			return pushedWarning(r.Context(), in["target"])
+		},
+	})
+}
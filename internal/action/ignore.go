@@ -0,0 +1,16 @@
+package action
+
+// registerIgnore adds "ignore". The TUI manages ignore rules on its own
+// screen; line-mode front ends list what is ignored.
+func registerIgnore(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "ignore",
+		Help:     "Ignore untracked files, see why a file is ignored",
+		Category: CatWork,
+		Screen:   "ignore",
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			args := []string{"status", "--short", "--ignored"}
+			return "git", args, previewArgs(args)
+		},
+	})
+}
@@ -0,0 +1,143 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ezgit/internal/config"
+	"ezgit/internal/ignore"
+	"ezgit/internal/repo"
+	"ezgit/internal/scaffold"
+)
+
+const noLicense = "none"
+
+// initialBranch is the branch init starts on when none is typed: the
+// EzGit config, then git's init.defaultBranch, then "main".
+func initialBranch(ctx context.Context) string {
+	if cfg, err := config.Load(config.Path()); err == nil && cfg.InitialBranch != "" {
+		return cfg.InitialBranch
+	}
+	if b, err := repo.Git(ctx, "config", "--get", "init.defaultBranch"); err == nil && b != "" {
+		return b
+	}
+	return "main"
+}
+
+func initDir(in ActionInput) string {
+	if d := strings.TrimSpace(in["path"]); d != "" {
+		return d
+	}
+	return "."
+}
+
+// initFiles renders the scaffold for in.
+func initFiles(ctx context.Context, in ActionInput) (string, []scaffold.File) {
+	dir := initDir(in)
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	holder, _ := repo.Git(ctx, "config", "--get", "user.name")
+	if holder == "" {
+		holder = name + " authors"
+	}
+	o := scaffold.Options{
+		Name:        name,
+		Description: strings.TrimSpace(in["description"]),
+		Readme:      in.Bool("readme"),
+		Ignore:      in.List("gitignore"),
+		Holder:      holder,
+		Year:        time.Now().Year(),
+	}
+	if l := in["license"]; l != "" && l != noLicense {
+		o.License = l
+	}
+	files, err := scaffold.Files(o)
+	if err != nil {
+		return dir, nil
+	}
+	return dir, files
+}
+
+// initPlan creates the repository, commits the scaffold and adds the
+// remote. Everything after init runs with -C so dir need not be the
+// current directory.
+func initPlan(ctx context.Context, in ActionInput) []Step {
+	dir := initDir(in)
+	branch := strings.TrimSpace(in["branch"])
+	if branch == "" {
+		branch = initialBranch(ctx)
+	}
+	steps := []Step{{Cmd: "git", Args: []string{"init", "--initial-branch=" + branch, dir}}}
+	if in.Bool("commit") {
+		_, files := initFiles(ctx, in)
+		if len(files) > 0 {
+			add := []string{"-C", dir, "add", "--"}
+			for _, f := range files {
+				add = append(add, f.Path)
+			}
+			steps = append(steps,
+				Step{Cmd: "git", Args: add},
+				Step{Cmd: "git", Args: []string{"-C", dir, "commit", "-m", "Initial commit"}})
+		} else {
+			steps = append(steps, Step{Cmd: "git", Args: []string{"-C", dir, "commit", "--allow-empty", "-m", "Initial commit"}})
+		}
+	}
+	if url := strings.TrimSpace(in["remote"]); url != "" {
+		steps = append(steps, Step{Cmd: "git", Args: []string{"-C", dir, "remote", "add", "origin", url}})
+	}
+	return steps
+}
+
+// initPreview lists every file init creates, then the commands.
+func initPreview(ctx context.Context, in ActionInput) string {
+	dir, files := initFiles(ctx, in)
+	var lines []string
+	for _, f := range files {
+		what := fmt.Sprintf("# creates %s (%d lines)", filepath.Join(dir, f.Path), strings.Count(f.Content, "\n"))
+		if _, err := os.Stat(filepath.Join(dir, f.Path)); err == nil {
+			what = "# keeps existing " + filepath.Join(dir, f.Path)
+		}
+		lines = append(lines, what)
+	}
+	return strings.Join(append(lines, planPreview(initPlan(ctx, in))), "\n")
+}
+
+func registerInit(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "init",
+		Help:     "Initialize a new git repository with README, .gitignore and LICENSE",
+		Category: CatRepository,
+		Prompts: []Prompt{
+			{Key: "path", Label: "Directory", Default: ".", Required: true, Type: PromptPath},
+			{Key: "readme", Label: "Create README?", Default: "true", Type: PromptBool},
+			{Key: "description", Label: "One-line description for the README (optional)",
+				When: func(in ActionInput) bool { return in.Bool("readme") }},
+			{Key: "gitignore", Label: ".gitignore templates (none picked: no .gitignore)", Type: PromptMultiSelect, Options: ignore.Templates()},
+			{Key: "license", Label: "License", Default: noLicense, Type: PromptEnum, Options: append([]string{noLicense}, scaffold.Licenses()...)},
+			// the preview shows the branch an empty answer resolves to
+			{Key: "branch", Label: "Initial branch (empty: from config, else main)"},
+			{Key: "commit", Label: "Make an initial commit?", Default: "true", Type: PromptBool},
+			{Key: "remote", Label: "Remote URL to add as origin (optional)", Placeholder: "git@github.com:you/project.git"},
+		},
+		Files:    func(in ActionInput) (string, []scaffold.File) { return initFiles(r.Context(), in) },
+		PlanFunc: func(in ActionInput) []Step { return initPlan(r.Context(), in) },
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			ctx := r.Context()
+			return "git", initPlan(ctx, in)[0].Args, initPreview(ctx, in)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if b := strings.TrimSpace(in["branch"]); b != "" {
+				if _, err := repo.Git(r.Context(), "check-ref-format", "--branch", b); err != nil {
+					return fmt.Errorf("%q is not a valid branch name", b)
+				}
+			}
+			return nil
+		},
+	})
+}
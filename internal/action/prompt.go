@@ -0,0 +1,177 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PromptType tells the wizard which widget to use for a prompt. Answers are
+// always stored as strings in ActionInput; Normalize turns what the user
+// typed into the canonical form the typed accessors read back.
+type PromptType string
+
+const (
+	PromptText      PromptType = ""
+	PromptBool      PromptType = "bool"
+	PromptEnum      PromptType = "enum"
+	PromptInt       PromptType = "int"
+	PromptPath      PromptType = "path"
+	PromptMultiline PromptType = "multiline"
+	// PromptMultiSelect answers are the chosen options, one per line.
+	PromptMultiSelect PromptType = "multi-select"
+	// PromptFiles picks changed or untracked files from git status,
+	// PromptTracked picks from every tracked file. Answers are paths, one
+	// per line.
+	PromptFiles   PromptType = "files"
+	PromptTracked PromptType = "tracked-files"
+
+	// The ref types get a fuzzy picker over the repository's refs.
+	PromptRef    PromptType = "ref"
+	PromptBranch PromptType = "branch"
+	PromptCommit PromptType = "commit"
+	PromptStash  PromptType = "stash"
+)
+
+var ErrNotBool = errors.New("answer yes or no")
+
+// IsFiles reports whether the prompt picks paths from a file list.
+func (t PromptType) IsFiles() bool {
+	return t == PromptFiles || t == PromptTracked
+}
+
+// IsRef reports whether the prompt picks a git ref.
+func (t PromptType) IsRef() bool {
+	switch t {
+	case PromptRef, PromptBranch, PromptCommit, PromptStash:
+		return true
+	}
+	return false
+}
+
+// Normalize checks an answer against the prompt's type, options and
+// validator and returns its canonical form: "true"/"false" for bools, the
+// option's own spelling for enums, a plain decimal for ints. An empty
+// answer is passed through untouched; Required is checked elsewhere.
+func (p Prompt) Normalize(v string) (string, error) {
+	switch {
+	case p.Type.IsFiles():
+		v = strings.Join(pathLines(v), "\n")
+	case p.Type != PromptMultiline:
+		v = strings.TrimSpace(v)
+	}
+	if v == "" {
+		return v, nil
+	}
+	switch p.Type {
+	case PromptBool:
+		b, err := ParseBool(v)
+		if err != nil {
+			return "", err
+		}
+		v = strconv.FormatBool(b)
+	case PromptEnum:
+		o, ok := p.option(v)
+		if !ok {
+			return "", fmt.Errorf("must be one of %s", strings.Join(p.Options, ", "))
+		}
+		v = o
+	case PromptInt:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return "", fmt.Errorf("must be a whole number")
+		}
+		v = strconv.Itoa(n)
+	case PromptMultiSelect:
+		var out []string
+		for _, s := range splitList(v) {
+			o, ok := p.option(s)
+			if !ok {
+				return "", fmt.Errorf("%q is not one of %s", s, strings.Join(p.Options, ", "))
+			}
+			out = append(out, o)
+		}
+		v = strings.Join(out, "\n")
+	}
+	if p.Validate != nil {
+		if err := p.Validate(v); err != nil {
+			return "", err
+		}
+	}
+	return v, nil
+}
+
+func (p Prompt) option(v string) (string, bool) {
+	for _, o := range p.Options {
+		if strings.EqualFold(o, v) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// ParseBool accepts the spellings people actually type at a y/N prompt.
+func ParseBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "y", "yes", "true", "t", "1", "on":
+		return true, nil
+	case "n", "no", "false", "f", "0", "off":
+		return false, nil
+	}
+	return false, ErrNotBool
+}
+
+// Bool reads a bool answer; anything unparseable is false.
+func (in ActionInput) Bool(key string) bool {
+	b, _ := ParseBool(in[key])
+	return b
+}
+
+// Int reads an int answer, or def when it is missing or not a number.
+func (in ActionInput) Int(key string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(in[key]))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Paths reads a file list answer. Unlike List it never splits on commas,
+// which are legal in file names.
+func (in ActionInput) Paths(key string) []string {
+	return pathLines(in[key])
+}
+
+// List reads a multi-select answer.
+func (in ActionInput) List(key string) []string {
+	return splitList(in[key])
+}
+
+// splitList splits a multi-select answer: one item per line, or comma
+// separated when typed on a single line.
+func splitList(v string) []string {
+	sep := "\n"
+	if !strings.Contains(v, "\n") {
+		sep = ","
+	}
+	var out []string
+	for _, s := range strings.Split(v, sep) {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// pathLines splits a file list answer into paths, dropping blank lines but
+// keeping any other whitespace, which may be part of a name.
+func pathLines(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, "\n") {
+		if strings.TrimSpace(s) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package action
+
+import (
+	"fmt"
+
+	"ezgit/internal/repo"
+)
+
+const (
+	PullFastForward = "fast-forward"
+	PullRebase      = "rebase"
+	PullMerge       = "merge"
+)
+
+var pullStrategies = []string{PullFastForward, PullRebase, PullMerge}
+
+// RecommendPull picks a strategy for d and says why.
+func RecommendPull(d repo.Divergence) (string, string) {
+	switch {
+	case d.Behind == 0:
+		return "", "You already have everything from " + d.Upstream + "; there is nothing to pull."
+	case d.Ahead == 0:
+		return PullFastForward, fmt.Sprintf("You have no local commits, so your branch can simply move forward to %s. Nothing is rewritten and no merge commit is made.", d.Upstream)
+	case d.LocalMerges:
+		return PullMerge, "Your local commits include merges, which a rebase would flatten. A merge keeps them as they are."
+	case d.LocalShared:
+		return PullMerge, "Some of your local commits are already on another remote branch. Rebasing would rewrite them for everyone who has them, so merge instead."
+	}
+	return PullRebase, fmt.Sprintf("Your %d local commit(s) are not shared anywhere yet. Replaying them on top of %s keeps history linear, as if you had started after their work.", d.Ahead, d.Upstream)
+}
+
+// PullSteps integrates the already fetched upstream with strategy,
+// optionally saving the choice as this repository's pull default first.
+// Saving sets both pull.rebase and pull.ff, so an earlier choice of the
+// other kind does not linger and override it.
+func PullSteps(strategy, upstream string, save bool) []Step {
+	var steps []Step
+	if save {
+		rebase, ff := "false", "true"
+		switch strategy {
+		case PullFastForward:
+			ff = "only"
+		case PullRebase:
+			rebase = "true"
+		}
+		steps = append(steps,
+			Step{Cmd: "git", Args: []string{"config", "pull.rebase", rebase}},
+			Step{Cmd: "git", Args: []string{"config", "pull.ff", ff}})
+	}
+	switch strategy {
+	case PullFastForward:
+		steps = append(steps, Step{Cmd: "git", Args: []string{"merge", "--ff-only", upstream}})
+	case PullRebase:
+		steps = append(steps, Step{Cmd: "git", Args: []string{"rebase", "--autostash", upstream}})
+	case PullMerge:
+		steps = append(steps, Step{Cmd: "git", Args: []string{"merge", "--no-edit", upstream}})
+	}
+	return steps
+}
+
+func pullArgs(in ActionInput) []string {
+	switch in["strategy"] {
+	case PullFastForward:
+		return []string{"pull", "--ff-only"}
+	case PullMerge:
+		return []string{"pull", "--no-rebase"}
+	}
+	return []string{"pull", "--rebase", "--autostash"}
+}
+
+// registerPull adds "pull". The TUI runs it on its own screen that fetches
+// first and recommends a strategy; line-mode front ends just ask.
+func registerPull(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "pull",
+		Help:     "Fetch + merge/rebase",
+		Category: CatRemotes,
+		Screen:   "pull",
+		Prompts: []Prompt{
+			{Key: "strategy", Label: "How to combine with the remote's commits", Default: PullRebase, Required: true, Type: PromptEnum, Options: pullStrategies},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			args := pullArgs(in)
+			return "git", args, previewArgs(args)
+		},
+	})
+}
@@ -0,0 +1,197 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+// pushTarget resolves what a push will do: the local branch (default the
+// current one), the remote (default its upstream's, else origin) and the
+// remote branch name, which follows the upstream when there is one.
+type pushTarget struct {
+	local, remote, dst string
+	upstream           bool
+}
+
+func resolvePush(ctx context.Context, in ActionInput) pushTarget {
+	t := pushTarget{local: strings.TrimSpace(in["branch"]), remote: strings.TrimSpace(in["remote"])}
+	if t.local == "" {
+		t.local = repo.CurrentBranch(ctx)
+	}
+	upRemote := repo.BranchRemote(ctx, t.local)
+	if t.remote == "" {
+		t.remote = upRemote
+	}
+	if t.remote == "" {
+		t.remote = "origin"
+	}
+	t.dst = t.local
+	if merge, err := repo.Git(ctx, "config", "branch."+t.local+".merge"); err == nil && merge != "" && upRemote != "" {
+		t.upstream = true
+		if upRemote == t.remote {
+			t.dst = strings.TrimPrefix(merge, "refs/heads/")
+		}
+	}
+	return t
+}
+
+// tracking is the remote-tracking ref for the push destination.
+func (t pushTarget) tracking() string {
+	return "refs/remotes/" + t.remote + "/" + t.dst
+}
+
+func pushArgs(ctx context.Context, in ActionInput) []string {
+	t := resolvePush(ctx, in)
+	args := []string{"push"}
+	if !t.upstream && in.Bool("set_upstream") {
+		args = append(args, "--set-upstream")
+	}
+	if in.Bool("force") {
+		// pin the lease to what we last fetched, so a push someone made
+		// since is never overwritten unseen; no tracking ref means the
+		// branch must not exist yet
+		sha, _ := repo.RevParse(ctx, t.tracking())
+		args = append(args, "--force-with-lease=refs/heads/"+t.dst+":"+sha)
+	}
+	refspec := t.local
+	if t.dst != t.local {
+		refspec = t.local + ":" + t.dst
+	}
+	return append(args, t.remote, refspec)
+}
+
+// pushPreview lists the commits the push sends and, when forcing, the
+// remote commits it would drop.
+func pushPreview(ctx context.Context, in ActionInput) string {
+	t := resolvePush(ctx, in)
+	lines := []string{previewArgs(pushArgs(ctx, in))}
+	if t.local == "" {
+		return lines[0]
+	}
+	rng := []string{t.local, "--not", "--remotes=" + t.remote}
+	if _, err := repo.RevParse(ctx, t.tracking()); err == nil {
+		rng = []string{t.tracking() + ".." + t.local}
+		if in.Bool("force") {
+			if out, _ := repo.Git(ctx, "log", "--oneline", "-n", "20", t.local+".."+t.tracking()); out != "" {
+				lines = append(lines, "# force drops from "+t.remote+"/"+t.dst+":")
+				for _, l := range repo.Lines(out) {
+					lines = append(lines, "#   - "+l)
+				}
+			}
+		}
+	}
+	out, _ := repo.Git(ctx, append([]string{"log", "--oneline", "-n", "20"}, rng...)...)
+	commits := repo.Lines(out)
+	if len(commits) == 0 {
+		return strings.Join(append(lines, "# nothing new to push"), "\n")
+	}
+	lines = append(lines, fmt.Sprintf("# pushes %d commit(s):", len(commits)))
+	for _, l := range commits {
+		lines = append(lines, "#   + "+l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func registerPush(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "push",
+		Help:     "Push to remote",
+		Category: CatRemotes,
+		Prompts: []Prompt{
+			{Key: "remote", Label: "Remote (empty: the branch's upstream remote, or origin)", Placeholder: "origin"},
+			{Key: "branch", Label: "Branch (empty: the current branch)", Type: PromptBranch},
+			{Key: "set_upstream", Label: "No upstream yet. Track the pushed branch (--set-upstream)?", Default: "true", Type: PromptBool,
+				When: func(in ActionInput) bool { return !resolvePush(r.Context(), in).upstream }},
+			{Key: "force", Label: "Force push (with lease)?", Default: "false", Type: PromptBool},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			ctx := r.Context()
+			return "git", pushArgs(ctx, in), pushPreview(ctx, in)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if strings.ContainsAny(in["remote"], " \t\n\r") {
+				return fmt.Errorf("invalid remote name")
+			}
+			if strings.ContainsAny(in["branch"], " \t\n\r") {
+				return fmt.Errorf("invalid branch name")
+			}
+			if resolvePush(r.Context(), in).local == "" {
+				return fmt.Errorf("HEAD is detached: name the branch to push")
+			}
+			return nil
+		},
+		IsDestructive: func(in ActionInput) bool {
+			return in.Bool("force")
+		},
+	})
+}
+
+// PushRejected reports whether git refused a push because the remote has
+// commits the local branch lacks.
+func PushRejected(stderr string) bool {
+	return strings.Contains(stderr, "[rejected]") &&
+		(strings.Contains(stderr, "fetch first") || strings.Contains(stderr, "non-fast-forward"))
+}
+
+// LeaseStale reports whether git refused a --force-with-lease push because
+// the remote branch moved since it was last fetched.
+func LeaseStale(stderr string) bool {
+	return strings.Contains(stderr, "stale info")
+}
+
+// pushDest picks the remote and the remote branch out of a push argv; both
+// are empty unless the push names them.
+func pushDest(push []string) (remote, dst string) {
+	var pos []string
+	for _, a := range push[1:] {
+		if !strings.HasPrefix(a, "-") {
+			pos = append(pos, a)
+		}
+	}
+	if len(pos) != 2 {
+		return "", ""
+	}
+	src, dst, ok := strings.Cut(pos[1], ":")
+	if !ok {
+		dst = src
+	}
+	return pos[0], strings.TrimPrefix(dst, "+")
+}
+
+// PullRetrySteps is the follow-up to a rejected push: rebase the local
+// branch onto what the remote has, then push again without any force,
+// since after the rebase the push is a fast-forward.
+func PullRetrySteps(push []string) []Step {
+	var retry []string
+	for _, a := range push {
+		if a == "-f" || a == "--force" || strings.HasPrefix(a, "--force-with-lease") || a == "--force-if-includes" {
+			continue
+		}
+		retry = append(retry, a)
+	}
+	pull := []string{"pull", "--rebase", "--autostash"}
+	if remote, dst := pushDest(push); remote != "" {
+		pull = append(pull, remote, dst)
+	}
+	return []Step{{Cmd: "git", Args: pull}, {Cmd: "git", Args: retry}}
+}
+
+// FetchReviewSteps is the follow-up to a force push refused for a stale
+// lease: fetch what was pushed since and list the commits the force would
+// drop. Pushing again then pins the lease to what was just reviewed.
+func FetchReviewSteps(push []string) []Step {
+	remote, dst := pushDest(push)
+	if remote == "" {
+		return []Step{
+			{Cmd: "git", Args: []string{"fetch"}},
+			{Cmd: "git", Args: []string{"log", "--oneline", "HEAD..@{upstream}"}},
+		}
+	}
+	return []Step{
+		{Cmd: "git", Args: []string{"fetch", remote}},
+		{Cmd: "git", Args: []string{"log", "--oneline", "HEAD..refs/remotes/" + remote + "/" + dst}},
+	}
+}
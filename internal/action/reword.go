@@ -0,0 +1,64 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+	"ezgit/internal/undo"
+)
+
+// registerReword adds "reword", which the TUI runs on its own screen, and
+// "undo-rewrite", which takes back the last reword or split.
+func registerReword(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "reword",
+		Help:     "Reword a commit message anywhere in history",
+		Category: CatHistory,
+		Screen:   "reword",
+		Prompts: []Prompt{
+			{Key: "target", Label: "Commit to reword", Default: "HEAD", Required: true, Type: PromptCommit},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			target := strings.TrimSpace(in["target"])
+			args := []string{"rebase", "-i", target + "^"}
+			return "git", args, previewArgs(args) + "\n# change \"pick\" to \"reword\" on " + target
+		},
+		NeedsTerminal: func(ActionInput) bool { return true },
+		ValidateFunc: func(in ActionInput) error {
+			if _, err := repo.RevParse(r.Context(), in["target"]); err != nil {
+				return fmt.Errorf("target: %q is not a commit", in["target"])
+			}
+			return nil
+		},
+		Warnings: func(in ActionInput) []string {
+			return pushedWarning(r.Context(), in["target"])
+		},
+	})
+
+	r.Register(&ActionDef{
+		Name:     "undo-rewrite",
+		Help:     "Undo the last reword or split",
+		Category: CatHistory,
+		BuildFunc: func(ActionInput) (string, []string, string) {
+			e, ok := undo.Undoable(r.Context())
+			if !ok {
+				return "", nil, "# nothing to undo: HEAD is not the result of a recorded reword or split"
+			}
+			args := []string{"reset", "--keep", e.Before}
+			short := e.Before[:min(len(e.Before), 7)]
+			return "git", args, previewArgs(args) + "\n# back to before the " + e.Action + " (" + short + ")"
+		},
+		ValidateFunc: func(ActionInput) error {
+			ctx := r.Context()
+			e, ok := undo.Undoable(ctx)
+			if !ok {
+				return fmt.Errorf("nothing to undo: HEAD is not the result of a recorded reword or split")
+			}
+			if b := repo.CurrentBranch(ctx); b != e.Branch {
+				return fmt.Errorf("the %s was made on %s, switch back to it first", e.Action, e.Branch)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+// registerSplit adds "split". The TUI runs it on its own screen; line-mode
+// front ends fall back to an interactive rebase where the commit is marked
+// "edit" by hand.
+func registerSplit(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "split",
+		Help:     "Split a commit into several",
+		Category: CatHistory,
+		Screen:   "split",
+		Prompts: []Prompt{
+			{Key: "target", Label: "Commit to split", Default: "HEAD", Required: true, Type: PromptCommit},
+		},
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			target := strings.TrimSpace(in["target"])
+			args := []string{"rebase", "-i", target + "^"}
+			return "git", args, previewArgs(args) + "\n# mark " + target + " as edit, then: git reset HEAD^, commit the pieces, git rebase --continue"
+		},
+		NeedsTerminal: func(ActionInput) bool { return true },
+		ValidateFunc: func(in ActionInput) error {
+			ctx := r.Context()
+			target := strings.TrimSpace(in["target"])
+			if _, err := repo.RevParse(ctx, target); err != nil {
+				return fmt.Errorf("target: %q is not a commit", target)
+			}
+			if _, err := repo.RevParse(ctx, target+"^"); err != nil {
+				return fmt.Errorf("target: %s is the first commit and has no parent to split it against", target)
+			}
+			return nil
+		},
+		Warnings: func(in ActionInput) []string {
+			return pushedWarning(r.Context(), in["target"])
+		},
+	})
+}
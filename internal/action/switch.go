@@ -0,0 +1,77 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+const (
+	dirtyStash = "stash them (offered back when you return)"
+	dirtyCarry = "carry them over"
+	dirtyAbort = "abort"
+)
+
+// switchTarget builds the switch argv for what the user picked: a local
+// branch, a remote-tracking branch to create a local one from, or free-form
+// switch arguments such as "-c new".
+func switchTarget(ctx context.Context, v string) []string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "-") || strings.ContainsAny(v, " \t") {
+		extra, _ := userArgs(v)
+		return append([]string{"switch"}, extra...)
+	}
+	if _, err := repo.Git(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+v); err != nil {
+		if _, err := repo.Git(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/"+v); err == nil {
+			return []string{"switch", "--track", v}
+		}
+	}
+	return []string{"switch", v}
+}
+
+// switchPlan stashes local changes under the source branch's tag first
+// when asked to, so they can be offered back on return. Should the switch
+// itself fail, the stash is popped right away.
+func switchPlan(ctx context.Context, in ActionInput) []Step {
+	sw := Step{Cmd: "git", Args: switchTarget(ctx, in["branch"])}
+	if in["dirty"] != dirtyStash {
+		return []Step{sw}
+	}
+	from := repo.CurrentBranch(ctx)
+	stash := Step{Cmd: "git", Args: []string{"stash", "push", "-m", repo.SwitchStashMessage(from)}}
+	sw.Undo = []Step{{Cmd: "git", Args: []string{"stash", "pop", "--index"}}}
+	return []Step{stash, sw}
+}
+
+func registerSwitch(r *Registry) {
+	r.Register(&ActionDef{
+		Name:     "switch",
+		Help:     "Switch branches (preferred)",
+		Category: CatBranch,
+		Prompts: []Prompt{
+			{Key: "branch", Label: "Branch to switch to", Required: true, Type: PromptBranch},
+			{Key: "dirty", Label: "You have uncommitted changes. What should happen to them?", Default: dirtyStash, Type: PromptEnum,
+				Options: []string{dirtyStash, dirtyCarry, dirtyAbort},
+				When:    func(ActionInput) bool { return repo.Dirty(r.Context()) }},
+		},
+		PlanFunc: func(in ActionInput) []Step { return switchPlan(r.Context(), in) },
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			steps := switchPlan(r.Context(), in)
+			return "git", steps[len(steps)-1].Args, planPreview(steps)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			if strings.TrimSpace(in["branch"]) == "" {
+				return fmt.Errorf("missing required input: branch")
+			}
+			if _, err := userArgs(in["branch"]); err != nil {
+				return fmt.Errorf("branch: %v", err)
+			}
+			if in["dirty"] == dirtyAbort {
+				return fmt.Errorf("switch aborted; your changes are untouched")
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+// SyncStep is one stage of "sync my work".
+type SyncStep struct {
+	Name string
+	Step
+}
+
+// SyncTarget is where sync integrates and pushes: the current branch and
+// its upstream, if it has one yet.
+type SyncTarget struct {
+	Branch   string
+	Remote   string
+	Upstream string
+}
+
+// ResolveSync finds the branch, remote and upstream for the current HEAD.
+func ResolveSync(ctx context.Context) (SyncTarget, error) {
+	t := SyncTarget{Branch: repo.CurrentBranch(ctx)}
+	if t.Branch == "" {
+		return t, fmt.Errorf("HEAD is detached; switch to a branch first")
+	}
+	t.Remote = repo.BranchRemote(ctx, t.Branch)
+	if t.Remote == "" {
+		t.Remote = "origin"
+	}
+	if _, err := repo.Git(ctx, "remote", "get-url", t.Remote); err != nil {
+		return t, fmt.Errorf("no remote %q to sync with; add one first", t.Remote)
+	}
+	t.Upstream = repo.Upstream(ctx, t.Branch)
+	return t, nil
+}
+
+// SyncSteps stages and commits everything when message is set, brings in
+// the upstream with a rebase and pushes. A branch without an upstream is
+// pushed with --set-upstream and has nothing to rebase onto.
+func SyncSteps(t SyncTarget, message string) []SyncStep {
+	var steps []SyncStep
+	add := func(name string, args ...string) {
+		steps = append(steps, SyncStep{Name: name, Step: Step{Cmd: "git", Args: args}})
+	}
+	if message != "" {
+		add("stage", "add", "-A")
+		add("commit", "commit", "-m", message)
+	}
+	add("fetch", "fetch", t.Remote)
+	if t.Upstream == "" {
+		add("push", "push", "--set-upstream", t.Remote, t.Branch)
+		return steps
+	}
+	add("rebase", "rebase", t.Upstream)
+	dst := strings.TrimPrefix(t.Upstream, t.Remote+"/")
+	if dst == t.Branch {
+		add("push", "push", t.Remote, t.Branch)
+	} else {
+		add("push", "push", t.Remote, t.Branch+":"+dst)
+	}
+	return steps
+}
+
+// SyncRollback puts the branch and working tree back the way they were
+// before sync started at before. committed is the commit sync made of the
+// local changes, or "" if the tree was clean. Any stopped rebase is aborted
+// first; the sync commit is then undone with its changes left unstaged.
+func SyncRollback(ctx context.Context, before, committed string) []Step {
+	var steps []Step
+	if repo.RebaseInProgress(ctx) {
+		steps = append(steps, Step{Cmd: "git", Args: []string{"rebase", "--abort"}})
+	}
+	target := before
+	if committed != "" {
+		target = committed
+	}
+	if head, _ := repo.RevParse(ctx, "HEAD"); head != target || len(steps) > 0 {
+		steps = append(steps, Step{Cmd: "git", Args: []string{"reset", "--keep", target}})
+	}
+	return append(steps, Step{Cmd: "git", Args: []string{"reset", "--quiet", before}})
+}
+
+// registerSync adds "sync". The TUI runs it on its own screen with per-step
+// progress and a rollback point; line-mode front ends run the plan as is.
+func registerSync(r *Registry) {
+	plan := func(in ActionInput) []Step {
+		ctx := r.Context()
+		t, _ := ResolveSync(ctx)
+		msg := strings.TrimSpace(in["message"])
+		if !hasChanges(ctx) {
+			msg = ""
+		}
+		var steps []Step
+		for _, s := range SyncSteps(t, msg) {
+			steps = append(steps, s.Step)
+		}
+		return steps
+	}
+	r.Register(&ActionDef{
+		Name:     "sync",
+		Help:     "Sync my work: commit, rebase onto upstream, push",
+		Category: CatRemotes,
+		Screen:   "sync",
+		Prompts: []Prompt{
+			{Key: "message", Label: "Commit message for your changes", Required: true,
+				When: func(ActionInput) bool { return hasChanges(r.Context()) }},
+		},
+		PlanFunc: plan,
+		BuildFunc: func(in ActionInput) (string, []string, string) {
+			steps := plan(in)
+			return "git", steps[0].Args, planPreview(steps)
+		},
+		ValidateFunc: func(in ActionInput) error {
+			_, err := ResolveSync(r.Context())
+			return err
+		},
+	})
+}
+
+// hasChanges reports whether there is anything to commit, untracked files
+// included.
+func hasChanges(ctx context.Context) bool {
+	files, _ := repo.Status(ctx)
+	return len(files) > 0
+}
@@ -0,0 +1,269 @@
+package combos
+
+import (
+	"fmt"
+	"strings"
+
+	"ezgit/internal/parser"
+)
+
+// Selection is what the user picked in the Layer-3 preview: which toggle
+// flags are included and what was typed into the manual inputs. Both maps
+// are keyed by FlagDef.ParamKey.
+type Selection struct {
+	Included map[string]bool
+	Values   map[string]string
+}
+
+// BuildArgs assembles the git argv (without the leading "git") for spec from
+// the first usable entry of spec.Forms. Form tokens are taken literally
+// except for placeholders:
+//
+//	<key> / <key>...     value of the flag whose ParamKey or Key is "key"
+//	[<key>] / [--key]    same, dropped when there is no value
+//	[options] / {flags}  every other included flag, in PreviewOrder
+//	[--]                 "--", kept only when something follows it
+//
+// Included flags that no placeholder consumes are inserted after the leading
+// literal words (the verb) when the form has no options placeholder. The
+// result is what both the preview and the runner use, so they never differ.
+func BuildArgs(spec CommandSpec, sel Selection) []string {
+	form := pickForm(spec, sel)
+	tokens := formTokens(form)
+	if len(tokens) == 0 {
+		tokens = formTokens(spec.Name)
+	}
+	if len(tokens) == 0 {
+		tokens = []string{spec.ActionKey}
+	}
+
+	consumed := map[string]bool{}
+	for _, t := range tokens {
+		if f, ok := placeholderFlag(spec, t); ok {
+			consumed[f.ParamKey] = true
+		}
+	}
+	var rest []string
+	for _, f := range spec.Flags {
+		if consumed[f.ParamKey] {
+			continue
+		}
+		rest = append(rest, flagArgs(f, sel)...)
+	}
+
+	hasOptions := false
+	for _, t := range tokens {
+		if isOptionsPlaceholder(t) {
+			hasOptions = true
+			break
+		}
+	}
+
+	var args []string
+	restPlaced := hasOptions
+	pendingSep := false
+	for _, t := range tokens {
+		if !restPlaced && !isLiteral(t) {
+			args = append(args, rest...)
+			restPlaced = true
+		}
+		switch {
+		case isOptionsPlaceholder(t):
+			args = appendAfterSep(args, &pendingSep, rest)
+		case t == "[--]":
+			pendingSep = true
+		case isLiteral(t):
+			args = appendAfterSep(args, &pendingSep, []string{t})
+		default:
+			if f, ok := placeholderFlag(spec, t); ok {
+				args = appendAfterSep(args, &pendingSep, flagArgs(f, sel))
+			}
+		}
+	}
+	if !restPlaced {
+		args = append(args, rest...)
+	}
+	return args
+}
+
+// Inputs flattens the selection into one value per contributing flag, keyed
+// by ParamKey. Flags without a value (booleans) map to "true".
+func (sel Selection) Inputs(spec CommandSpec) map[string]string {
+	out := map[string]string{}
+	for _, f := range spec.Flags {
+		v, ok := flagValue(f, sel)
+		if !ok {
+			if f.ManualOnly {
+				out[f.ParamKey] = ""
+			}
+			continue
+		}
+		if v == "" {
+			v = "true"
+		}
+		out[f.ParamKey] = v
+	}
+	return out
+}
+
+func appendAfterSep(args []string, pendingSep *bool, vals []string) []string {
+	if len(vals) == 0 {
+		return args
+	}
+	if *pendingSep {
+		args = append(args, "--")
+		*pendingSep = false
+	}
+	return append(args, vals...)
+}
+
+// pickForm returns the first form whose required placeholders all have a
+// value, falling back to the first form.
+func pickForm(spec CommandSpec, sel Selection) string {
+	for _, form := range spec.Forms {
+		ok := true
+		for _, t := range formTokens(form) {
+			if !strings.HasPrefix(t, "<") {
+				continue
+			}
+			f, found := placeholderFlag(spec, t)
+			if !found || len(flagArgs(f, sel)) == 0 {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return form
+		}
+	}
+	if len(spec.Forms) > 0 {
+		return spec.Forms[0]
+	}
+	return ""
+}
+
+func formTokens(form string) []string {
+	tokens := strings.Fields(form)
+	if len(tokens) > 0 && tokens[0] == "git" {
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+func isOptionsPlaceholder(t string) bool {
+	switch strings.ToLower(t) {
+	case "[options]", "[<options>]", "<options>", "[flags]", "{flags}", "{options}":
+		return true
+	}
+	return false
+}
+
+func isLiteral(t string) bool {
+	return !strings.ContainsAny(t, "<>[]{}") && t != "..."
+}
+
+// placeholderFlag resolves a form placeholder to the flag it refers to.
+func placeholderFlag(spec CommandSpec, t string) (FlagDef, bool) {
+	if isLiteral(t) || isOptionsPlaceholder(t) || t == "[--]" {
+		return FlagDef{}, false
+	}
+	name := strings.Trim(t, "[]<>{}.")
+	if name == "" {
+		return FlagDef{}, false
+	}
+	for _, f := range spec.Flags {
+		if f.ParamKey == name || flagName(f.Key) == name || strings.Trim(f.Key, "<>[]{}.") == name {
+			return f, true
+		}
+	}
+	return FlagDef{}, false
+}
+
+// flagName strips any value hint from a flag key: "--depth=<n>" and
+// "--depth <n>" both become "--depth".
+func flagName(key string) string {
+	if i := strings.IndexAny(key, " ="); i > 0 {
+		return key[:i]
+	}
+	return key
+}
+
+func isBoolFlag(f FlagDef) bool {
+	if strings.ToLower(f.Type) == "bool" {
+		return true
+	}
+	_, ok := f.Default.(bool)
+	return ok
+}
+
+// flagValue returns the value a flag contributes and whether it contributes
+// at all.
+func flagValue(f FlagDef, sel Selection) (string, bool) {
+	if f.ManualOnly {
+		v := strings.TrimSpace(sel.Values[f.ParamKey])
+		if v != "" {
+			return v, true
+		}
+		if f.Default != nil && !isBoolFlag(f) {
+			return fmt.Sprintf("%v", f.Default), true
+		}
+		return "", false
+	}
+	if !sel.Included[f.ParamKey] {
+		return "", false
+	}
+	if isBoolFlag(f) || f.Default == nil {
+		return "", true
+	}
+	return fmt.Sprintf("%v", f.Default), true
+}
+
+func isList(f FlagDef) bool {
+	return strings.HasSuffix(f.Key, "...") || strings.ToLower(f.Type) == "list"
+}
+
+// ArgErrors returns, by ParamKey, the list values that cannot be split into
+// arguments, such as one with an unbalanced quote. BuildArgs passes those
+// through as a single token, so a form must not run while any are left.
+func ArgErrors(spec CommandSpec, sel Selection) map[string]string {
+	errs := map[string]string{}
+	for _, f := range spec.Flags {
+		if strings.HasPrefix(f.Key, "-") || !isList(f) {
+			continue
+		}
+		if v, ok := flagValue(f, sel); ok && v != "" {
+			if _, err := parser.SplitArgs(v); err != nil {
+				errs[f.ParamKey] = err.Error()
+			}
+		}
+	}
+	return errs
+}
+
+// flagArgs renders one flag into argv tokens.
+func flagArgs(f FlagDef, sel Selection) []string {
+	v, ok := flagValue(f, sel)
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(f.Key, "-") {
+		if v == "" {
+			return nil
+		}
+		if isList(f) {
+			// ArgErrors reports values that do not split
+			if parts, err := parser.SplitArgs(v); err == nil {
+				return parts
+			}
+		}
+		return []string{v}
+	}
+	name := flagName(f.Key)
+	if v == "" {
+		return []string{name}
+	}
+	if strings.Contains(f.Key, "=") {
+		return []string{name + "=" + v}
+	}
+	return []string{name, v}
+}
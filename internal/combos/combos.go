@@ -75,13 +75,28 @@ func LoadFromFile(path string) (*CombosFile, error) {
 func Register(doc *CombosFile) {
 	mu.Lock()
 	defer mu.Unlock()
+	index(doc, actionMap, aliasMap)
+}
+
+// Replace swaps the registered catalog for doc, so commands removed from
+// the file disappear on reload. The new maps are built first and swapped
+// in under one lock; readers never see a half-built catalog.
+func Replace(doc *CombosFile) {
+	actions, aliases := map[string]CommandSpec{}, map[string]string{}
+	index(doc, actions, aliases)
+	mu.Lock()
+	actionMap, aliasMap = actions, aliases
+	mu.Unlock()
+}
+
+func index(doc *CombosFile, actions map[string]CommandSpec, aliases map[string]string) {
 	for _, c := range doc.Commands {
-		actionMap[c.ActionKey] = c
+		actions[c.ActionKey] = c
 		for _, al := range c.ActionAliases {
-			aliasMap[strings.ToLower(al)] = c.ActionKey
+			aliases[strings.ToLower(al)] = c.ActionKey
 		}
 		if c.Name != "" {
-			aliasMap[strings.ToLower(c.Name)] = c.ActionKey
+			aliases[strings.ToLower(c.Name)] = c.ActionKey
 		}
 	}
 }
@@ -0,0 +1,265 @@
+// Package commitmsg builds, wraps and lints commit messages.
+package commitmsg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"ezgit/internal/config"
+)
+
+// Width is where bodies are wrapped, the width git log and most mail
+// clients assume.
+const Width = 72
+
+// ConventionalTypes are offered by the type picker when the config does not
+// list its own.
+var ConventionalTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string { return t.Key + ": " + t.Value }
+
+// Message is a commit message being composed. Type and Scope are the
+// optional conventional-commit prefix. BodyWidth is where String wraps the
+// body; zero means Width.
+type Message struct {
+	Type      string
+	Scope     string
+	Breaking  bool
+	Subject   string
+	Body      string
+	Trailers  []Trailer
+	BodyWidth int
+}
+
+// Header is the first line: "type(scope)!: subject", or just the subject.
+func (m Message) Header() string {
+	subject := strings.TrimSpace(m.Subject)
+	if m.Type == "" {
+		return subject
+	}
+	h := m.Type
+	if s := strings.TrimSpace(m.Scope); s != "" {
+		h += "(" + s + ")"
+	}
+	if m.Breaking {
+		h += "!"
+	}
+	return h + ": " + subject
+}
+
+// String is the full message: header, wrapped body and trailers, each
+// block separated by a blank line.
+func (m Message) String() string {
+	parts := []string{m.Header()}
+	width := m.BodyWidth
+	if width == 0 {
+		width = Width
+	}
+	if body := strings.TrimSpace(Wrap(m.Body, width)); body != "" {
+		parts = append(parts, body)
+	}
+	if len(m.Trailers) > 0 {
+		lines := make([]string, len(m.Trailers))
+		for i, t := range m.Trailers {
+			lines[i] = t.String()
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// HasTrailer reports whether an identical trailer is already present.
+func (m Message) HasTrailer(t Trailer) bool {
+	for _, x := range m.Trailers {
+		if strings.EqualFold(x.Key, t.Key) && x.Value == t.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap reflows paragraphs to width. Lines that are indented, list items or
+// quoted are left alone, as are words longer than width such as URLs.
+func Wrap(text string, width int) string {
+	var out []string
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		out = append(out, fill(strings.Fields(strings.Join(para, " ")), width)...)
+		para = nil
+	}
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.TrimSpace(l) == "":
+			flush()
+			out = append(out, "")
+		case preformatted(l):
+			flush()
+			out = append(out, strings.TrimRight(l, " \t"))
+		default:
+			para = append(para, strings.TrimSpace(l))
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+var listItem = regexp.MustCompile(`^(\s*[-*+]\s|\s*\d+[.)]\s|\s|>)`)
+
+func preformatted(l string) bool {
+	return listItem.MatchString(l)
+}
+
+func fill(words []string, width int) []string {
+	var lines []string
+	cur := ""
+	for _, w := range words {
+		switch {
+		case cur == "":
+			cur = w
+		case utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(w) <= width:
+			cur += " " + w
+		default:
+			lines = append(lines, cur)
+			cur = w
+		}
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// FromTemplate splits a commit.template file into subject and body, dropping
+// comment lines the way git's default cleanup would.
+func FromTemplate(text string) (subject, body string) {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(l, "#") {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	text = strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return "", ""
+	}
+	subject, body, _ = strings.Cut(text, "\n")
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
+
+// ReadTemplate reads the file named by commit.template, expanding "~/".
+// Relative paths are taken from the top of the working tree, as git does.
+func ReadTemplate(path, top string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	} else if !filepath.IsAbs(path) && top != "" {
+		path = filepath.Join(top, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Issue is one lint finding. Errors block the commit, warnings only need
+// acknowledging.
+type Issue struct {
+	Error   bool
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Error {
+		return "error: " + i.Message
+	}
+	return "warning: " + i.Message
+}
+
+// Blocking reports whether any issue is an error.
+func Blocking(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks m against the configured rules; zero values in rules take
+// the defaults documented on config.CommitLint.
+func Lint(m Message, rules config.CommitLint) []Issue {
+	var issues []Issue
+	add := func(err bool, format string, a ...any) {
+		issues = append(issues, Issue{Error: err, Message: fmt.Sprintf(format, a...)})
+	}
+	max, warn, width := rules.SubjectMax, rules.SubjectWarn, rules.BodyWidth
+	if max == 0 {
+		max = Width
+	}
+	if warn == 0 {
+		warn = 50
+	}
+	if width == 0 {
+		width = Width
+	}
+	header := m.Header()
+	n := utf8.RuneCountInString(header)
+	switch {
+	case strings.TrimSpace(m.Subject) == "":
+		add(true, "subject is empty")
+	case n > max:
+		add(true, "subject is %d characters, limit is %d", n, max)
+	case n > warn:
+		add(false, "subject is %d characters, aim for %d or fewer", n, warn)
+	}
+	if strings.HasSuffix(strings.TrimSpace(m.Subject), ".") && !rules.AllowTrailingPeriod {
+		add(false, "subject ends with a period")
+	}
+	if rules.RequireConventional && m.Type == "" {
+		add(true, "a conventional commit type is required")
+	}
+	if m.Type != "" {
+		types := rules.Types
+		if len(types) == 0 {
+			types = ConventionalTypes
+		}
+		known := false
+		for _, t := range types {
+			if t == m.Type {
+				known = true
+			}
+		}
+		if !known {
+			add(true, "type %q is not one of %s", m.Type, strings.Join(types, ", "))
+		}
+	}
+	if rules.RequireBody && strings.TrimSpace(m.Body) == "" {
+		add(true, "a body explaining the change is required")
+	}
+	for i, l := range strings.Split(Wrap(m.Body, width), "\n") {
+		if utf8.RuneCountInString(l) > width && !strings.HasPrefix(strings.TrimSpace(l), "http") {
+			add(false, "body line %d is longer than %d characters", i+1, width)
+		}
+	}
+	for _, w := range rules.Forbid {
+		if w != "" && strings.Contains(strings.ToLower(header), strings.ToLower(w)) {
+			add(true, "subject contains %q", w)
+		}
+	}
+	return issues
+}
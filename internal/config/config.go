@@ -7,20 +7,75 @@ import (
 )
 
 type Config struct {
-	DataDir     string `json:"data_dir"`
-	EnableAudit bool   `json:"enable_audit"`
+	DataDir       string         `json:"data_dir"`
+	EnableAudit   bool           `json:"enable_audit"`
+	CustomActions []CustomAction `json:"custom_actions,omitempty"`
+	CommitLint    CommitLint     `json:"commit_lint"`
+	// InitialBranch names the first branch of repositories made by init;
+	// empty falls back to git's init.defaultBranch, then "main".
+	InitialBranch string `json:"initial_branch,omitempty"`
 }
 
-func LoadOrCreate() (*Config, error) {
+// CommitLint configures the checks run before a commit from the composer.
+// Zero values mean the default: a 72 column hard limit and 50 column soft
+// limit on the subject, bodies wrapped at 72 and the usual conventional
+// commit types.
+type CommitLint struct {
+	SubjectMax          int      `json:"subject_max,omitempty"`
+	SubjectWarn         int      `json:"subject_warn,omitempty"`
+	BodyWidth           int      `json:"body_width,omitempty"`
+	AllowTrailingPeriod bool     `json:"allow_trailing_period,omitempty"`
+	RequireConventional bool     `json:"require_conventional,omitempty"`
+	Types               []string `json:"types,omitempty"`
+	RequireBody         bool     `json:"require_body,omitempty"`
+	// Forbid lists words that may not appear in the subject, e.g. "WIP".
+	Forbid []string `json:"forbid,omitempty"`
+}
+
+// CustomAction is a user-defined verb. Args is the git argv without the
+// leading "git"; a "{key}" inside an arg is replaced by the answer to the
+// prompt with that key.
+type CustomAction struct {
+	Name        string         `json:"name"`
+	Help        string         `json:"help"`
+	Category    int            `json:"category"`
+	Args        []string       `json:"args"`
+	Prompts     []CustomPrompt `json:"prompts,omitempty"`
+	Destructive bool           `json:"destructive,omitempty"`
+	// Terminal runs the command attached to the terminal, for commands
+	// that open an editor or prompt.
+	Terminal bool `json:"terminal,omitempty"`
+}
+
+// CustomPrompt mirrors action.Prompt. Type is one of bool, enum, int, path,
+// ref, branch, commit, stash, multiline or multi-select; empty means text.
+type CustomPrompt struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Default  string   `json:"default"`
+	Required bool     `json:"required"`
+	Type     string   `json:"type,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+func Dir() string {
 	home := os.Getenv("HOME")
 	if home == "" {
 		home = "."
 	}
-	dir := filepath.Join(home, ".ezgit")
+	return filepath.Join(home, ".ezgit")
+}
+
+func Path() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+func LoadOrCreate() (*Config, error) {
+	dir := Dir()
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return nil, err
 	}
-	cfgFile := filepath.Join(dir, "config.json")
+	cfgFile := Path()
 	cfg := &Config{
 		DataDir:     dir,
 		EnableAudit: true,
@@ -36,3 +91,20 @@ func LoadOrCreate() (*Config, error) {
 	}
 	return cfg, nil
 }
+
+// Load reads the config at path and, unlike LoadOrCreate, reports parse
+// errors so a bad edit can be surfaced while the TUI keeps running.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		DataDir:     filepath.Dir(path),
+		EnableAudit: true,
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,179 @@
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ezgit/internal/action"
+	"ezgit/internal/combos"
+)
+
+// Subcommands are the git commands offered for the first word, on top of
+// whatever the combos catalog knows.
+var Subcommands = []string{
+	"add", "am", "apply", "archive", "bisect", "blame", "branch", "bundle",
+	"checkout", "cherry", "cherry-pick", "clean", "clone", "commit", "config",
+	"count-objects", "describe", "diff", "fetch", "format-patch", "fsck", "gc",
+	"grep", "help", "init", "log", "ls-files", "ls-remote", "merge", "mergetool",
+	"mv", "notes", "prune", "pull", "push", "range-diff", "rebase", "reflog",
+	"remote", "reset", "restore", "revert", "rm", "shortlog", "show",
+	"sparse-checkout", "stash", "status", "submodule", "switch", "tag",
+	"worktree",
+}
+
+// remoteFirst are subcommands whose first positional argument is a remote.
+var remoteFirst = map[string]bool{"push": true, "pull": true, "fetch": true, "ls-remote": true}
+
+// Completer offers completions for a raw git command line.
+type Completer struct {
+	Refs    []string
+	Remotes []string
+	// Dir is where relative paths are resolved; empty means the current
+	// directory.
+	Dir string
+	// Actions describe ezgit's own verbs in Help.
+	Actions *action.Registry
+}
+
+// Complete returns the candidates for the last word of line and the byte
+// offset where that word starts.
+func (c *Completer) Complete(line string) ([]string, int) {
+	start := strings.LastIndexAny(line, " \t") + 1
+	word := line[start:]
+	words := strings.Fields(line[:start])
+	if len(words) > 0 && words[0] == "git" {
+		words = words[1:]
+	}
+
+	var pool []string
+	switch {
+	case len(words) == 0:
+		pool = subcommands()
+	case strings.HasPrefix(word, "-"):
+		pool = options(words[0])
+	case remoteFirst[words[0]] && positionalCount(words[1:]) == 0:
+		pool = c.Remotes
+	default:
+		pool = append(append([]string{}, c.Refs...), c.paths(word)...)
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, p := range pool {
+		if strings.HasPrefix(p, word) && !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out, start
+}
+
+// CommonPrefix is the longest prefix shared by every candidate.
+func CommonPrefix(cands []string) string {
+	if len(cands) == 0 {
+		return ""
+	}
+	p := cands[0]
+	for _, c := range cands[1:] {
+		for !strings.HasPrefix(c, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	return p
+}
+
+// Help is the one-line description of the subcommand at the start of line.
+func (c *Completer) Help(line string) string {
+	words := strings.Fields(line)
+	if len(words) > 0 && words[0] == "git" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	if spec, ok := combos.Get(words[0]); ok && spec.Description != "" {
+		return spec.Description
+	}
+	if c.Actions == nil {
+		return ""
+	}
+	if a, ok := c.Actions.Get(words[0]); ok {
+		return a.Help
+	}
+	return ""
+}
+
+func subcommands() []string {
+	out := append([]string{}, Subcommands...)
+	for _, k := range combos.RegisteredKeys() {
+		if spec, ok := combos.Get(k); ok && spec.Name != "" {
+			if f := strings.Fields(spec.Name); len(f) > 0 && f[0] != "git" {
+				out = append(out, f[0])
+			} else if len(f) > 1 {
+				out = append(out, f[1])
+			}
+		}
+	}
+	return out
+}
+
+// options lists the flags the combos catalog knows for sub.
+func options(sub string) []string {
+	spec, ok := combos.Get(sub)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, f := range spec.Flags {
+		if strings.HasPrefix(f.Key, "-") {
+			key := f.Key
+			if i := strings.IndexAny(key, " ="); i > 0 {
+				key = key[:i]
+			}
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func positionalCount(words []string) int {
+	n := 0
+	for _, w := range words {
+		if !strings.HasPrefix(w, "-") {
+			n++
+		}
+	}
+	return n
+}
+
+// paths completes word against the filesystem; directories get a trailing
+// slash so completion can continue into them.
+func (c *Completer) paths(word string) []string {
+	dir, base := filepath.Split(word)
+	look := dir
+	if look == "" {
+		look = "."
+	}
+	if c.Dir != "" && !filepath.IsAbs(look) {
+		look = filepath.Join(c.Dir, look)
+	}
+	entries, err := os.ReadDir(look)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (name == ".git" && dir == "") {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		out = append(out, dir+name)
+	}
+	return out
+}
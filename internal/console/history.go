@@ -0,0 +1,138 @@
+package console
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory bounds what is kept. Add only appends to the file, so
+// LoadHistory rewrites it down to this many lines once it has grown past
+// twice that.
+const maxHistory = 1000
+
+// History is the per-repository list of raw commands, oldest first.
+type History struct {
+	path    string
+	entries []string
+	pos     int
+	draft   string
+}
+
+// HistoryPath is where the history of the repository at top is stored under
+// dataDir. The repository path is hashed so the file name stays portable.
+func HistoryPath(dataDir, top string) string {
+	sum := sha1.Sum([]byte(top))
+	return filepath.Join(dataDir, "history", hex.EncodeToString(sum[:])[:16]+".txt")
+}
+
+func LoadHistory(path string) *History {
+	h := &History{path: path}
+	if f, err := os.Open(path); err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if l := sc.Text(); strings.TrimSpace(l) != "" {
+				h.entries = append(h.entries, l)
+			}
+		}
+		f.Close()
+	}
+	if len(h.entries) > 2*maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		_ = h.rewrite()
+	} else if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	h.pos = len(h.entries)
+	return h
+}
+
+// rewrite replaces the file with the entries in memory.
+func (h *History) rewrite() error {
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add records line, skipping an immediate repeat, and appends it to disk.
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	defer h.Reset()
+	if line == "" || strings.Contains(line, "\n") {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
+// Reset moves the cursor back past the newest entry.
+func (h *History) Reset() {
+	h.pos = len(h.entries)
+	h.draft = ""
+}
+
+// Prev steps to the previous entry. current is remembered as the draft the
+// first time so Next can return to it.
+func (h *History) Prev(current string) (string, bool) {
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	if h.pos == 0 {
+		return current, false
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+func (h *History) Next() (string, bool) {
+	if h.pos >= len(h.entries) {
+		return h.draft, false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+// Search finds the newest entry containing query strictly before index
+// before (use len(Entries()) to search from the end). It returns the
+// entry's index, or -1.
+func (h *History) Search(query string, before int) int {
+	if before > len(h.entries) {
+		before = len(h.entries)
+	}
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
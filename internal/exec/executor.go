@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
@@ -13,12 +14,96 @@ import (
 	"time"
 )
 
-type Runner struct{}
+// Cmd is one command for an Executor to run.
+type Cmd struct {
+	Name string
+	Args []string
+	// Env is added to the inherited environment.
+	Env []string
+	// Stream receives output lines as they arrive; Progress, when set,
+	// receives git's progress meters instead of Stream.
+	Stream   StreamCallback
+	Progress func(Progress)
+	// Timeout kills the command after this long; zero means no limit.
+	Timeout time.Duration
+	// Stdin, Stdout and Stderr, when any is set, attach the command to
+	// them instead of capturing its output, e.g. for an editor on the
+	// terminal. The Result then carries only the exit status.
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+}
+
+// Attached reports whether c runs on the caller's streams.
+func (c Cmd) Attached() bool {
+	return c.Stdin != nil || c.Stdout != nil || c.Stderr != nil
+}
+
+// Result is what a finished command left behind. Output is trimmed of its
+// final newline and keeps only the last state of redrawn progress lines.
+type Result struct {
+	Exit   int
+	Stdout string
+	Stderr string
+}
+
+// Executor runs commands. The TUI, actions and safety checks reach git only
+// through one, so Runner can be swapped for a Recorder or a Replayer.
+type Executor interface {
+	Exec(ctx context.Context, c Cmd) (Result, error)
+}
+
+// Runner runs commands for real.
+type Runner struct {
+	// Env is added to the inherited environment.
+	Env []string
+	// OnProgress, when set, receives git's progress meters as structured
+	// events; those lines then no longer reach the StreamCallback.
+	OnProgress func(Progress)
+}
 
 type StreamCallback func(line string, isErr bool)
 
+func (r *Runner) Exec(ctx context.Context, c Cmd) (Result, error) {
+	run := Runner{Env: append(append([]string{}, r.Env...), c.Env...), OnProgress: r.OnProgress}
+	if c.Attached() {
+		return run.attach(ctx, c)
+	}
+	if c.Progress != nil {
+		run.OnProgress = c.Progress
+	}
+	exit, out, errOut, err := run.Run(ctx, c.Name, c.Args, c.Stream, c.Timeout)
+	return Result{Exit: exit, Stdout: out, Stderr: errOut}, err
+}
+
+// attach runs c on the streams it names. Only a command that could not
+// start or was killed reports an error, with exit status -1.
+func (r *Runner) attach(ctx context.Context, c Cmd) (Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = c.Stdin, c.Stdout, c.Stderr
+	err := cmd.Run()
+	exit := -1
+	if cmd.ProcessState != nil {
+		exit = cmd.ProcessState.ExitCode()
+	}
+	if exit >= 0 {
+		err = nil
+	}
+	return Result{Exit: exit}, err
+}
+
 func (r *Runner) Run(ctx context.Context, name string, args []string, streamCb StreamCallback, timeout time.Duration) (int, string, string, error) {
 	cmd := exec.CommandContext(ctx, name, args...)
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
 		return -1, "", "", err
@@ -38,20 +123,37 @@ func (r *Runner) Run(ctx context.Context, name string, args []string, streamCb S
 	readPipe := func(rdr io.Reader, dest *bytes.Buffer, isErr bool) {
 		defer wg.Done()
 		scanner := bufio.NewScanner(rdr)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+		lines := &lineSplitter{}
+		scanner.Split(lines.split)
 		for scanner.Scan() {
 			line := scanner.Text()
-			dest.WriteString(line + "\n")
+			// a line ending in a lone '\r' is redrawn; only keep the last
+			if !lines.cr {
+				dest.WriteString(line + "\n")
+			}
+			if r.OnProgress != nil {
+				if p, ok := ParseProgress(line); ok {
+					r.OnProgress(p)
+					continue
+				}
+			}
 			if streamCb != nil {
 				streamCb(line, isErr)
 			}
 		}
+		// keep draining so the command never blocks on a full pipe
+		_, _ = io.Copy(io.Discard, rdr)
 	}
 
 	go readPipe(stdoutPipe, &stdoutBuf, false)
 	go readPipe(stderrPipe, &stderrBuf, true)
 
+	// Wait closes the pipes, so it may only run once both readers are done
+	// or it can drop the tail of the output.
 	errCh := make(chan error, 1)
 	go func() {
+		wg.Wait()
 		errCh <- cmd.Wait()
 	}()
 	var waitErr error
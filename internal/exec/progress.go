@@ -0,0 +1,106 @@
+package exec
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Progress is one update of a git progress meter, e.g.
+// "Receiving objects:  45% (450/1000), 1.2 MiB | 800 KiB/s". Meters that
+// only count, like "Enumerating objects: 12", have a zero Total.
+type Progress struct {
+	Phase   string
+	Percent int
+	Current int64
+	Total   int64
+	// Done is set on the final ", done." update of the phase.
+	Done bool
+}
+
+var (
+	progressRe = regexp.MustCompile(`^(?:remote: *)?([A-Za-z][A-Za-z ]*?):\s+(\d+)% \((\d+)/(\d+)\)(.*)$`)
+	countRe    = regexp.MustCompile(`^(?:remote: *)?([A-Z][A-Za-z ]*? objects):\s+(\d+)(, done\.)?$`)
+)
+
+// progressVerbs are the git commands that print meters to a pipe when
+// asked with --progress.
+var progressVerbs = map[string]bool{"clone": true, "fetch": true, "pull": true, "push": true}
+
+// ParseProgress recognizes a git progress line.
+func ParseProgress(line string) (Progress, bool) {
+	line = strings.TrimSpace(line)
+	m := progressRe.FindStringSubmatch(line)
+	if m == nil {
+		if m = countRe.FindStringSubmatch(line); m == nil {
+			return Progress{}, false
+		}
+		p := Progress{Phase: m[1], Done: m[3] != ""}
+		p.Current, _ = strconv.ParseInt(m[2], 10, 64)
+		return p, true
+	}
+	p := Progress{Phase: m[1]}
+	p.Percent, _ = strconv.Atoi(m[2])
+	p.Current, _ = strconv.ParseInt(m[3], 10, 64)
+	p.Total, _ = strconv.ParseInt(m[4], 10, 64)
+	p.Done = strings.Contains(m[5], "done")
+	return p, true
+}
+
+// WithProgress adds --progress to a git argv (without "git") whose command
+// reports progress, since git stays quiet when stderr is not a terminal.
+func WithProgress(args []string) []string {
+	i, _ := Subcommand(args)
+	if i >= len(args) || !progressVerbs[args[i]] {
+		return args
+	}
+	for _, a := range args[i+1:] {
+		if a == "--" {
+			break
+		}
+		if a == "--progress" || a == "--no-progress" || a == "-q" || a == "--quiet" {
+			return args
+		}
+	}
+	out := append([]string{}, args[:i+1]...)
+	out = append(out, "--progress")
+	return append(out, args[i+1:]...)
+}
+
+// maxLine bounds a line; longer output is handed on in pieces rather than
+// failing the scan with bufio.ErrTooLong.
+const maxLine = 1 << 20
+
+// lineSplitter is a bufio.SplitFunc like ScanLines that also ends a line
+// at a lone '\r', which is how git redraws its progress meters in place.
+// cr tells whether the last line ended that way, i.e. is about to be
+// overdrawn.
+type lineSplitter struct {
+	cr bool
+}
+
+func (s *lineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	s.cr = false
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// a '\r' may be the start of "\r\n"; wait for the next byte
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		s.cr = true
+		return i + 1, data[:i], nil
+	}
+	if atEOF || len(data) >= maxLine {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
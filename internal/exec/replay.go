@@ -0,0 +1,184 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Call is one recorded command run, as stored in a fixture file. Env holds
+// only what the caller added, never the inherited environment.
+type Call struct {
+	Argv   []string `json:"argv"`
+	Env    []string `json:"env,omitempty"`
+	Stdout string   `json:"stdout"`
+	Stderr string   `json:"stderr"`
+	Exit   int      `json:"exit"`
+	Err    string   `json:"error,omitempty"`
+}
+
+// Recorder runs commands through Next and keeps every run, so a session
+// against a real repository can be saved as a fixture.
+type Recorder struct {
+	Next Executor
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) Exec(ctx context.Context, c Cmd) (Result, error) {
+	res, err := r.Next.Exec(ctx, c)
+	call := Call{
+		Argv:   append([]string{c.Name}, c.Args...),
+		Env:    c.Env,
+		Stdout: res.Stdout,
+		Stderr: res.Stderr,
+		Exit:   res.Exit,
+	}
+	if err != nil {
+		call.Err = err.Error()
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+	return res, err
+}
+
+// Calls returns the runs recorded so far, in the order they finished.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Save writes the recorded runs to a fixture file at path.
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Calls(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Replayer answers commands from recorded runs instead of running them.
+// A command gets the first unused run with the same argv and env; once all
+// of those are used, the last one answers again, since status queries are
+// repeated freely. A command never recorded fails.
+type Replayer struct {
+	mu    sync.Mutex
+	calls []Call
+	used  []bool
+}
+
+func NewReplayer(calls []Call) *Replayer {
+	return &Replayer{calls: calls, used: make([]bool, len(calls))}
+}
+
+// LoadReplay reads a fixture file written by Recorder.Save.
+func LoadReplay(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var calls []Call
+	if err := json.Unmarshal(b, &calls); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewReplayer(calls), nil
+}
+
+func (p *Replayer) Exec(ctx context.Context, c Cmd) (Result, error) {
+	argv := append([]string{c.Name}, c.Args...)
+	p.mu.Lock()
+	found := -1
+	for i, call := range p.calls {
+		if !reflect.DeepEqual(call.Argv, argv) || !sameEnv(call.Env, c.Env) {
+			continue
+		}
+		found = i
+		if !p.used[i] {
+			break
+		}
+	}
+	if found >= 0 {
+		p.used[found] = true
+	}
+	p.mu.Unlock()
+	if found < 0 {
+		return Result{Exit: -1}, fmt.Errorf("replay: no recording for %s", strings.Join(argv, " "))
+	}
+	call := p.calls[found]
+	if c.Attached() {
+		replayAttached(call, c)
+	}
+	replayStream(call.Stdout, false, c)
+	replayStream(call.Stderr, true, c)
+	res := Result{Exit: call.Exit, Stdout: call.Stdout, Stderr: call.Stderr}
+	if call.Err != "" {
+		return res, errors.New(call.Err)
+	}
+	return res, nil
+}
+
+// Unused returns the recorded runs nobody asked for, so a test can check
+// that a flow ran every command it was recorded with.
+func (p *Replayer) Unused() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Call
+	for i, call := range p.calls {
+		if !p.used[i] {
+			out = append(out, call)
+		}
+	}
+	return out
+}
+
+// replayAttached writes recorded output to the streams of an attached
+// command, as the process itself would have.
+func replayAttached(call Call, c Cmd) {
+	if c.Stdout != nil {
+		io.WriteString(c.Stdout, call.Stdout)
+	}
+	if c.Stderr != nil {
+		io.WriteString(c.Stderr, call.Stderr)
+	}
+}
+
+// replayStream feeds recorded output to the callbacks the way Runner would.
+func replayStream(out string, isErr bool, c Cmd) {
+	if out == "" || (c.Stream == nil && c.Progress == nil) {
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	lines := &lineSplitter{}
+	scanner.Split(lines.split)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if c.Progress != nil {
+			if pr, ok := ParseProgress(line); ok {
+				c.Progress(pr)
+				continue
+			}
+		}
+		if c.Stream != nil {
+			c.Stream(line, isErr)
+		}
+	}
+}
+
+func sameEnv(a, b []string) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
@@ -0,0 +1,122 @@
+package exec
+
+import "strings"
+
+// NoPromptEnv keeps git from asking for credentials on a terminal it does
+// not own; the request fails with CredentialPromptError instead.
+var NoPromptEnv = []string{"GIT_TERMINAL_PROMPT=0"}
+
+// CredentialPromptError is the stderr fragment git prints when it needed to
+// prompt but NoPromptEnv forbade it.
+const CredentialPromptError = "terminal prompts disabled"
+
+// globalValueOptions are the git options before the subcommand that take
+// the next argument as their value.
+var globalValueOptions = map[string]bool{"-c": true, "-C": true, "--git-dir": true, "--work-tree": true, "--namespace": true}
+
+// Subcommand finds the subcommand of the git argv args (without "git"),
+// past git's own options such as "-C dir" or "-c key=value". It returns
+// its index, len(args) when there is none, and the -c settings on the way.
+func Subcommand(args []string) (int, []string) {
+	var config []string
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		if globalValueOptions[args[i]] {
+			i++
+			if args[i-1] == "-c" && i < len(args) {
+				config = append(config, args[i])
+			}
+		}
+		i++
+	}
+	if i > len(args) {
+		i = len(args)
+	}
+	return i, config
+}
+
+// NeedsTerminal reports whether the git argv args (without "git") will
+// open an editor, a pager-like viewer or an interactive prompt, and so has
+// to run attached to the real terminal rather than with piped output.
+func NeedsTerminal(args []string) bool {
+	i, config := Subcommand(args)
+	// -c sequence.editor=... makes "rebase -i" scriptable
+	scripted := false
+	// -c core.editor=true accepts every message unchanged
+	noEditor := false
+	for _, c := range config {
+		if strings.HasPrefix(strings.ToLower(c), "sequence.editor=") {
+			scripted = true
+		}
+		if strings.EqualFold(c, "core.editor=true") || strings.EqualFold(c, "core.editor=:") {
+			noEditor = true
+		}
+	}
+	if i >= len(args) {
+		return false
+	}
+	verb, rest := args[i], args[i+1:]
+	has := func(flags ...string) bool {
+		for _, a := range rest {
+			if a == "--" {
+				return false
+			}
+			for _, f := range flags {
+				if a == f || (strings.HasPrefix(f, "--") && strings.HasPrefix(a, f+"=")) {
+					return true
+				}
+				if len(f) == 2 && !strings.HasPrefix(a, "--") && strings.HasPrefix(a, "-") && strings.ContainsRune(a[1:], rune(f[1])) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	sub := ""
+	for _, a := range rest {
+		if !strings.HasPrefix(a, "-") {
+			sub = a
+			break
+		}
+	}
+	switch verb {
+	case "commit", "rebase", "tag", "merge", "revert", "notes", "config":
+		// these only want the terminal for the editor
+		if noEditor {
+			return false
+		}
+	}
+	switch verb {
+	case "commit":
+		if has("--no-edit") && !has("-e", "--edit") {
+			return false
+		}
+		return has("-e", "--edit") || !has("-m", "--message", "-F", "--file", "-C", "--reuse-message", "--fixup")
+	case "rebase":
+		if scripted && !has("--edit-todo") {
+			return false
+		}
+		return has("-i", "--interactive", "--edit-todo") || (has("--continue") && !has("--no-edit"))
+	case "add", "reset", "checkout", "restore", "stash":
+		return has("-p", "--patch") || (verb == "add" && has("-i", "--interactive"))
+	case "clean":
+		return has("-i", "--interactive")
+	case "mergetool", "difftool", "gui", "citool", "gitk":
+		return true
+	case "bisect":
+		return sub == "visualize" || sub == "view"
+	case "tag":
+		return has("-a", "--annotate", "-s", "--sign") && !has("-m", "--message", "-F", "--file")
+	case "merge":
+		return has("-e", "--edit", "--continue")
+	case "revert":
+		return !has("--no-edit", "-n", "--no-commit", "--abort", "--quit", "--skip")
+	case "notes":
+		return (sub == "add" || sub == "edit" || sub == "append") && !has("-m", "--message", "-F", "--file", "-C")
+	case "help":
+		return true
+	case "config":
+		return has("-e", "--edit")
+	}
+	return false
+}
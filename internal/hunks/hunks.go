@@ -0,0 +1,96 @@
+// Package hunks splits unified diffs into hunks and reassembles a patch
+// from a chosen subset, for staging part of a file.
+package hunks
+
+import "strings"
+
+type Hunk struct {
+	Header string
+	Lines  []string
+}
+
+// File is one "diff --git" section. Files without hunks (binary files,
+// mode-only changes) and untracked files can only be staged whole.
+type File struct {
+	Path      string
+	Header    []string
+	Hunks     []Hunk
+	Binary    bool
+	Untracked bool
+}
+
+// Whole reports whether the file is staged as a unit rather than by hunk.
+func (f File) Whole() bool {
+	return f.Untracked || f.Binary || len(f.Hunks) == 0
+}
+
+// Parse splits "git diff" output into files and hunks.
+func Parse(diff string) []File {
+	var files []File
+	var cur *File
+	var hunk *Hunk
+	for _, l := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			files = append(files, File{Path: pathFromDiffLine(l), Header: []string{l}})
+			cur = &files[len(files)-1]
+			hunk = nil
+		case cur == nil:
+			continue
+		case strings.HasPrefix(l, "@@"):
+			cur.Hunks = append(cur.Hunks, Hunk{Header: l})
+			hunk = &cur.Hunks[len(cur.Hunks)-1]
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, l)
+		default:
+			cur.Header = append(cur.Header, l)
+			if strings.HasPrefix(l, "Binary files ") || l == "GIT binary patch" {
+				cur.Binary = true
+			}
+			if p, ok := strings.CutPrefix(l, "+++ b/"); ok {
+				cur.Path = p
+			} else if p, ok := strings.CutPrefix(l, "--- a/"); ok && cur.Path == "" {
+				cur.Path = p
+			}
+		}
+	}
+	return files
+}
+
+func pathFromDiffLine(l string) string {
+	rest := strings.TrimPrefix(l, "diff --git ")
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+// Patch rebuilds a patch containing only the hunks for which keep returns
+// true. Each file header is emitted once, and files with nothing kept are
+// left out. Hunk headers keep their original line numbers, which still
+// locate them correctly in the index because unselected hunks leave the
+// old side untouched.
+func Patch(files []File, keep func(file, hunk int) bool) string {
+	var b strings.Builder
+	for fi, f := range files {
+		var kept []Hunk
+		for hi, h := range f.Hunks {
+			if keep(fi, hi) {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		for _, l := range f.Header {
+			b.WriteString(l + "\n")
+		}
+		for _, h := range kept {
+			b.WriteString(h.Header + "\n")
+			for _, l := range h.Lines {
+				b.WriteString(l + "\n")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+// Package ignore edits gitignore-style files.
+package ignore
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Append adds patterns to the ignore file at path, creating it and its
+// directory if needed. Patterns already present are skipped; the ones
+// actually written are returned.
+func Append(path string, patterns []string) ([]string, error) {
+	return appendUnder(path, "", patterns)
+}
+
+// Merge adds the lines of template that path does not have yet under a
+// "# name" heading. Blank lines and comments of the template are dropped,
+// so merging twice, or merging templates that share lines, adds nothing.
+func Merge(path, name, template string) ([]string, error) {
+	var patterns []string
+	for _, l := range strings.Split(template, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			patterns = append(patterns, l)
+		}
+	}
+	return appendUnder(path, "# "+name, patterns)
+}
+
+// appendUnder writes the new patterns, preceded by heading when there is
+// one and anything is written at all.
+func appendUnder(path, heading string, patterns []string) ([]string, error) {
+	existing := map[string]bool{}
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		existing[strings.TrimSpace(l)] = true
+	}
+	var added []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || existing[p] {
+			continue
+		}
+		existing[p] = true
+		added = append(added, p)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	text := strings.Join(added, "\n") + "\n"
+	if heading != "" {
+		text = heading + "\n" + text
+		if len(b) > 0 {
+			text = "\n" + text
+		}
+	}
+	if len(b) > 0 && !strings.HasSuffix(string(b), "\n") {
+		text = "\n" + text
+	}
+	if _, err := f.WriteString(text); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// PathPattern turns a path relative to the top of the working tree into a
+// pattern that matches exactly that path.
+func PathPattern(root string) string {
+	r := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+	p := "/" + r.Replace(root)
+	if strings.HasSuffix(p, " ") {
+		p = strings.TrimSuffix(p, " ") + `\ `
+	}
+	return p
+}
+
+// Pattern kinds for Pattern.
+const (
+	KindFile = "file"
+	KindExt  = "extension"
+	KindDir  = "directory"
+)
+
+// Pattern returns the pattern that ignores root, a path relative to the
+// top of the working tree, by kind: the file itself, every file with its
+// extension, or its directory. A root with a trailing slash is a whole
+// untracked directory and its pattern only matches directories. Patterns
+// for a global excludes file are not anchored, since they apply to every
+// repository. "" means kind does not apply, e.g. a file without extension
+// or at the top.
+func Pattern(root, kind string, global bool) string {
+	root = filepath.ToSlash(root)
+	isDir := strings.HasSuffix(root, "/")
+	root = strings.TrimSuffix(root, "/")
+	suffix := ""
+	if isDir {
+		suffix = "/"
+	}
+	switch kind {
+	case KindExt:
+		base := path.Base(root)
+		ext := path.Ext(base)
+		if isDir || ext == "" || ext == base {
+			return ""
+		}
+		return "*" + ext
+	case KindDir:
+		dir := path.Dir(root)
+		if dir == "." {
+			return ""
+		}
+		if global {
+			return path.Base(dir) + "/"
+		}
+		return PathPattern(dir) + "/"
+	}
+	if global {
+		return PathPattern(path.Base(root))[1:] + suffix
+	}
+	return PathPattern(root) + suffix
+}
@@ -0,0 +1,31 @@
+package ignore
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/*.gitignore
+var templateFS embed.FS
+
+// Templates lists the embedded .gitignore templates by name, e.g. "Go".
+func Templates() []string {
+	entries, _ := templateFS.ReadDir("templates")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".gitignore"))
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	return names
+}
+
+// Template returns the embedded template called name.
+func Template(name string) (string, error) {
+	b, err := templateFS.ReadFile("templates/" + name + ".gitignore")
+	if err != nil {
+		return "", fmt.Errorf("no .gitignore template %q", name)
+	}
+	return string(b), nil
+}
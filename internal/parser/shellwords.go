@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnbalancedSingle = errors.New("unterminated single quote")
+	ErrUnbalancedDouble = errors.New("unterminated double quote")
+	ErrTrailingEscape   = errors.New("trailing backslash")
+)
+
+// SplitArgs splits s into argv the way a POSIX shell would, without
+// expanding anything: single quotes are literal, double quotes allow \" \\
+// \$ and \` escapes, and a backslash outside quotes escapes the next
+// character. Unbalanced quotes are an error rather than a guess.
+func SplitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", r) {
+				cur.WriteRune('\\')
+			}
+			if r != '\n' {
+				cur.WriteRune(r)
+			}
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	switch {
+	case escaped:
+		return nil, ErrTrailingEscape
+	case quote == '\'':
+		return nil, ErrUnbalancedSingle
+	case quote == '"':
+		return nil, ErrUnbalancedDouble
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// JoinArgs renders argv for display so that every token boundary is
+// visible: tokens that need it are single-quoted. It is only ever shown to
+// the user; nothing is passed through a shell.
+func JoinArgs(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = QuoteArg(a)
+	}
+	return strings.Join(out, " ")
+}
+
+// QuoteArg quotes a single token for display when it would otherwise be
+// ambiguous.
+func QuoteArg(a string) string {
+	if a == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(a, " \t\n\r'\"\\$`*?[]();&|<>#") {
+		return a
+	}
+	return "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+}
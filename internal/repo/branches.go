@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Branch is a local branch as the branch manager shows it.
+type Branch struct {
+	Name     string
+	Current  bool
+	Upstream string
+	Ahead    int
+	Behind   int
+	// Gone is set when the upstream was deleted on the remote.
+	Gone    bool
+	Date    string
+	Author  string
+	Subject string
+	// Merged is set when the branch is fully merged into the default
+	// branch.
+	Merged bool
+}
+
+// Stale reports whether the branch is a cleanup candidate.
+func (b Branch) Stale() bool { return b.Merged || b.Gone }
+
+const branchFormat = "--format=%(HEAD)%00%(refname:short)%00%(upstream:short)%00%(upstream:track,nobracket)%00%(committerdate:relative)%00%(authorname)%00%(contents:subject)"
+
+// ParseBranches parses for-each-ref output in branchFormat.
+func ParseBranches(out string) []Branch {
+	var bs []Branch
+	for _, l := range Lines(out) {
+		f := strings.Split(l, "\x00")
+		if len(f) < 7 {
+			continue
+		}
+		b := Branch{Current: f[0] == "*", Name: f[1], Upstream: f[2], Date: f[4], Author: f[5], Subject: f[6]}
+		for _, part := range strings.Split(f[3], ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "gone":
+				b.Gone = true
+			case strings.HasPrefix(part, "ahead "):
+				b.Ahead, _ = strconv.Atoi(strings.TrimPrefix(part, "ahead "))
+			case strings.HasPrefix(part, "behind "):
+				b.Behind, _ = strconv.Atoi(strings.TrimPrefix(part, "behind "))
+			}
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// Branches lists the local branches, most recently committed first, with
+// Merged filled in against base.
+func Branches(ctx context.Context, base string) ([]Branch, error) {
+	out, err := Git(ctx, "for-each-ref", "--sort=-committerdate", branchFormat, "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	bs := ParseBranches(out)
+	if base == "" {
+		return bs, nil
+	}
+	merged := map[string]bool{}
+	if out, err := Git(ctx, "for-each-ref", "--merged", base, "--format=%(refname:short)", "refs/heads"); err == nil {
+		for _, n := range Lines(out) {
+			merged[n] = true
+		}
+	}
+	for i := range bs {
+		bs[i].Merged = merged[bs[i].Name]
+	}
+	return bs, nil
+}
+
+// DefaultBranch guesses the branch others merge into: the remote's HEAD,
+// then init.defaultBranch, main or master when they exist locally. It
+// returns the ref to compare against, local when there is one.
+func DefaultBranch(ctx context.Context) string {
+	exists := func(ref string) bool {
+		_, err := Git(ctx, "rev-parse", "--verify", "--quiet", ref)
+		return err == nil
+	}
+	if r, err := Git(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil && r != "" {
+		if local := strings.TrimPrefix(r, "origin/"); exists("refs/heads/" + local) {
+			return local
+		}
+		return r
+	}
+	candidates := []string{"main", "master"}
+	if d, err := Git(ctx, "config", "init.defaultBranch"); err == nil && d != "" {
+		candidates = append([]string{d}, candidates...)
+	}
+	for _, c := range candidates {
+		if exists("refs/heads/" + c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// BranchRemote is the remote a branch tracks, or "".
+func BranchRemote(ctx context.Context, branch string) string {
+	r, _ := Git(ctx, "config", "branch."+branch+".remote")
+	return r
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+)
+
+// Conflicts lists the unmerged paths, relative to the top of the working
+// tree.
+func Conflicts(ctx context.Context) []string {
+	out, err := GitRaw(ctx, "diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// MergeInProgress reports whether a merge stopped before its commit.
+func MergeInProgress(ctx context.Context) bool {
+	_, err := Git(ctx, "rev-parse", "--verify", "--quiet", "MERGE_HEAD")
+	return err == nil
+}
+
+// HasConflictMarkers reports whether path still has a "<<<<<<<" line.
+func HasConflictMarkers(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<24)
+	for sc.Scan() {
+		if strings.HasPrefix(sc.Text(), "<<<<<<< ") {
+			return true
+		}
+	}
+	return false
+}
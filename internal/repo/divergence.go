@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Divergence compares HEAD with its upstream after a fetch.
+type Divergence struct {
+	Upstream string
+	Ahead    int
+	Behind   int
+	// Local and Remote are the commits only on HEAD and only on the
+	// upstream, newest first, in --oneline form (at most divergenceShown).
+	Local  []string
+	Remote []string
+	// LocalMerges is set when the local-only commits include merges,
+	// which a rebase would flatten.
+	LocalMerges bool
+	// LocalShared is set when some local-only commit is already on another
+	// remote branch, so rebasing it would rewrite shared history.
+	LocalShared bool
+}
+
+const divergenceShown = 20
+
+// Diverge works out how HEAD and upstream differ.
+func Diverge(ctx context.Context, upstream string) (Divergence, error) {
+	d := Divergence{Upstream: upstream}
+	out, err := Git(ctx, "rev-list", "--left-right", "--count", "HEAD..."+upstream)
+	if err != nil {
+		return d, err
+	}
+	if f := strings.Fields(out); len(f) == 2 {
+		d.Ahead, _ = strconv.Atoi(f[0])
+		d.Behind, _ = strconv.Atoi(f[1])
+	}
+	n := "-n" + strconv.Itoa(divergenceShown)
+	if out, err := Git(ctx, "log", "--oneline", n, upstream+"..HEAD"); err == nil {
+		d.Local = Lines(out)
+	}
+	if out, err := Git(ctx, "log", "--oneline", n, "HEAD.."+upstream); err == nil {
+		d.Remote = Lines(out)
+	}
+	d.LocalMerges = Count(ctx, "--min-parents=2", upstream+"..HEAD") > 0
+	if d.Ahead > 0 {
+		unshared := Count(ctx, "HEAD", "--not", upstream, "--remotes")
+		d.LocalShared = unshared < d.Ahead
+	}
+	return d, nil
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Where ignore rules can be written.
+const (
+	IgnoreRepo    = ".gitignore"
+	IgnoreExclude = ".git/info/exclude"
+	IgnoreGlobal  = "global excludes file"
+)
+
+// IgnoreFile returns the file behind where: the top-level .gitignore, this
+// clone's info/exclude, or the global excludes file (core.excludesFile, or
+// git's default under XDG_CONFIG_HOME).
+func IgnoreFile(ctx context.Context, where string) (string, error) {
+	switch where {
+	case IgnoreExclude:
+		p, err := Git(ctx, "rev-parse", "--git-path", "info/exclude")
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(p)
+	case IgnoreGlobal:
+		if p, err := Git(ctx, "config", "--path", "--get", "core.excludesFile"); err == nil && p != "" {
+			return p, nil
+		}
+		if x := os.Getenv("XDG_CONFIG_HOME"); x != "" {
+			return filepath.Join(x, "git", "ignore"), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".config", "git", "ignore"), nil
+	}
+	top, err := Toplevel(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(top, ".gitignore"), nil
+}
+
+// IgnoreRule is the rule that ignores a path, as check-ignore -v reports it.
+type IgnoreRule struct {
+	Path    string
+	Source  string
+	Line    string
+	Pattern string
+}
+
+// WhyIgnored runs check-ignore -v on paths, relative to the top, and
+// returns the rule for each ignored one.
+func WhyIgnored(ctx context.Context, paths []string) map[string]IgnoreRule {
+	rules := map[string]IgnoreRule{}
+	if len(paths) == 0 {
+		return rules
+	}
+	top, err := Toplevel(ctx)
+	if err != nil {
+		return rules
+	}
+	// exits 1 when nothing is ignored; the output is still valid
+	out, _ := GitRaw(ctx, append([]string{"-C", top, "-c", "core.quotePath=false", "check-ignore", "-v", "--"}, paths...)...)
+	for _, l := range Lines(out) {
+		if r, ok := parseIgnoreRule(l); ok {
+			rules[r.Path] = r
+		}
+	}
+	return rules
+}
+
+var ignoreRuleRe = regexp.MustCompile(`^(.*?):(\d+):(.*)\t(.*)$`)
+
+// parseIgnoreRule parses one "source:line:pattern<TAB>path" line of
+// check-ignore -v.
+func parseIgnoreRule(l string) (IgnoreRule, bool) {
+	m := ignoreRuleRe.FindStringSubmatch(l)
+	if m == nil {
+		return IgnoreRule{}, false
+	}
+	p := m[4]
+	if u, err := strconv.Unquote(p); err == nil && strings.HasPrefix(p, `"`) {
+		p = u
+	}
+	return IgnoreRule{Source: m[1], Line: m[2], Pattern: m[3], Path: p}, true
+}
+
+// Untracked lists untracked files that are not ignored, or with ignored
+// set, the ignored ones, relative to the top. A directory with nothing
+// tracked in it is listed once, with a trailing slash, like git status
+// does.
+func Untracked(ctx context.Context, ignored bool) ([]string, error) {
+	top, err := Toplevel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-C", top, "ls-files", "-z", "--others", "--exclude-standard", "--directory", "--no-empty-directory"}
+	if ignored {
+		args = append(args, "--ignored")
+	}
+	out, err := GitRaw(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TodoLine is one entry of an interactive rebase todo list. For "exec"
+// Subject holds the shell command and Commit is empty.
+type TodoLine struct {
+	Action  string
+	Commit  string
+	Subject string
+}
+
+func (l TodoLine) String() string {
+	if l.Commit == "" {
+		return l.Action + " " + l.Subject
+	}
+	return l.Action + " " + l.Commit + " " + l.Subject
+}
+
+// Todo lists the commits a rebase onto base would replay, oldest first, all
+// as "pick". Merges are refused since a plain rebase -i would flatten them.
+func Todo(ctx context.Context, base string) ([]TodoLine, error) {
+	rng := "HEAD"
+	if base != "" {
+		rng = base + "..HEAD"
+	}
+	if Count(ctx, "--min-parents=2", rng) > 0 {
+		return nil, fmt.Errorf("there are merge commits after %s; rewrite them from the console instead", base)
+	}
+	out, err := Git(ctx, "log", "--reverse", "--format=%H %s", rng)
+	if err != nil {
+		return nil, err
+	}
+	var todo []TodoLine
+	for _, l := range Lines(out) {
+		sha, subject, _ := strings.Cut(l, " ")
+		todo = append(todo, TodoLine{Action: "pick", Commit: sha, Subject: subject})
+	}
+	return todo, nil
+}
+
+// SequenceEditor writes todo to the git directory and returns the
+// "-c sequence.editor=..." arguments that make rebase -i use it verbatim
+// instead of opening an editor. Git runs the editor through its own shell,
+// so cp is available on every platform git is.
+func SequenceEditor(ctx context.Context, todo []TodoLine) ([]string, error) {
+	path, err := Git(ctx, "rev-parse", "--git-path", "EZGIT_REBASE_TODO")
+	if err != nil {
+		return nil, err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for _, l := range todo {
+		b.WriteString(l.String() + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return nil, err
+	}
+	return []string{"-c", "sequence.editor=cp " + shellQuote(filepath.ToSlash(path))}, nil
+}
+
+// Reword replaces the message of sha, a commit on the current branch, and
+// replays the commits after it. The message goes through a file and an
+// exec line in the generated todo, so no editor is involved. It is taken
+// as typed, "#" lines included, like the composer's commits. A failed
+// rebase is aborted, leaving the branch as it was.
+func Reword(ctx context.Context, sha, message string) error {
+	base := sha + "^"
+	if _, err := RevParse(ctx, base); err != nil {
+		base = ""
+	}
+	todo, err := Todo(ctx, base)
+	if err != nil {
+		return err
+	}
+	if len(todo) == 0 || todo[0].Commit != sha {
+		return fmt.Errorf("%s is not on the current branch", sha)
+	}
+	msgPath, err := Git(ctx, "rev-parse", "--git-path", "EZGIT_REWORD_MSG")
+	if err != nil {
+		return err
+	}
+	if msgPath, err = filepath.Abs(msgPath); err != nil {
+		return err
+	}
+	if err := os.WriteFile(msgPath, []byte(message), 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(msgPath)
+	amend := TodoLine{Action: "exec", Subject: "git commit --quiet --amend --only --allow-empty --cleanup=whitespace -F " + shellQuote(filepath.ToSlash(msgPath))}
+	todo = append([]TodoLine{todo[0], amend}, todo[1:]...)
+	editor, err := SequenceEditor(ctx, todo)
+	if err != nil {
+		return err
+	}
+	args := append(editor, "rebase", "-i", "--autostash")
+	if base == "" {
+		args = append(args, "--root")
+	} else {
+		args = append(args, base)
+	}
+	if _, err := Git(ctx, args...); err != nil {
+		if RebaseInProgress(ctx) {
+			_, _ = Git(ctx, "rebase", "--abort")
+		}
+		return err
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RebaseInProgress reports whether a rebase has stopped and is waiting to be
+// continued or aborted.
+func RebaseInProgress(ctx context.Context) bool {
+	for _, d := range []string{"rebase-merge", "rebase-apply"} {
+		p, err := Git(ctx, "rev-parse", "--git-path", d)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Backup records HEAD under refs/ezgit/backup/<what>-<time> before a
+// history rewrite and returns the ref name.
+func Backup(ctx context.Context, what string) (string, error) {
+	ref := "refs/ezgit/backup/" + what + "-" + time.Now().Format("20060102-150405")
+	if _, err := Git(ctx, "update-ref", "-m", "ezgit: backup before "+what, ref, "HEAD"); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
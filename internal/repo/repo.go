@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	execpkg "ezgit/internal/exec"
+)
+
+type executorKey struct{}
+
+// WithExecutor returns a context under which the git commands of this
+// package, and so of the actions and safety checks built on it, run
+// through e, e.g. a Recorder or a Replayer.
+func WithExecutor(ctx context.Context, e execpkg.Executor) context.Context {
+	return context.WithValue(ctx, executorKey{}, e)
+}
+
+// ExecutorFrom returns the executor ctx carries, or one that runs
+// commands for real.
+func ExecutorFrom(ctx context.Context) execpkg.Executor {
+	if e, ok := ctx.Value(executorKey{}).(execpkg.Executor); ok && e != nil {
+		return e
+	}
+	return &execpkg.Runner{}
+}
+
+// Git runs git with args and returns its trimmed stdout. A non-zero exit is
+// reported as an error carrying git's stderr.
+func Git(ctx context.Context, args ...string) (string, error) {
+	out, err := GitRaw(ctx, args...)
+	return strings.TrimSpace(out), err
+}
+
+// GitRaw is Git without trimming, for output where leading whitespace is
+// significant such as porcelain status.
+func GitRaw(ctx context.Context, args ...string) (string, error) {
+	res, err := ExecutorFrom(ctx).Exec(ctx, execpkg.Cmd{Name: "git", Args: args})
+	if err != nil {
+		return "", err
+	}
+	out := res.Stdout
+	if res.Exit != 0 {
+		msg := strings.TrimSpace(res.Stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", res.Exit)
+		}
+		return out, fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return out, nil
+}
+
+// Toplevel is the root of the working tree containing the current directory.
+func Toplevel(ctx context.Context) (string, error) {
+	return Git(ctx, "rev-parse", "--show-toplevel")
+}
+
+// CurrentBranch returns the checked-out branch, or "" on a detached HEAD.
+func CurrentBranch(ctx context.Context) string {
+	b, err := Git(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return b
+}
+
+// Upstream returns the short name of branch's upstream, e.g. "origin/main".
+func Upstream(ctx context.Context, branch string) string {
+	u, err := Git(ctx, "rev-parse", "--abbrev-ref", "--symbolic-full-name", branch+"@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return u
+}
+
+// RevParse resolves rev to a full object name.
+func RevParse(ctx context.Context, rev string) (string, error) {
+	return Git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// Count returns the number of commits selected by the rev-list arguments.
+func Count(ctx context.Context, revs ...string) int {
+	out, err := Git(ctx, append([]string{"rev-list", "--count"}, revs...)...)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(out)
+	return n
+}
+
+// Lines splits command output into non-empty lines.
+func Lines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
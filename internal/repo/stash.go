@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"strings"
+)
+
+// switchStashTag starts the message of stashes made when switching away
+// from a branch with uncommitted changes; the source branch follows it.
+const switchStashTag = "ezgit-switch:"
+
+// SwitchStashMessage is the stash message that ties the work to branch.
+func SwitchStashMessage(branch string) string {
+	return switchStashTag + branch + " work in progress saved before switching away"
+}
+
+// Stash is one stash entry. Branch is set for entries made by
+// SwitchStashMessage.
+type Stash struct {
+	Ref     string
+	Subject string
+	Branch  string
+}
+
+// ParseStashList parses "stash list --format=%gd%x00%s".
+func ParseStashList(out string) []Stash {
+	var ss []Stash
+	for _, l := range Lines(out) {
+		ref, subject, ok := strings.Cut(l, "\x00")
+		if !ok {
+			continue
+		}
+		s := Stash{Ref: ref, Subject: subject}
+		// "On <branch>: <message>"
+		if _, msg, ok := strings.Cut(subject, ": "); ok && strings.HasPrefix(msg, switchStashTag) {
+			s.Branch, _, _ = strings.Cut(strings.TrimPrefix(msg, switchStashTag), " ")
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+// SwitchStashes lists the stashes saved when switching away from branch,
+// newest first.
+func SwitchStashes(ctx context.Context, branch string) []Stash {
+	if branch == "" {
+		return nil
+	}
+	out, err := Git(ctx, "stash", "list", "--format=%gd%x00%s")
+	if err != nil {
+		return nil
+	}
+	var ss []Stash
+	for _, s := range ParseStashList(out) {
+		if s.Branch == branch {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// Dirty reports whether tracked files have staged or unstaged changes.
+func Dirty(ctx context.Context) bool {
+	out, err := Git(ctx, "status", "--porcelain", "--untracked-files=no")
+	return err == nil && out != ""
+}
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStatus is one entry of porcelain status. X is the index column and Y
+// the working tree column, as in "git status --short".
+type FileStatus struct {
+	X, Y byte
+	// Path is relative to the current directory, ready to pass to git.
+	Path string
+	// Root is relative to the top of the working tree.
+	Root string
+	// Orig is the source of a rename or copy, relative to the top.
+	Orig string
+}
+
+func (f FileStatus) Code() string { return string([]byte{f.X, f.Y}) }
+
+func (f FileStatus) Untracked() bool { return f.X == '?' }
+
+func (f FileStatus) Staged() bool { return f.X != ' ' && f.X != '?' && f.X != '!' }
+
+func (f FileStatus) Unstaged() bool { return f.Y != ' ' && f.X != '?' }
+
+// Status lists changed and untracked files. Untracked directories are
+// expanded so every file can be picked on its own.
+func Status(ctx context.Context) ([]FileStatus, error) {
+	out, err := GitRaw(ctx, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	files := ParseStatusZ(out)
+	top, err := Toplevel(ctx)
+	if err != nil {
+		return files, nil
+	}
+	cwd, _ := os.Getwd()
+	for i := range files {
+		files[i].Path = relToCwd(top, cwd, files[i].Root)
+	}
+	return files, nil
+}
+
+// ParseStatusZ parses "git status --porcelain=v1 -z" output. Paths are left
+// relative to the top of the working tree in both Path and Root.
+func ParseStatusZ(out string) []FileStatus {
+	var files []FileStatus
+	recs := strings.Split(out, "\x00")
+	for i := 0; i < len(recs); i++ {
+		r := recs[i]
+		if len(r) < 4 {
+			continue
+		}
+		f := FileStatus{X: r[0], Y: r[1], Path: r[3:], Root: r[3:]}
+		if (f.X == 'R' || f.X == 'C') && i+1 < len(recs) {
+			i++
+			f.Orig = recs[i]
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// TrackedFiles lists the tracked files under the current directory, with
+// their status overlaid where they have changes.
+func TrackedFiles(ctx context.Context) ([]FileStatus, error) {
+	out, err := GitRaw(ctx, "ls-files", "-z", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	changed := map[string]FileStatus{}
+	if st, err := Status(ctx); err == nil {
+		for _, f := range st {
+			changed[f.Root] = f
+		}
+	}
+	top, _ := Toplevel(ctx)
+	cwd := ""
+	if top != "" {
+		cwd, _ = os.Getwd()
+	}
+	var files []FileStatus
+	for _, p := range strings.Split(out, "\x00") {
+		if p == "" {
+			continue
+		}
+		if f, ok := changed[p]; ok {
+			files = append(files, f)
+			continue
+		}
+		files = append(files, FileStatus{X: ' ', Y: ' ', Path: relToCwd(top, cwd, p), Root: p})
+	}
+	return files, nil
+}
+
+func relToCwd(top, cwd, p string) string {
+	if cwd == "" {
+		return p
+	}
+	rel, err := filepath.Rel(cwd, filepath.Join(top, filepath.FromSlash(p)))
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
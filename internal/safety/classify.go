@@ -0,0 +1,347 @@
+package safety
+
+import (
+	"fmt"
+	"strings"
+
+	execpkg "ezgit/internal/exec"
+)
+
+type Level int
+
+const (
+	LevelSafe Level = iota
+	// LevelCaution rewrites something but keeps it recoverable (reflog,
+	// backup refs); it is shown but does not need typed confirmation.
+	LevelCaution
+	// LevelDestructive can lose data for good and needs typed confirmation.
+	LevelDestructive
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelCaution:
+		return "caution"
+	case LevelDestructive:
+		return "destructive"
+	}
+	return "safe"
+}
+
+// Verdict is the classification of one git argv.
+type Verdict struct {
+	Level   Level
+	Reasons []string
+	AtRisk  []string
+}
+
+func (v Verdict) Destructive() bool {
+	return v.Level >= LevelDestructive
+}
+
+// Lines renders the verdict for the preview and confirmation screens.
+func (v Verdict) Lines() []string {
+	var out []string
+	for _, r := range v.Reasons {
+		out = append(out, r)
+	}
+	for _, a := range v.AtRisk {
+		out = append(out, "at risk: "+a)
+	}
+	return out
+}
+
+func (v *Verdict) flag(l Level, reason string, atRisk ...string) {
+	if l > v.Level {
+		v.Level = l
+	}
+	v.Reasons = append(v.Reasons, reason)
+	v.AtRisk = append(v.AtRisk, atRisk...)
+}
+
+// argv is a parsed git command line. Long options keep their "--name"
+// spelling, combined short options are split into one entry per letter.
+type argv struct {
+	verb  string
+	opts  map[string]string
+	pos   []string
+	paths []string // after "--"
+	dash  bool
+}
+
+// shortWithValue lists short options that consume the next argument, per
+// subcommand, so their values are not mistaken for paths or refs.
+var shortWithValue = map[string]string{
+	"branch":   "u",
+	"checkout": "bB",
+	"clean":    "e",
+	"commit":   "mFcC",
+	"merge":    "mFsX",
+	"push":     "o",
+	"rebase":   "xsX",
+	"restore":  "s",
+	"stash":    "m",
+	"switch":   "cC",
+	"tag":      "mFu",
+	"worktree": "bB",
+}
+
+// longWithValue lists long options that consume the next argument when
+// written without "=".
+var longWithValue = map[string]bool{
+	"--source": true, "--message": true, "--exclude": true, "--orphan": true,
+	"--create": true, "--force-create": true, "--set-upstream-to": true,
+	"--push-option": true, "--repo": true, "--receive-pack": true,
+	"--onto": true, "--exec": true, "--strategy": true, "--strategy-option": true,
+}
+
+func parseArgv(args []string) argv {
+	a := argv{opts: map[string]string{}}
+	i, _ := execpkg.Subcommand(args)
+	if i >= len(args) {
+		return a
+	}
+	a.verb = args[i]
+	valued := shortWithValue[a.verb]
+	for i = i + 1; i < len(args); i++ {
+		s := args[i]
+		switch {
+		case a.dash:
+			a.paths = append(a.paths, s)
+		case s == "--":
+			a.dash = true
+		case strings.HasPrefix(s, "--"):
+			name, val := s, ""
+			if eq := strings.Index(s, "="); eq >= 0 {
+				name, val = s[:eq], s[eq+1:]
+			} else if longWithValue[s] && i+1 < len(args) {
+				i++
+				val = args[i]
+			}
+			a.opts[name] = val
+		case strings.HasPrefix(s, "-") && len(s) > 1:
+			for j, r := range s[1:] {
+				key := "-" + string(r)
+				if strings.ContainsRune(valued, r) {
+					if rest := s[2+j:]; rest != "" {
+						a.opts[key] = rest
+					} else if i+1 < len(args) {
+						i++
+						a.opts[key] = args[i]
+					}
+					break
+				}
+				a.opts[key] = ""
+			}
+		default:
+			a.pos = append(a.pos, s)
+		}
+	}
+	return a
+}
+
+func (a argv) has(names ...string) bool {
+	for _, n := range names {
+		if _, ok := a.opts[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a argv) value(names ...string) string {
+	for _, n := range names {
+		if v, ok := a.opts[n]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Classify inspects a git argv (without the leading "git") and reports
+// whether it can lose data, why, and what exactly is at risk.
+func Classify(args []string) Verdict {
+	var v Verdict
+	a := parseArgv(args)
+	sub := ""
+	if len(a.pos) > 0 {
+		sub = a.pos[0]
+	}
+	switch a.verb {
+	case "reset":
+		if a.has("--hard") {
+			target := "HEAD"
+			if len(a.pos) > 0 {
+				target = a.pos[0]
+			}
+			v.flag(LevelDestructive, "reset --hard discards all uncommitted changes to tracked files", "uncommitted changes in tracked files")
+			if target != "HEAD" {
+				v.AtRisk = append(v.AtRisk, fmt.Sprintf("commits not reachable from %s (recoverable only via reflog)", target))
+			}
+		}
+	case "clean":
+		if a.has("-n", "--dry-run") {
+			break
+		}
+		if a.has("-f", "--force") {
+			what := "untracked files"
+			switch {
+			case a.has("-X"):
+				what = "ignored files"
+			case a.has("-x"):
+				what = "untracked and ignored files (build output, local config, .env)"
+			}
+			if a.has("-d") {
+				what += " and directories"
+			}
+			v.flag(LevelDestructive, "clean deletes "+what+"; they are not in git and cannot be recovered", what)
+		} else if a.has("-i", "--interactive") {
+			v.flag(LevelCaution, "interactive clean deletes the untracked files you pick")
+		}
+	case "checkout":
+		paths := a.paths
+		if !a.dash && len(a.pos) > 0 && (a.pos[0] == "." || len(a.pos) > 1) && !a.has("-b", "-B", "--orphan") {
+			paths = a.pos
+			if a.pos[0] != "." {
+				paths = a.pos[1:]
+			}
+		}
+		if len(paths) > 0 && a.has("--ours", "--theirs") {
+			// picking a side of a conflict; both sides stay in the commits
+			v.flag(LevelCaution, "checkout --ours/--theirs replaces "+strings.Join(paths, ", ")+" with one side of the conflict")
+		} else if len(paths) > 0 {
+			v.flag(LevelDestructive, "checkout of paths overwrites local modifications to those files", "uncommitted changes in "+strings.Join(paths, ", "))
+		}
+		if a.has("-f", "--force") {
+			v.flag(LevelDestructive, "checkout --force throws away local changes", "uncommitted changes")
+		}
+		if a.has("-B") {
+			v.flag(LevelCaution, "checkout -B resets branch "+a.value("-B")+" if it exists")
+		}
+	case "restore":
+		staged := a.has("-S", "--staged")
+		worktree := a.has("-W", "--worktree")
+		if worktree || !staged {
+			paths := append(append([]string{}, a.pos...), a.paths...)
+			v.flag(LevelDestructive, "restore overwrites working tree files with the index/source version", "uncommitted changes in "+strings.Join(paths, ", "))
+		}
+	case "switch":
+		if a.has("-f", "--force", "--discard-changes") {
+			v.flag(LevelDestructive, "switch --discard-changes throws away local changes", "uncommitted changes")
+		}
+		if a.has("-C", "--force-create") {
+			v.flag(LevelCaution, "switch -C resets branch "+a.value("-C", "--force-create")+" if it exists")
+		}
+	case "stash":
+		switch sub {
+		case "drop":
+			entry := "stash@{0}"
+			if len(a.pos) > 1 {
+				entry = a.pos[1]
+			}
+			v.flag(LevelDestructive, "stash drop deletes a stash entry", entry)
+		case "clear":
+			v.flag(LevelDestructive, "stash clear deletes every stash entry", "all stash entries")
+		}
+	case "branch":
+		force := a.has("-f", "--force")
+		switch {
+		case a.has("-D") || (a.has("-d", "--delete") && force):
+			v.flag(LevelDestructive, "branch -D deletes branches even if they are not merged", branchList(a.pos)...)
+		case a.has("-M", "-C") || (a.has("-m", "-c", "--move", "--copy") && force):
+			target := ""
+			if len(a.pos) > 0 {
+				target = a.pos[len(a.pos)-1]
+			}
+			v.flag(LevelDestructive, "forced rename/copy overwrites an existing branch", "branch "+target)
+		}
+	case "tag":
+		if a.has("-d", "--delete") {
+			v.flag(LevelDestructive, "tag -d deletes tags", tagList(a.pos)...)
+		} else if a.has("-f", "--force") {
+			v.flag(LevelCaution, "tag --force moves an existing tag")
+		}
+	case "push":
+		classifyPush(&v, a)
+	case "gc":
+		if p := a.value("--prune"); p == "now" || p == "all" {
+			v.flag(LevelDestructive, "gc --prune="+p+" deletes unreachable objects immediately", "commits only reachable from the reflog or dangling")
+		}
+	case "prune":
+		if !a.has("-n", "--dry-run") {
+			v.flag(LevelDestructive, "prune deletes unreachable objects", "dangling commits and blobs")
+		}
+	case "reflog":
+		if sub == "expire" || sub == "delete" {
+			v.flag(LevelDestructive, "reflog "+sub+" removes recovery points", "reflog entries")
+		}
+	case "worktree":
+		if sub == "remove" && a.has("-f", "--force") {
+			v.flag(LevelDestructive, "worktree remove --force deletes a worktree with uncommitted changes", "worktree "+strings.Join(a.pos[1:], " "))
+		}
+	case "update-ref":
+		if a.has("-d") {
+			v.flag(LevelDestructive, "update-ref -d deletes a ref", strings.Join(a.pos, " "))
+		} else if len(a.pos) >= 2 {
+			v.flag(LevelCaution, "update-ref moves "+a.pos[0])
+		}
+	case "rm":
+		if !a.has("--cached") && a.has("-f", "--force") {
+			paths := append(append([]string{}, a.pos...), a.paths...)
+			v.flag(LevelDestructive, "rm -f deletes files including local modifications", "uncommitted changes in "+strings.Join(paths, ", "))
+		}
+	case "filter-branch", "filter-repo":
+		v.flag(LevelDestructive, a.verb+" rewrites every matching commit", "history of the rewritten refs")
+	case "commit":
+		if a.has("--amend") {
+			v.flag(LevelCaution, "commit --amend replaces the last commit")
+		}
+	}
+	return v
+}
+
+func classifyPush(v *Verdict, a argv) {
+	var refspecs []string
+	if len(a.pos) > 1 {
+		refspecs = a.pos[1:]
+	}
+	switch {
+	case a.has("--force", "-f"):
+		v.flag(LevelDestructive, "push --force overwrites the remote branch", "remote commits not present locally")
+	case a.has("--force-with-lease"):
+		v.flag(LevelDestructive, "push --force-with-lease overwrites the remote branch if it still matches what you last fetched", "remote commits not present locally")
+	}
+	for _, rs := range refspecs {
+		if strings.HasPrefix(rs, "+") {
+			v.flag(LevelDestructive, "refspec "+rs+" force-updates the remote ref", "remote commits on "+strings.TrimPrefix(rs, "+"))
+		}
+		if strings.HasPrefix(rs, ":") {
+			v.flag(LevelDestructive, "refspec "+rs+" deletes the remote ref", "remote ref "+strings.TrimPrefix(rs, ":"))
+		}
+	}
+	if a.has("--delete", "-d") {
+		v.flag(LevelDestructive, "push --delete removes remote refs", refspecs...)
+	}
+	if a.has("--mirror") {
+		v.flag(LevelDestructive, "push --mirror overwrites and deletes remote refs to match local", "every remote ref")
+	}
+	if a.has("--prune") {
+		v.flag(LevelDestructive, "push --prune deletes remote branches that have no local counterpart", "remote-only branches")
+	}
+}
+
+func branchList(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		out = append(out, "branch "+n)
+	}
+	return out
+}
+
+func tagList(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		out = append(out, "tag "+n)
+	}
+	return out
+}
@@ -11,6 +11,7 @@ import (
 
 type Config struct {
 	RequireTypedConfirmation bool
+	Policy                   *Policy
 }
 
 type Safety struct {
@@ -21,19 +22,31 @@ func New(cfg Config) *Safety {
 	return &Safety{cfg: cfg}
 }
 
+func (s *Safety) SetPolicy(p *Policy) {
+	s.cfg.Policy = p
+}
+
+func (s *Safety) Policy() *Policy {
+	return s.cfg.Policy
+}
+
+// Evaluate runs the repository policy against the final argv of a command.
+func (s *Safety) Evaluate(cmd string, args []string, rc RepoContext) []Finding {
+	if cmd != "git" {
+		return nil
+	}
+	return s.cfg.Policy.Evaluate(args, rc)
+}
+
 func (s *Safety) RequiresConfirmation(cmd string, args []string) (bool, string) {
 	if !s.cfg.RequireTypedConfirmation {
 		return false, ""
 	}
-	if s.IsDestructive(cmd, args) {
-		return true, "Destructive operation — requires typed confirmation."
+	if cmd != "git" {
+		return false, ""
 	}
-	if cmd == "git" && len(args) > 0 && args[0] == "push" {
-		for _, a := range args {
-			if a == "--force" || a == "-f" {
-				return true, "Force push detected — destructive."
-			}
-		}
+	if v := Classify(args); v.Destructive() {
+		return true, strings.Join(v.Reasons, "; ")
 	}
 	return false, ""
 }
@@ -42,24 +55,7 @@ func (s *Safety) IsDestructive(cmd string, args []string) bool {
 	if cmd != "git" {
 		return false
 	}
-	if len(args) == 0 {
-		return false
-	}
-	verb := args[0]
-	switch verb {
-	case "reset", "clean", "rebase", "filter-branch", "push":
-		if verb == "push" {
-			for _, a := range args {
-				if strings.Contains(a, "force") || a == "-f" {
-					return true
-				}
-			}
-			return false
-		}
-		return true
-	default:
-		return false
-	}
+	return Classify(args).Destructive()
 }
 
 func TypedPrompt(cmd string, args []string) string {
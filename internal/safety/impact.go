@@ -0,0 +1,269 @@
+package safety
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+// impactLimit caps each section so a huge reset doesn't flood the screen.
+const impactLimit = 15
+
+type LostFile struct {
+	Path string
+	Size int64
+}
+
+// Impact is what a destructive command would lose, computed before it runs.
+type Impact struct {
+	Diffstat    []string
+	Deleted     []LostFile
+	Unreachable []string
+	Overwritten []string
+	Notes       []string
+}
+
+func (i Impact) Empty() bool {
+	return len(i.Diffstat) == 0 && len(i.Deleted) == 0 && len(i.Unreachable) == 0 && len(i.Overwritten) == 0 && len(i.Notes) == 0
+}
+
+// Lines renders the impact for the confirmation screen.
+func (i Impact) Lines() []string {
+	var out []string
+	if len(i.Diffstat) > 0 {
+		out = append(out, "Uncommitted changes that will be discarded:")
+		out = append(out, indent(capLines(i.Diffstat))...)
+	}
+	if len(i.Deleted) > 0 {
+		var total int64
+		files := make([]string, 0, len(i.Deleted))
+		for _, f := range i.Deleted {
+			total += f.Size
+			files = append(files, fmt.Sprintf("%s (%s)", f.Path, humanSize(f.Size)))
+		}
+		out = append(out, fmt.Sprintf("Files that will be deleted (%d, %s):", len(i.Deleted), humanSize(total)))
+		out = append(out, indent(capLines(files))...)
+	}
+	if len(i.Unreachable) > 0 {
+		out = append(out, fmt.Sprintf("Commits that become unreachable (%d):", len(i.Unreachable)))
+		out = append(out, indent(capLines(i.Unreachable))...)
+	}
+	if len(i.Overwritten) > 0 {
+		out = append(out, fmt.Sprintf("Remote commits that will be overwritten (%d, as of last fetch):", len(i.Overwritten)))
+		out = append(out, indent(capLines(i.Overwritten))...)
+	}
+	out = append(out, i.Notes...)
+	return out
+}
+
+// ComputeImpact inspects the repository to work out what the git argv args
+// would lose. Commands it knows nothing about yield an empty Impact.
+func ComputeImpact(ctx context.Context, args []string) Impact {
+	var im Impact
+	a := parseArgv(args)
+	switch a.verb {
+	case "reset":
+		if !a.has("--hard") {
+			break
+		}
+		im.Diffstat = diffstat(ctx, "HEAD")
+		if len(a.pos) > 0 && a.pos[0] != "HEAD" {
+			im.Unreachable = unreachable(ctx, "HEAD", a.pos[0], repo.CurrentBranch(ctx))
+		}
+	case "clean":
+		if a.has("-f", "--force") && !a.has("-n", "--dry-run") {
+			im.Deleted = cleanVictims(ctx, args)
+		}
+	case "checkout", "restore":
+		paths := append(append([]string{}, a.pos...), a.paths...)
+		if a.verb == "checkout" && len(a.paths) == 0 && len(a.pos) > 1 {
+			paths = a.pos[1:]
+		}
+		if a.verb == "checkout" && a.has("-f", "--force") && len(a.paths) == 0 && len(paths) <= 1 {
+			im.Diffstat = diffstat(ctx, "HEAD")
+			break
+		}
+		if len(paths) > 0 {
+			im.Diffstat = diffstat(ctx, "", paths...)
+		}
+	case "switch":
+		if a.has("-f", "--force", "--discard-changes") {
+			im.Diffstat = diffstat(ctx, "HEAD")
+		}
+	case "branch":
+		if !a.has("-D") && !(a.has("-d", "--delete") && a.has("-f", "--force")) {
+			break
+		}
+		for _, b := range a.pos {
+			for _, c := range unreachable(ctx, "refs/heads/"+b, "", b) {
+				im.Unreachable = append(im.Unreachable, b+": "+c)
+			}
+		}
+	case "stash":
+		if len(a.pos) > 0 && a.pos[0] == "drop" {
+			entry := "stash@{0}"
+			if len(a.pos) > 1 {
+				entry = a.pos[1]
+			}
+			if out, err := repo.Git(ctx, "stash", "show", "--stat", entry); err == nil {
+				im.Diffstat = repo.Lines(out)
+			}
+		}
+	case "push":
+		if Classify(args).Destructive() {
+			im.Overwritten = overwrittenOnRemote(ctx, a)
+		}
+	}
+	return im
+}
+
+func diffstat(ctx context.Context, rev string, paths ...string) []string {
+	args := []string{"diff", "--stat"}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	out, err := repo.Git(ctx, args...)
+	if err != nil {
+		return nil
+	}
+	return repo.Lines(out)
+}
+
+// unreachable lists commits reachable from tip that no other ref (besides
+// the branch being moved or deleted) still holds.
+func unreachable(ctx context.Context, tip, keep, branch string) []string {
+	args := []string{"log", "--oneline", tip, "--not"}
+	if keep != "" {
+		args = append(args, keep)
+	}
+	if branch != "" {
+		args = append(args, "--exclude="+branch)
+	}
+	args = append(args, "--branches", "--tags", "--remotes")
+	out, err := repo.Git(ctx, args...)
+	if err != nil {
+		return nil
+	}
+	return repo.Lines(out)
+}
+
+// cleanVictims asks git clean for a dry run with the same options and sizes
+// every path it would remove.
+func cleanVictims(ctx context.Context, args []string) []LostFile {
+	dry := []string{}
+	for _, s := range args {
+		switch {
+		case s == "--force" || s == "-i" || s == "--interactive":
+			continue
+		case strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "--") && strings.ContainsAny(s, "fi"):
+			s = strings.NewReplacer("f", "", "i", "").Replace(s)
+			if s == "-" {
+				continue
+			}
+		}
+		dry = append(dry, s)
+	}
+	for i, s := range dry {
+		if s == "clean" {
+			dry = append(dry[:i+1], append([]string{"-n"}, dry[i+1:]...)...)
+			break
+		}
+	}
+	out, err := repo.Git(ctx, dry...)
+	if err != nil {
+		return nil
+	}
+	top, _ := repo.Toplevel(ctx)
+	var files []LostFile
+	for _, l := range repo.Lines(out) {
+		p := strings.TrimPrefix(l, "Would remove ")
+		files = append(files, LostFile{Path: p, Size: pathSize(p, top)})
+	}
+	return files
+}
+
+func overwrittenOnRemote(ctx context.Context, a argv) []string {
+	remote := "origin"
+	if len(a.pos) > 0 {
+		remote = a.pos[0]
+	}
+	cur := repo.CurrentBranch(ctx)
+	local, branch := cur, cur
+	if len(a.pos) > 1 {
+		spec := strings.TrimPrefix(a.pos[1], "+")
+		local, branch = spec, spec
+		if i := strings.Index(spec, ":"); i >= 0 {
+			local, branch = spec[:i], spec[i+1:]
+		}
+		branch = strings.TrimPrefix(branch, "refs/heads/")
+	}
+	if local == "" || branch == "" {
+		return nil
+	}
+	tracking := "refs/remotes/" + remote + "/" + branch
+	if _, err := repo.RevParse(ctx, tracking); err != nil {
+		return nil
+	}
+	out, err := repo.Git(ctx, "log", "--oneline", local+".."+tracking)
+	if err != nil {
+		return nil
+	}
+	return repo.Lines(out)
+}
+
+func pathSize(p, top string) int64 {
+	full := p
+	if !filepath.IsAbs(full) {
+		if _, err := os.Stat(full); err != nil && top != "" {
+			full = filepath.Join(top, p)
+		}
+	}
+	var total int64
+	_ = filepath.WalkDir(full, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if fi, err := d.Info(); err == nil {
+				total += fi.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+func capLines(lines []string) []string {
+	if len(lines) <= impactLimit {
+		return lines
+	}
+	out := append([]string{}, lines[:impactLimit]...)
+	return append(out, fmt.Sprintf("... and %d more", len(lines)-impactLimit))
+}
+
+func indent(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = "  " + l
+	}
+	return out
+}
+
+func humanSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
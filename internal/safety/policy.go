@@ -0,0 +1,235 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"ezgit/internal/repo"
+)
+
+// PolicyFile is where a repository keeps its shared policy, relative to the
+// top of the working tree.
+const PolicyFile = ".ezgit/policy.json"
+
+type Severity string
+
+const (
+	SeverityDeny    Severity = "deny"
+	SeverityConfirm Severity = "confirm"
+	SeverityWarn    Severity = "warn"
+)
+
+// Rule matches a git argv. Every field that is set must match:
+//
+//	command     git subcommand, e.g. "push"
+//	flags       at least one of these flags is present
+//	branches    the target branch matches one of these globs
+//	protected   the target branch is one of the policy's protected branches
+//	max_commits a push sends more than this many commits
+type Rule struct {
+	Name       string   `json:"name"`
+	Command    string   `json:"command"`
+	Flags      []string `json:"flags,omitempty"`
+	Branches   []string `json:"branches,omitempty"`
+	Protected  bool     `json:"protected,omitempty"`
+	MaxCommits int      `json:"max_commits,omitempty"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+}
+
+type Policy struct {
+	ProtectedBranches []string `json:"protected_branches"`
+	Rules             []Rule   `json:"rules"`
+}
+
+// Finding is a rule that matched, with the message to show.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+// RepoContext is what rules need to know about the repository beyond the
+// argv itself.
+type RepoContext struct {
+	CurrentBranch string
+	// PushCount returns how many commits pushing local to remote/branch
+	// would send.
+	PushCount func(remote, local, branch string) int
+}
+
+func LoadPolicy(p string) (*Policy, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var pol Policy
+	if err := json.Unmarshal(b, &pol); err != nil {
+		return nil, err
+	}
+	for i, r := range pol.Rules {
+		switch r.Severity {
+		case SeverityDeny, SeverityConfirm, SeverityWarn:
+		default:
+			return nil, fmt.Errorf("rule %d (%s): unknown severity %q", i, r.Name, r.Severity)
+		}
+	}
+	return &pol, nil
+}
+
+// LoadRepoPolicy loads PolicyFile from the repository at top. A missing
+// file is not an error and yields a nil policy.
+func LoadRepoPolicy(top string) (*Policy, error) {
+	pol, err := LoadPolicy(filepath.Join(top, PolicyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return pol, err
+}
+
+// GitRepoContext fills a RepoContext from the repository in the current
+// directory.
+func GitRepoContext(ctx context.Context) RepoContext {
+	return RepoContext{
+		CurrentBranch: repo.CurrentBranch(ctx),
+		PushCount: func(remote, local, branch string) int {
+			if _, err := repo.RevParse(ctx, "refs/remotes/"+remote+"/"+branch); err == nil {
+				return repo.Count(ctx, "refs/remotes/"+remote+"/"+branch+".."+local)
+			}
+			return repo.Count(ctx, local, "--not", "--remotes="+remote)
+		},
+	}
+}
+
+// Evaluate returns the findings of every rule matching the git argv args.
+// Global options before the subcommand, such as "-c k=v" or "-C dir", are
+// skipped.
+func (p *Policy) Evaluate(args []string, rc RepoContext) []Finding {
+	a := parseArgv(args)
+	if p == nil || a.verb == "" {
+		return nil
+	}
+	var out []Finding
+	for _, r := range p.Rules {
+		if r.matches(p, a, rc) {
+			msg := r.Message
+			if msg == "" {
+				msg = fmt.Sprintf("policy rule %q matched", r.Name)
+			}
+			out = append(out, Finding{Rule: r.Name, Severity: r.Severity, Message: msg})
+		}
+	}
+	return out
+}
+
+// IsProtected reports whether branch matches one of the protected globs.
+func (p *Policy) IsProtected(branch string) bool {
+	if p == nil {
+		return false
+	}
+	return matchAny(p.ProtectedBranches, branch)
+}
+
+func (r Rule) matches(p *Policy, a argv, rc RepoContext) bool {
+	if r.Command != "" && a.verb != r.Command {
+		return false
+	}
+	if len(r.Flags) > 0 {
+		found := false
+		for _, f := range r.Flags {
+			if a.has(f) || (forceFlags[f] && forcedRefspec(a)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	target := targetBranch(a, rc)
+	if len(r.Branches) > 0 && !matchAny(r.Branches, target) {
+		return false
+	}
+	if r.Protected && !p.IsProtected(target) {
+		return false
+	}
+	if r.MaxCommits > 0 {
+		if a.verb != "push" || rc.PushCount == nil {
+			return false
+		}
+		remote, local, branch := pushTarget(a, rc)
+		if rc.PushCount(remote, local, branch) <= r.MaxCommits {
+			return false
+		}
+	}
+	return true
+}
+
+// forceFlags are the push flags a "+refspec" is equivalent to.
+var forceFlags = map[string]bool{"--force": true, "-f": true, "--force-with-lease": true, "--force-if-includes": true}
+
+// forcedRefspec reports whether a push forces through a "+src:dst" refspec.
+func forcedRefspec(a argv) bool {
+	if a.verb != "push" {
+		return false
+	}
+	for i := 1; i < len(a.pos); i++ {
+		if strings.HasPrefix(a.pos[i], "+") {
+			return true
+		}
+	}
+	return false
+}
+
+// pushTarget resolves the remote, local ref and remote branch of a push.
+// Only the first refspec is considered; HEAD and an empty source stand for
+// the current branch.
+func pushTarget(a argv, rc RepoContext) (remote, local, branch string) {
+	pos := a.pos
+	remote = "origin"
+	if len(pos) > 0 {
+		remote = pos[0]
+	}
+	local, branch = rc.CurrentBranch, rc.CurrentBranch
+	if len(pos) > 1 {
+		spec := strings.TrimPrefix(pos[1], "+")
+		src, dst := spec, spec
+		if i := strings.Index(spec, ":"); i >= 0 {
+			src, dst = spec[:i], spec[i+1:]
+		}
+		if src != "" && src != "HEAD" {
+			local = src
+		}
+		if dst != "HEAD" {
+			branch = strings.TrimPrefix(dst, "refs/heads/")
+		}
+	}
+	return remote, local, branch
+}
+
+// targetBranch is the branch a command affects: the destination of a push,
+// otherwise the checked-out branch.
+func targetBranch(a argv, rc RepoContext) string {
+	if a.verb == "push" {
+		_, _, b := pushTarget(a, rc)
+		return b
+	}
+	return rc.CurrentBranch
+}
+
+func matchAny(patterns []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
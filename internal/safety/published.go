@@ -0,0 +1,64 @@
+package safety
+
+import (
+	"context"
+
+	"ezgit/internal/repo"
+)
+
+// RewritesPublished lists the commits that the git argv args would rewrite
+// although they are already on the current branch's upstream: the amended
+// HEAD, or the commits a rebase replays. Rewriting them means a force push
+// for everyone else on the branch.
+func RewritesPublished(ctx context.Context, args []string) []string {
+	a := parseArgv(args)
+	base := ""
+	switch a.verb {
+	case "commit":
+		if !a.has("--amend") {
+			return nil
+		}
+		base = "HEAD^"
+	case "rebase":
+		if a.has("--continue", "--abort", "--skip", "--quit", "--edit-todo", "--show-current-patch") {
+			return nil
+		}
+		switch {
+		case a.has("--root"):
+			base = ""
+		case len(a.pos) > 0:
+			base = a.pos[0]
+		default:
+			// rebasing onto the upstream itself replays only unpushed work
+			return nil
+		}
+	default:
+		return nil
+	}
+	up := repo.Upstream(ctx, "HEAD")
+	if up == "" {
+		return nil
+	}
+	mb, err := repo.Git(ctx, "merge-base", "HEAD", up)
+	if err != nil || mb == "" {
+		return nil
+	}
+	rng := mb
+	if base != "" {
+		if _, err := repo.RevParse(ctx, base); err != nil {
+			if a.verb == "commit" {
+				// amending a root commit
+				rng = mb
+			} else {
+				return nil
+			}
+		} else {
+			rng = base + ".." + mb
+		}
+	}
+	out, err := repo.Git(ctx, "log", "--oneline", rng)
+	if err != nil {
+		return nil
+	}
+	return repo.Lines(out)
+}
@@ -0,0 +1,119 @@
+// Package scaffold renders the files a new repository starts with: a
+// README, a .gitignore built from the embedded templates and a LICENSE.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"ezgit/internal/ignore"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Options describes the scaffold. Ignore holds .gitignore template names
+// and License a name from Licenses, or "" for none.
+type Options struct {
+	Name        string
+	Description string
+	Readme      bool
+	Ignore      []string
+	License     string
+	Holder      string
+	Year        int
+}
+
+// File is one file to create. Path is where it goes, relative to the
+// repository directory until Write joins it with one.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Licenses lists the embedded license templates.
+func Licenses() []string {
+	entries, _ := templateFS.ReadDir("templates/licenses")
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Files renders every file o asks for.
+func Files(o Options) ([]File, error) {
+	var files []File
+	if o.Readme {
+		s, err := render("templates/README.md.tmpl", o)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: "README.md", Content: s})
+	}
+	if len(o.Ignore) > 0 {
+		var parts []string
+		for _, name := range o.Ignore {
+			t, err := ignore.Template(name)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, "# "+name+"\n"+t)
+		}
+		files = append(files, File{Path: ".gitignore", Content: strings.Join(parts, "\n")})
+	}
+	if o.License != "" {
+		s, err := render("templates/licenses/"+o.License, o)
+		if err != nil {
+			return nil, fmt.Errorf("no license template %q", o.License)
+		}
+		files = append(files, File{Path: "LICENSE", Content: s})
+	}
+	return files, nil
+}
+
+func render(name string, o Options) (string, error) {
+	t, err := template.ParseFS(templateFS, name)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, o); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Write creates files under dir. A file that already exists is never
+// overwritten; it is returned in kept instead.
+func Write(dir string, files []File) (written, kept []string, err error) {
+	for _, f := range files {
+		p := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return written, kept, err
+		}
+		fh, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			kept = append(kept, f.Path)
+			continue
+		}
+		if err != nil {
+			return written, kept, err
+		}
+		_, err = fh.WriteString(f.Content)
+		if cerr := fh.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return written, kept, err
+		}
+		written = append(written, f.Path)
+	}
+	return written, kept, nil
+}
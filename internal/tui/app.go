@@ -3,31 +3,111 @@ package tui
 import (
 	"bufio"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"ezgit/internal/action"
 )
 
+// BasicPrompter asks for every prompt of a on plain stdin/stdout, for
+// terminals where the full TUI is not available. Invalid answers are asked
+// again.
 func BasicPrompter(a *action.ActionDef, r *bufio.Reader) (action.ActionInput, error) {
 	inputs := action.ActionInput{}
 	for _, p := range a.Prompts {
-		def := p.Default
+		if !p.Asked(inputs) {
+			continue
+		}
 		label := p.Label
 		if label == "" {
 			label = p.Key
 		}
-		fmt.Printf("%s [%s]: ", label, def)
+		for {
+			line, err := askLine(p, label, r)
+			if err != nil {
+				return nil, err
+			}
+			if line == "" {
+				line = p.Default
+			}
+			v, err := p.Normalize(line)
+			if err == nil && p.Required && strings.TrimSpace(v) == "" {
+				err = fmt.Errorf("required")
+			}
+			if err != nil {
+				fmt.Printf("  %v\n", err)
+				continue
+			}
+			inputs[p.Key] = v
+			break
+		}
+	}
+	return inputs, nil
+}
+
+// askLine prints the prompt in a form that suits its type and reads the raw
+// answer. Multiline prompts read until a line with a single ".".
+func askLine(p action.Prompt, label string, r *bufio.Reader) (string, error) {
+	switch p.Type {
+	case action.PromptBool:
+		hint := "y/N"
+		if b, _ := action.ParseBool(p.Default); b {
+			hint = "Y/n"
+		}
+		fmt.Printf("%s [%s]: ", label, hint)
+	case action.PromptEnum:
+		fmt.Printf("%s (%s) [%s]: ", label, strings.Join(p.Options, "/"), p.Default)
+	case action.PromptMultiSelect:
+		fmt.Printf("%s\n", label)
+		for i, o := range p.Options {
+			fmt.Printf("  %d) %s\n", i+1, o)
+		}
+		fmt.Printf("numbers or names, comma separated [%s]: ", strings.ReplaceAll(p.Default, "\n", ","))
 		line, err := r.ReadString('\n')
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		line = strings.TrimSpace(line)
-		if line == "" {
-			line = def
+		return pickByNumber(p.Options, line), nil
+	case action.PromptMultiline:
+		fmt.Printf("%s (end with a line containing only \".\")\n", label)
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "." {
+				break
+			}
+			lines = append(lines, line)
 		}
-		inputs[p.Key] = line
+		return strings.TrimSpace(strings.Join(lines, "\n")), nil
+	default:
+		fmt.Printf("%s [%s]: ", label, p.Default)
 	}
-	return inputs, nil
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// pickByNumber maps "1,3" style answers onto options; names are kept as
+// typed for Normalize to check.
+func pickByNumber(options []string, line string) string {
+	var out []string
+	for _, f := range strings.Split(line, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(f); err == nil && n >= 1 && n <= len(options) {
+			f = options[n-1]
+		}
+		out = append(out, f)
+	}
+	return strings.Join(out, "\n")
 }
 
 func TypedConfirmation(r *bufio.Reader, cmd string, args []string) bool {
@@ -1,3 +1,748 @@
-// placeholder for future TUI widgets
-// select lists, diff view, streaming panes
+// reusable widgets for the TUI: select lists, pickers, streaming panes
 package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"ezgit/internal/action"
+	"ezgit/internal/hunks"
+	"ezgit/internal/repo"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type RefKind string
+
+const (
+	RefBranch RefKind = "branch"
+	RefRemote RefKind = "remote"
+	RefTag    RefKind = "tag"
+	RefCommit RefKind = "commit"
+	RefStash  RefKind = "stash"
+)
+
+// RefItem is one entry of a RefPicker.
+type RefItem struct {
+	Name    string
+	Kind    RefKind
+	Subject string
+	Date    string
+}
+
+// RefKindsFor maps a prompt type to the refs its picker lists.
+func RefKindsFor(t action.PromptType) []RefKind {
+	switch t {
+	case action.PromptBranch:
+		return []RefKind{RefBranch, RefRemote}
+	case action.PromptCommit:
+		return []RefKind{RefCommit, RefBranch, RefTag}
+	case action.PromptStash:
+		return []RefKind{RefStash}
+	case action.PromptRef:
+		return []RefKind{RefBranch, RefRemote, RefTag, RefCommit, RefStash}
+	}
+	return nil
+}
+
+// recentCommits is how many commits LoadRefs lists for RefCommit.
+const recentCommits = 50
+
+// LoadRefs lists the refs of the given kinds in the current repository,
+// most recently touched first within each kind.
+func LoadRefs(ctx context.Context, kinds ...RefKind) []RefItem {
+	var items []RefItem
+	const format = "--format=%(refname:short)%09%(committerdate:relative)%09%(contents:subject)"
+	for _, k := range kinds {
+		var out string
+		var err error
+		switch k {
+		case RefBranch:
+			out, err = repo.Git(ctx, "for-each-ref", "--sort=-committerdate", format, "refs/heads")
+		case RefRemote:
+			out, err = repo.Git(ctx, "for-each-ref", "--sort=-committerdate", format, "refs/remotes")
+		case RefTag:
+			out, err = repo.Git(ctx, "for-each-ref", "--sort=-creatordate", format, "refs/tags")
+		case RefCommit:
+			out, err = repo.Git(ctx, "log", "-n", fmt.Sprint(recentCommits), "--format=%h%x09%cr%x09%s")
+		case RefStash:
+			out, err = repo.Git(ctx, "stash", "list", "--format=%gd%x09%cr%x09%s")
+		}
+		if err != nil {
+			continue
+		}
+		for _, l := range repo.Lines(out) {
+			parts := strings.SplitN(l, "\t", 3)
+			for len(parts) < 3 {
+				parts = append(parts, "")
+			}
+			if k == RefRemote && strings.HasSuffix(parts[0], "/HEAD") {
+				continue
+			}
+			items = append(items, RefItem{Name: parts[0], Kind: k, Date: parts[1], Subject: parts[2]})
+		}
+	}
+	return items
+}
+
+// RefPicker is a fuzzy-filtered list of refs. The caller owns the text
+// input and feeds its value in through SetFilter.
+type RefPicker struct {
+	Items  []RefItem
+	Height int
+
+	filter  string
+	matches []int
+	cursor  int
+	moved   bool
+}
+
+func NewRefPicker(items []RefItem) *RefPicker {
+	p := &RefPicker{Items: items, Height: 8}
+	p.SetFilter("")
+	return p
+}
+
+func (p *RefPicker) SetItems(items []RefItem) {
+	p.Items = items
+	p.SetFilter(p.filter)
+}
+
+// SetFilter re-ranks the items against filter.
+func (p *RefPicker) SetFilter(filter string) {
+	p.filter = strings.TrimSpace(filter)
+	type scored struct{ idx, score int }
+	var res []scored
+	for i, it := range p.Items {
+		if s, ok := FuzzyScore(p.filter, it.Name+" "+it.Subject); ok {
+			res = append(res, scored{i, s})
+		}
+	}
+	sort.SliceStable(res, func(a, b int) bool { return res[a].score > res[b].score })
+	p.matches = p.matches[:0]
+	for _, r := range res {
+		p.matches = append(p.matches, r.idx)
+	}
+	p.cursor = 0
+	p.moved = false
+}
+
+// Update moves the cursor; it reports whether it consumed the key.
+func (p *RefPicker) Update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "ctrl+p":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		p.moved = true
+		return true
+	case "down", "ctrl+n":
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+		p.moved = true
+		return true
+	}
+	return false
+}
+
+// Selected returns the highlighted item.
+func (p *RefPicker) Selected() (RefItem, bool) {
+	if len(p.matches) == 0 {
+		return RefItem{}, false
+	}
+	return p.Items[p.matches[p.cursor]], true
+}
+
+// Choice decides what a prompt answered with typed should get: the
+// highlighted ref when the user navigated to it, the one name typed
+// uniquely prefixes, otherwise the typed text unchanged. Fuzzy matches are
+// only a suggestion; taking them silently could act on the wrong commit.
+func (p *RefPicker) Choice(typed string) string {
+	typed = strings.TrimSpace(typed)
+	if sel, ok := p.Selected(); ok && p.moved {
+		return sel.Name
+	}
+	if typed == "" || strings.HasPrefix(typed, "-") || strings.ContainsAny(typed, " \t") {
+		return typed
+	}
+	prefixed := ""
+	for _, it := range p.Items {
+		if it.Name == typed {
+			return typed
+		}
+		if strings.HasPrefix(it.Name, typed) {
+			if prefixed != "" && prefixed != it.Name {
+				return typed
+			}
+			prefixed = it.Name
+		}
+	}
+	if prefixed != "" {
+		return prefixed
+	}
+	return typed
+}
+
+func (p *RefPicker) View() string {
+	if len(p.Items) == 0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("(loading refs...)")
+	}
+	if len(p.matches) == 0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("(no matching refs — the typed text is used as-is)")
+	}
+	h := p.Height
+	if h <= 0 {
+		h = 8
+	}
+	start := 0
+	if p.cursor >= h {
+		start = p.cursor - h + 1
+	}
+	end := start + h
+	if end > len(p.matches) {
+		end = len(p.matches)
+	}
+	kindStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	active := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	lines := []string{}
+	for i := start; i < end; i++ {
+		it := p.Items[p.matches[i]]
+		subject := it.Subject
+		if r := []rune(subject); len(r) > 40 {
+			subject = string(r[:39]) + "…"
+		}
+		line := fmt.Sprintf("%-24s %s %s", it.Name, kindStyle.Render(fmt.Sprintf("%-6s %-14s", it.Kind, it.Date)), subject)
+		if i == p.cursor {
+			lines = append(lines, active.Render("> ")+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	if len(p.matches) > end {
+		lines = append(lines, kindStyle.Render(fmt.Sprintf("  … %d more", len(p.matches)-end)))
+	}
+	if !p.moved && p.filter != "" {
+		if c := p.Choice(p.filter); c != p.Items[p.matches[0]].Name {
+			lines = append(lines, kindStyle.Render(fmt.Sprintf("  enter uses %q as typed; ↑/↓ to pick a match", c)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FuzzyScore matches pattern as a case-insensitive subsequence of s.
+// Consecutive runs and matches at word starts score higher.
+func FuzzyScore(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	pr := []rune(strings.ToLower(pattern))
+	sr := []rune(s)
+	score, pi, run := 0, 0, 0
+	for i, r := range sr {
+		if pi >= len(pr) {
+			break
+		}
+		if unicode.ToLower(r) != pr[pi] {
+			run = 0
+			continue
+		}
+		run++
+		score += 1 + run*2
+		if i == 0 || strings.ContainsRune("/-_. ", sr[i-1]) {
+			score += 5
+		}
+		pi++
+	}
+	if pi < len(pr) {
+		return 0, false
+	}
+	return score - len(sr)/10, true
+}
+
+// OptionList is a vertical list of choices for bool, enum and multi-select
+// prompts. In Multi mode space toggles the highlighted option.
+type OptionList struct {
+	Options []string
+	Multi   bool
+	Cursor  int
+	Checked map[int]bool
+}
+
+func NewOptionList(options []string, multi bool, value string) *OptionList {
+	l := &OptionList{Options: options, Multi: multi, Checked: map[int]bool{}}
+	for _, v := range strings.Split(value, "\n") {
+		for i, o := range options {
+			if strings.EqualFold(o, strings.TrimSpace(v)) {
+				if multi {
+					l.Checked[i] = true
+				} else {
+					l.Cursor = i
+				}
+			}
+		}
+	}
+	return l
+}
+
+// Update handles navigation and toggling; it reports whether it consumed
+// the key.
+func (l *OptionList) Update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k", "left", "h":
+		if l.Cursor > 0 {
+			l.Cursor--
+		}
+		return true
+	case "down", "j", "right", "l":
+		if l.Cursor < len(l.Options)-1 {
+			l.Cursor++
+		}
+		return true
+	case " ", "x":
+		if l.Multi {
+			l.Checked[l.Cursor] = !l.Checked[l.Cursor]
+			return true
+		}
+	case "a":
+		if l.Multi {
+			all := len(l.Selected()) < len(l.Options)
+			for i := range l.Options {
+				l.Checked[i] = all
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Selected returns the chosen options in list order.
+func (l *OptionList) Selected() []string {
+	if !l.Multi {
+		if len(l.Options) == 0 {
+			return nil
+		}
+		return []string{l.Options[l.Cursor]}
+	}
+	var out []string
+	for i, o := range l.Options {
+		if l.Checked[i] {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Value is the answer in the form action.Prompt.Normalize expects.
+func (l *OptionList) Value() string {
+	return strings.Join(l.Selected(), "\n")
+}
+
+func (l *OptionList) View() string {
+	active := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	lines := make([]string, 0, len(l.Options))
+	for i, o := range l.Options {
+		mark := "  "
+		if l.Multi {
+			mark = "[ ] "
+			if l.Checked[i] {
+				mark = "[x] "
+			}
+		}
+		if i == l.Cursor {
+			lines = append(lines, active.Render("> "+mark+o))
+		} else {
+			lines = append(lines, "  "+mark+o)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CompletePath completes a partially typed file system path: the longest
+// common extension of every entry that starts with it, directories getting
+// a trailing slash when they are the only match.
+func CompletePath(partial string) string {
+	dir, base := filepath.Split(partial)
+	lookIn := dir
+	if lookIn == "" {
+		lookIn = "."
+	}
+	entries, err := os.ReadDir(lookIn)
+	if err != nil {
+		return partial
+	}
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if e.IsDir() {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, name)
+	}
+	if len(matches) == 0 {
+		return partial
+	}
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	return dir + common
+}
+
+// FileList is a checkbox list of files with their two-letter status, fuzzy
+// filtered like RefPicker. Checked paths survive a reload of the items.
+type FileList struct {
+	Items  []repo.FileStatus
+	Height int
+
+	checked map[string]bool
+	filter  string
+	matches []int
+	cursor  int
+}
+
+func NewFileList(items []repo.FileStatus) *FileList {
+	l := &FileList{Height: 12, checked: map[string]bool{}}
+	l.SetItems(items)
+	return l
+}
+
+func (l *FileList) SetItems(items []repo.FileStatus) {
+	l.Items = items
+	keep := map[string]bool{}
+	for _, it := range items {
+		if l.checked[it.Path] {
+			keep[it.Path] = true
+		}
+	}
+	l.checked = keep
+	l.SetFilter(l.filter)
+}
+
+func (l *FileList) SetFilter(filter string) {
+	l.filter = strings.TrimSpace(filter)
+	type scored struct{ idx, score int }
+	var res []scored
+	for i, it := range l.Items {
+		if s, ok := FuzzyScore(l.filter, it.Path); ok {
+			res = append(res, scored{i, s})
+		}
+	}
+	if l.filter != "" {
+		sort.SliceStable(res, func(a, b int) bool { return res[a].score > res[b].score })
+	}
+	l.matches = l.matches[:0]
+	for _, r := range res {
+		l.matches = append(l.matches, r.idx)
+	}
+	if l.cursor >= len(l.matches) {
+		l.cursor = intMax(0, len(l.matches)-1)
+	}
+}
+
+// Update handles navigation and toggling; it reports whether it consumed
+// the key. "a" toggles every file that matches the filter.
+func (l *FileList) Update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case "down", "j":
+		if l.cursor < len(l.matches)-1 {
+			l.cursor++
+		}
+	case " ", "x":
+		if len(l.matches) > 0 {
+			p := l.Items[l.matches[l.cursor]].Path
+			l.checked[p] = !l.checked[p]
+			if l.cursor < len(l.matches)-1 {
+				l.cursor++
+			}
+		}
+	case "a":
+		all := false
+		for _, i := range l.matches {
+			if !l.checked[l.Items[i].Path] {
+				all = true
+			}
+		}
+		for _, i := range l.matches {
+			l.checked[l.Items[i].Path] = all
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// Selected returns the checked files in list order; nothing checked means
+// nothing selected.
+func (l *FileList) Selected() []repo.FileStatus {
+	var out []repo.FileStatus
+	for _, it := range l.Items {
+		if l.checked[it.Path] {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Targets is what a one-key operation acts on: the checked files, or the
+// highlighted one when nothing is checked.
+func (l *FileList) Targets() []repo.FileStatus {
+	out := l.Selected()
+	if len(out) == 0 && len(l.matches) > 0 {
+		out = append(out, l.Items[l.matches[l.cursor]])
+	}
+	return out
+}
+
+func (l *FileList) View() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	if len(l.Items) == 0 {
+		return dim.Render("(no files)")
+	}
+	if len(l.matches) == 0 {
+		return dim.Render("(no file matches the filter)")
+	}
+	staged := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	unstaged := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	active := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	h := l.Height
+	if h <= 0 {
+		h = 12
+	}
+	start := 0
+	if l.cursor >= h {
+		start = l.cursor - h + 1
+	}
+	end := start + h
+	if end > len(l.matches) {
+		end = len(l.matches)
+	}
+	lines := []string{}
+	for i := start; i < end; i++ {
+		it := l.Items[l.matches[i]]
+		box := "[ ]"
+		if l.checked[it.Path] {
+			box = "[x]"
+		}
+		code := staged.Render(string(it.X)) + unstaged.Render(string(it.Y))
+		name := it.Path
+		if it.Orig != "" {
+			name = it.Orig + " -> " + name
+		}
+		if i == l.cursor {
+			lines = append(lines, active.Render("> "+box)+" "+code+" "+active.Render(name))
+		} else {
+			lines = append(lines, "  "+box+" "+code+" "+name)
+		}
+	}
+	n := 0
+	for _, c := range l.checked {
+		if c {
+			n++
+		}
+	}
+	footer := fmt.Sprintf("%d selected", n)
+	if rest := len(l.matches) - end; rest > 0 {
+		footer = fmt.Sprintf("… %d more • %s", rest, footer)
+	}
+	lines = append(lines, dim.Render("  "+footer))
+	return strings.Join(lines, "\n")
+}
+
+// HunkList is a checkbox list of the hunks of a diff, with the highlighted
+// hunk shown underneath. Files that can't be split are a single entry.
+type HunkList struct {
+	Files  []hunks.File
+	Height int
+
+	entries []hunkEntry
+	checked map[int]bool
+	cursor  int
+}
+
+// hunkEntry is one row: a hunk of a file, or the whole file when hunk < 0.
+type hunkEntry struct{ file, hunk int }
+
+func NewHunkList(files []hunks.File) *HunkList {
+	l := &HunkList{Files: files, Height: 10, checked: map[int]bool{}}
+	for fi, f := range files {
+		if f.Whole() {
+			l.entries = append(l.entries, hunkEntry{fi, -1})
+			continue
+		}
+		for hi := range f.Hunks {
+			l.entries = append(l.entries, hunkEntry{fi, hi})
+		}
+	}
+	return l
+}
+
+func (l *HunkList) Len() int { return len(l.entries) }
+
+// Update handles navigation and toggling; it reports whether it consumed
+// the key. "f" toggles every hunk of the highlighted file, "a" all of them.
+func (l *HunkList) Update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case "down", "j":
+		if l.cursor < len(l.entries)-1 {
+			l.cursor++
+		}
+	case " ", "x":
+		if len(l.entries) > 0 {
+			l.checked[l.cursor] = !l.checked[l.cursor]
+			if l.cursor < len(l.entries)-1 {
+				l.cursor++
+			}
+		}
+	case "f", "a":
+		if len(l.entries) == 0 {
+			return true
+		}
+		file := l.entries[l.cursor].file
+		in := func(i int) bool { return msg.String() == "a" || l.entries[i].file == file }
+		all := false
+		for i := range l.entries {
+			if in(i) && !l.checked[i] {
+				all = true
+			}
+		}
+		for i := range l.entries {
+			if in(i) {
+				l.checked[i] = all
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// Selected reports how many entries are checked.
+func (l *HunkList) Selected() int {
+	n := 0
+	for _, c := range l.checked {
+		if c {
+			n++
+		}
+	}
+	return n
+}
+
+// Patch is the checked hunks as a patch for "git apply --cached".
+func (l *HunkList) Patch() string {
+	keep := map[hunkEntry]bool{}
+	for i, e := range l.entries {
+		if l.checked[i] && e.hunk >= 0 {
+			keep[e] = true
+		}
+	}
+	return hunks.Patch(l.Files, func(f, h int) bool { return keep[hunkEntry{f, h}] })
+}
+
+// WholeFiles lists the checked files that are staged as a unit.
+func (l *HunkList) WholeFiles() []string {
+	var out []string
+	for i, e := range l.entries {
+		if l.checked[i] && e.hunk < 0 {
+			out = append(out, l.Files[e.file].Path)
+		}
+	}
+	return out
+}
+
+func (l *HunkList) View() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	if len(l.entries) == 0 {
+		return dim.Render("(no changes left)")
+	}
+	active := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+	add := lipgloss.NewStyle().Foreground(lipgloss.Color("78"))
+	del := lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	h := l.Height
+	if h <= 0 {
+		h = 10
+	}
+	start := 0
+	if l.cursor >= h {
+		start = l.cursor - h + 1
+	}
+	end := start + h
+	if end > len(l.entries) {
+		end = len(l.entries)
+	}
+	lines := []string{}
+	for i := start; i < end; i++ {
+		e := l.entries[i]
+		f := l.Files[e.file]
+		box := "[ ]"
+		if l.checked[i] {
+			box = "[x]"
+		}
+		what := ""
+		switch {
+		case f.Untracked:
+			what = "new file"
+		case f.Binary:
+			what = "binary"
+		case e.hunk < 0:
+			what = "whole file"
+		default:
+			what = fmt.Sprintf("hunk %d/%d %s", e.hunk+1, len(f.Hunks), f.Hunks[e.hunk].Header)
+		}
+		line := f.Path + " " + dim.Render(what)
+		if i == l.cursor {
+			lines = append(lines, active.Render("> "+box)+" "+line)
+		} else {
+			lines = append(lines, "  "+box+" "+line)
+		}
+	}
+	footer := fmt.Sprintf("%d of %d selected", l.Selected(), len(l.entries))
+	if rest := len(l.entries) - end; rest > 0 {
+		footer = fmt.Sprintf("… %d more • %s", rest, footer)
+	}
+	lines = append(lines, dim.Render("  "+footer), "")
+	if e := l.entries[l.cursor]; e.hunk >= 0 {
+		body := l.Files[e.file].Hunks[e.hunk].Lines
+		const maxLines = 15
+		for i, b := range body {
+			if i == maxLines {
+				lines = append(lines, dim.Render(fmt.Sprintf("… %d more lines", len(body)-maxLines)))
+				break
+			}
+			switch {
+			case strings.HasPrefix(b, "+"):
+				b = add.Render(b)
+			case strings.HasPrefix(b, "-"):
+				b = del.Render(b)
+			}
+			lines = append(lines, b)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func intMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
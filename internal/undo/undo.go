@@ -0,0 +1,89 @@
+// Package undo keeps a journal of the history rewrites EzGit performs
+// (reword, split) so the most recent one can be taken back.
+package undo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ezgit/internal/repo"
+)
+
+// Entry records where a branch pointed before and after a rewrite.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Branch string    `json:"branch"`
+	Before string    `json:"before"`
+	After  string    `json:"after"`
+	Backup string    `json:"backup,omitempty"`
+}
+
+func path(ctx context.Context) (string, error) {
+	return repo.Git(ctx, "rev-parse", "--git-path", "ezgit/undo.jsonl")
+}
+
+// Record appends e to the repository's journal.
+func Record(ctx context.Context, e Entry) error {
+	p, err := path(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return json.NewEncoder(f).Encode(e)
+}
+
+// Entries reads the journal, oldest first.
+func Entries(ctx context.Context) ([]Entry, error) {
+	p, err := path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
+
+// Undoable is the newest entry that HEAD still points at the result of;
+// once the branch has moved on, undoing would throw that work away.
+func Undoable(ctx context.Context) (Entry, bool) {
+	head, err := repo.RevParse(ctx, "HEAD")
+	if err != nil {
+		return Entry{}, false
+	}
+	es, _ := Entries(ctx)
+	for i := len(es) - 1; i >= 0; i-- {
+		if es[i].After == head {
+			return es[i], true
+		}
+	}
+	return Entry{}, false
+}
@@ -0,0 +1,77 @@
+package watch
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+type stamp struct {
+	exists bool
+	mod    time.Time
+	size   int64
+}
+
+// Watcher polls a fixed set of files and reports which ones changed since
+// the previous poll. Creating or deleting a file counts as a change.
+type Watcher struct {
+	mu     sync.Mutex
+	stamps map[string]stamp
+	order  []string
+}
+
+func New(paths ...string) *Watcher {
+	w := &Watcher{stamps: map[string]stamp{}}
+	for _, p := range paths {
+		w.Add(p)
+	}
+	return w
+}
+
+func (w *Watcher) Add(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.stamps[path]; ok {
+		return
+	}
+	w.stamps[path] = statFile(path)
+	w.order = append(w.order, path)
+}
+
+// Remove stops watching path.
+func (w *Watcher) Remove(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.stamps[path]; !ok {
+		return
+	}
+	delete(w.stamps, path)
+	for i, p := range w.order {
+		if p == path {
+			w.order = append(w.order[:i], w.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *Watcher) Changed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var changed []string
+	for _, p := range w.order {
+		cur := statFile(p)
+		if cur != w.stamps[p] {
+			w.stamps[p] = cur
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+func statFile(path string) stamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{exists: true, mod: fi.ModTime(), size: fi.Size()}
+}
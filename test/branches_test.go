@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"ezgit/internal/repo"
+)
+
+func TestBranchesState(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+	git(t, "init", "-q", "-b", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "root")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "main")
+	git(t, "branch", "done")
+	git(t, "switch", "-q", "-c", "wip")
+	git(t, "commit", "-q", "--allow-empty", "-m", "wip 1")
+	git(t, "commit", "-q", "--allow-empty", "-m", "wip 2")
+	git(t, "push", "-q", "-u", "origin", "wip")
+	git(t, "push", "-q", "origin", "--delete", "wip")
+	git(t, "fetch", "-q", "--prune")
+
+	base := repo.DefaultBranch(ctx)
+	if base != "main" {
+		t.Fatalf("default branch = %q", base)
+	}
+	bs, err := repo.Branches(ctx, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]repo.Branch{}
+	for _, b := range bs {
+		got[b.Name] = b
+	}
+	if b := got["wip"]; !b.Current || !b.Gone || b.Merged || b.Subject != "wip 2" {
+		t.Errorf("wip = %+v", b)
+	}
+	if b := got["done"]; !b.Merged || b.Upstream != "" || !b.Stale() {
+		t.Errorf("done = %+v", b)
+	}
+	if b := got["main"]; b.Upstream != "origin/main" || b.Ahead != 0 || b.Gone {
+		t.Errorf("main = %+v", b)
+	}
+
+	git(t, "switch", "-q", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "local")
+	bs, _ = repo.Branches(ctx, base)
+	for _, b := range bs {
+		if b.Name == "main" && (b.Ahead != 1 || b.Behind != 0) {
+			t.Errorf("main ahead/behind = %d/%d", b.Ahead, b.Behind)
+		}
+	}
+}
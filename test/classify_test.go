@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"ezgit/internal/safety"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		args []string
+		want safety.Level
+	}{
+		{[]string{"status"}, safety.LevelSafe},
+		{[]string{"rebase", "origin/main"}, safety.LevelSafe},
+		{[]string{"reset", "--hard", "HEAD~1"}, safety.LevelDestructive},
+		{[]string{"reset", "--soft", "HEAD~1"}, safety.LevelSafe},
+		{[]string{"clean", "-fdx"}, safety.LevelDestructive},
+		{[]string{"clean", "-n", "-fd"}, safety.LevelSafe},
+		{[]string{"checkout", "--", "."}, safety.LevelDestructive},
+		{[]string{"checkout", "."}, safety.LevelDestructive},
+		{[]string{"checkout", "main"}, safety.LevelSafe},
+		{[]string{"checkout", "-b", "feature", "main"}, safety.LevelSafe},
+		{[]string{"checkout", "--theirs", "--", "a.txt"}, safety.LevelCaution},
+		{[]string{"restore", "src/a.go"}, safety.LevelDestructive},
+		{[]string{"restore", "--staged", "src/a.go"}, safety.LevelSafe},
+		{[]string{"restore", "-SW", "src/a.go"}, safety.LevelDestructive},
+		{[]string{"stash", "drop"}, safety.LevelDestructive},
+		{[]string{"stash", "clear"}, safety.LevelDestructive},
+		{[]string{"stash", "pop"}, safety.LevelSafe},
+		{[]string{"branch", "-D", "old"}, safety.LevelDestructive},
+		{[]string{"branch", "-d", "old"}, safety.LevelSafe},
+		{[]string{"branch", "--delete", "--force", "old"}, safety.LevelDestructive},
+		{[]string{"tag", "-d", "v1"}, safety.LevelDestructive},
+		{[]string{"push", "--force-with-lease", "origin", "main"}, safety.LevelDestructive},
+		{[]string{"push", "-uf", "origin", "main"}, safety.LevelDestructive},
+		{[]string{"push", "origin", "+main"}, safety.LevelDestructive},
+		{[]string{"push", "origin", ":old"}, safety.LevelDestructive},
+		{[]string{"push", "--delete", "origin", "old"}, safety.LevelDestructive},
+		{[]string{"push", "-u", "origin", "main"}, safety.LevelSafe},
+		{[]string{"gc", "--prune=now"}, safety.LevelDestructive},
+		{[]string{"gc"}, safety.LevelSafe},
+		{[]string{"worktree", "remove", "--force", "../wt"}, safety.LevelDestructive},
+		{[]string{"update-ref", "-d", "refs/heads/x"}, safety.LevelDestructive},
+		{[]string{"commit", "-m", "-f"}, safety.LevelSafe},
+		{[]string{"-C", "sub", "clean", "-f"}, safety.LevelDestructive},
+	}
+	for _, c := range cases {
+		if got := safety.Classify(c.args); got.Level != c.want {
+			t.Errorf("Classify(%q) = %v %v, want %v", c.args, got.Level, got.Reasons, c.want)
+		}
+	}
+}
+
+// The value of --onto is the new base, not the upstream the rebase replays
+// from.
+func TestRewritesPublishedRebaseOnto(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	git(t, "commit", "-q", "--allow-empty", "-m", "one")
+	git(t, "commit", "-q", "--allow-empty", "-m", "two")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "HEAD")
+	git(t, "commit", "-q", "--allow-empty", "-m", "three")
+
+	ctx := context.Background()
+	if pub := safety.RewritesPublished(ctx, []string{"rebase", "--onto", "HEAD", "HEAD~2"}); len(pub) != 1 {
+		t.Errorf("rebase --onto replaying a pushed commit: %q", pub)
+	}
+	if pub := safety.RewritesPublished(ctx, []string{"rebase", "--onto", "HEAD~2", "HEAD~1"}); len(pub) != 0 {
+		t.Errorf("rebase --onto replaying only unpushed work: %q", pub)
+	}
+	if pub := safety.RewritesPublished(ctx, []string{"rebase", "--exec", "make test", "-s", "ort", "HEAD~2"}); len(pub) != 1 {
+		t.Errorf("rebase with valued options: %q", pub)
+	}
+}
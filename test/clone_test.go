@@ -0,0 +1,57 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"ezgit/internal/action"
+	execpkg "ezgit/internal/exec"
+)
+
+func TestCloneURL(t *testing.T) {
+	if got := action.ExpandCloneURL("gh:owner/repo"); got != "https://github.com/owner/repo.git" {
+		t.Errorf("gh shorthand expanded to %q", got)
+	}
+	for _, u := range []string{"gh:owner/repo", "https://example.com/a.git", "git@example.com:a/b.git", "ssh://git@example.com/a"} {
+		if err := action.ValidateCloneURL(u); err != nil {
+			t.Errorf("%s: %v", u, err)
+		}
+	}
+	for _, u := range []string{"", "not a url", "zz:owner/repo"} {
+		if action.ValidateCloneURL(u) == nil {
+			t.Errorf("%q accepted", u)
+		}
+	}
+}
+
+func TestCloneArgs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, _ := r.Get("clone")
+	in := action.ActionInput{"url": "gh:owner/repo", "depth": "1", "partial": "true", "branch": "dev", "single_branch": "true", "submodules": "true", "origin": "upstream"}
+	_, args, _ := a.Build(in)
+	want := []string{"clone", "--progress", "--depth", "1", "--filter=blob:none", "--branch", "dev", "--single-branch", "--recurse-submodules", "--origin", "upstream", "--", "https://github.com/owner/repo.git"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q", args)
+	}
+	if d := a.Chdir(in); d != "repo" {
+		t.Errorf("clone dir = %q, want repo", d)
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	p, ok := execpkg.ParseProgress("Receiving objects:  45% (450/1000), 1.2 MiB | 3 MiB/s")
+	if !ok || p.Phase != "Receiving objects" || p.Percent != 45 || p.Current != 450 || p.Total != 1000 || p.Done {
+		t.Errorf("got %+v, %v", p, ok)
+	}
+	if p, ok := execpkg.ParseProgress("remote: Counting objects: 100% (7/7), done."); !ok || !p.Done {
+		t.Errorf("got %+v, %v", p, ok)
+	}
+	if p, ok := execpkg.ParseProgress("remote: Enumerating objects: 12, done."); !ok || p.Current != 12 || p.Total != 0 || !p.Done {
+		t.Errorf("got %+v, %v", p, ok)
+	}
+	if _, ok := execpkg.ParseProgress("Cloning into 'repo'..."); ok {
+		t.Error("plain line parsed as progress")
+	}
+}
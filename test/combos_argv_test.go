@@ -0,0 +1,63 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"ezgit/internal/combos"
+)
+
+func TestBuildArgsFollowsFormAndPreviewOrder(t *testing.T) {
+	spec := combos.CommandSpec{
+		ActionKey: "commit",
+		Forms:     []string{"git commit [options] [--] [<paths>...]"},
+		Flags: []combos.FlagDef{
+			{Key: "--amend", ParamKey: "amend", Type: "bool", PreviewOrder: 1},
+			{Key: "-m <msg>", ParamKey: "message", ManualOnly: true, PreviewOrder: 2},
+			{Key: "--author=<who>", ParamKey: "author", ManualOnly: true, PreviewOrder: 3},
+			{Key: "--no-verify", ParamKey: "no_verify", Type: "bool", PreviewOrder: 4},
+			{Key: "<paths>...", ParamKey: "paths", ManualOnly: true, PreviewOrder: 5},
+		},
+	}
+	sel := combos.Selection{
+		Included: map[string]bool{"amend": true},
+		Values:   map[string]string{"message": "fix the bug", "author": "", "paths": "a.go b.go"},
+	}
+	got := combos.BuildArgs(spec, sel)
+	want := []string{"commit", "--amend", "-m", "fix the bug", "--", "a.go", "b.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BuildArgs = %q, want %q", got, want)
+	}
+
+	if errs := combos.ArgErrors(spec, sel); len(errs) != 0 {
+		t.Fatalf("ArgErrors = %v", errs)
+	}
+	sel.Values["paths"] = `"a b.go`
+	if errs := combos.ArgErrors(spec, sel); errs["paths"] == "" || len(errs) != 1 {
+		t.Fatalf("unbalanced quote in paths: ArgErrors = %v", errs)
+	}
+
+	sel.Values["paths"] = ""
+	sel.Values["author"] = "Me <me@example.com>"
+	got = combos.BuildArgs(spec, sel)
+	want = []string{"commit", "--amend", "-m", "fix the bug", "--author=Me <me@example.com>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BuildArgs = %q, want %q", got, want)
+	}
+}
+
+func TestBuildArgsWithoutForms(t *testing.T) {
+	spec := combos.CommandSpec{
+		ActionKey: "stash-pop",
+		Name:      "stash pop",
+		Flags: []combos.FlagDef{
+			{Key: "--index", ParamKey: "index", Type: "bool"},
+			{Key: "<stash>", ParamKey: "stash", ManualOnly: true, Default: "stash@{0}", PreviewOrder: 1},
+		},
+	}
+	got := combos.BuildArgs(spec, combos.Selection{Included: map[string]bool{"index": true}})
+	want := []string{"stash", "pop", "--index", "stash@{0}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BuildArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"ezgit/internal/commitmsg"
+	"ezgit/internal/config"
+)
+
+func TestCommitMessageString(t *testing.T) {
+	m := commitmsg.Message{
+		Type:     "fix",
+		Scope:    "push",
+		Subject:  "pin the lease to the fetched SHA",
+		Body:     strings.Repeat("word ", 30) + "\n\n- a list item that is kept exactly as it was typed even though it is long",
+		Trailers: []commitmsg.Trailer{{Key: "Signed-off-by", Value: "A U Thor <a@example.com>"}},
+	}
+	got := m.String()
+	lines := strings.Split(got, "\n")
+	if lines[0] != "fix(push): pin the lease to the fetched SHA" || lines[1] != "" {
+		t.Fatalf("header block: %q", lines[:2])
+	}
+	for _, l := range lines[2:4] {
+		if len(l) > commitmsg.Width {
+			t.Fatalf("body not wrapped: %q", l)
+		}
+	}
+	if !strings.Contains(got, "\n- a list item that is kept exactly as it was typed even though it is long\n") {
+		t.Fatalf("list item reflowed:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n\nSigned-off-by: A U Thor <a@example.com>\n") {
+		t.Fatalf("trailers:\n%s", got)
+	}
+}
+
+func TestCommitMessageBodyWidth(t *testing.T) {
+	body := strings.Repeat("word ", 40)
+	for _, width := range []int{50, 100} {
+		m := commitmsg.Message{Subject: "Wrap at the configured width", Body: body, BodyWidth: width}
+		lines := strings.Split(m.String(), "\n")
+		if n := len(lines[2]); n > width || n <= width-5 {
+			t.Errorf("width %d: first body line is %d long", width, n)
+		}
+		if issues := commitmsg.Lint(m, config.CommitLint{BodyWidth: width}); len(issues) != 0 {
+			t.Errorf("width %d: %v", width, issues)
+		}
+	}
+}
+
+func TestCommitLint(t *testing.T) {
+	long := commitmsg.Message{Subject: strings.Repeat("x", 80)}
+	if !commitmsg.Blocking(commitmsg.Lint(long, config.CommitLint{})) {
+		t.Fatal("80 column subject should block")
+	}
+	ok := commitmsg.Message{Subject: "Add clone wizard"}
+	if issues := commitmsg.Lint(ok, config.CommitLint{}); len(issues) != 0 {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+	if !commitmsg.Blocking(commitmsg.Lint(ok, config.CommitLint{RequireConventional: true})) {
+		t.Fatal("missing type should block when conventional commits are required")
+	}
+	if !commitmsg.Blocking(commitmsg.Lint(commitmsg.Message{Type: "feature", Subject: "x"}, config.CommitLint{})) {
+		t.Fatal("unknown type should block")
+	}
+}
+
+func TestCommitTemplate(t *testing.T) {
+	subject, body := commitmsg.FromTemplate("# comment\nSubject line\n\nBody text\n# trailing comment\n")
+	if subject != "Subject line" || body != "Body text" {
+		t.Fatalf("got %q / %q", subject, body)
+	}
+}
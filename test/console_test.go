@@ -0,0 +1,119 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/combos"
+	"ezgit/internal/console"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "h.txt")
+	h := console.LoadHistory(path)
+	for _, l := range []string{"status", "status", "  log --oneline ", "", "diff\nrm -rf", "push"} {
+		if err := h.Add(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"status", "log --oneline", "push"}
+	if !reflect.DeepEqual(h.Entries(), want) {
+		t.Fatalf("entries = %q", h.Entries())
+	}
+
+	h = console.LoadHistory(path)
+	if !reflect.DeepEqual(h.Entries(), want) {
+		t.Fatalf("reloaded entries = %q", h.Entries())
+	}
+	if l, ok := h.Prev("draft"); !ok || l != "push" {
+		t.Errorf("Prev = %q, %v", l, ok)
+	}
+	h.Prev("")
+	h.Prev("")
+	if _, ok := h.Prev(""); ok {
+		t.Error("Prev went past the oldest entry")
+	}
+	h.Next()
+	h.Next()
+	if l, ok := h.Next(); !ok || l != "draft" {
+		t.Errorf("Next back to the draft = %q, %v", l, ok)
+	}
+	if i := h.Search("o", len(h.Entries())); i != 1 {
+		t.Errorf("Search(o) = %d, want the newest match 1", i)
+	}
+	if i := h.Search("o", 1); i != -1 {
+		t.Errorf("Search(o) before 1 = %d", i)
+	}
+}
+
+func TestHistoryFileIsTrimmed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "h.txt")
+	var b strings.Builder
+	for i := 0; i < 2500; i++ {
+		fmt.Fprintf(&b, "log -%d\n", i)
+	}
+	os.WriteFile(path, []byte(b.String()), 0o600)
+
+	h := console.LoadHistory(path)
+	if n := len(h.Entries()); n != 1000 || h.Entries()[n-1] != "log -2499" {
+		t.Fatalf("kept %d entries, newest %q", n, h.Entries()[n-1])
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 1000 {
+		t.Errorf("file still has %d lines", n)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	t.Cleanup(func() { combos.Replace(&combos.CombosFile{}) })
+	combos.Replace(&combos.CombosFile{Commands: []combos.CommandSpec{{
+		ActionKey: "push", Name: "git push",
+		Flags: []combos.FlagDef{{Key: "--force-with-lease"}, {Key: "--force"}, {Key: "-u <upstream>"}},
+	}}})
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0o755)
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0o644)
+	os.Mkdir(filepath.Join(dir, ".git"), 0o755)
+	c := &console.Completer{Refs: []string{"main", "feature/x"}, Remotes: []string{"origin", "upstream"}, Dir: dir}
+
+	cases := []struct {
+		line  string
+		want  []string
+		start int
+	}{
+		{"git pu", []string{"pull", "push"}, 4},
+		{"push --f", []string{"--force", "--force-with-lease"}, 5},
+		{"push u", []string{"upstream"}, 5},
+		{"push origin ma", []string{"main"}, 12},
+		{"add src/", []string{"src/main.go", "src/pkg/"}, 4},
+		{"add .g", nil, 4},
+	}
+	for _, tc := range cases {
+		got, start := c.Complete(tc.line)
+		if !reflect.DeepEqual(got, tc.want) || start != tc.start {
+			t.Errorf("%q: got %q at %d, want %q at %d", tc.line, got, start, tc.want, tc.start)
+		}
+	}
+	if p := console.CommonPrefix([]string{"--force", "--force-with-lease"}); p != "--force" {
+		t.Errorf("CommonPrefix = %q", p)
+	}
+}
+
+func TestCompleterHelpUsesItsRegistry(t *testing.T) {
+	r := action.NewRegistry()
+	if err := r.ReplaceCustom([]*action.ActionDef{{Name: "hello", Help: "Say hello"}}); err != nil {
+		t.Fatal(err)
+	}
+	c := &console.Completer{Actions: r}
+	if h := c.Help("git hello there"); h != "Say hello" {
+		t.Errorf("Help = %q", h)
+	}
+	if h := (&console.Completer{}).Help("hello"); h != "" {
+		t.Errorf("Help without a registry = %q", h)
+	}
+}
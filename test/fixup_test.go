@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/safety"
+)
+
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestFixupOlderCommit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	git(t, "add", "a.txt")
+	git(t, "commit", "-qm", "add a")
+	os.WriteFile("b.txt", []byte("b\n"), 0o644)
+	git(t, "add", "b.txt")
+	git(t, "commit", "-qm", "add b")
+	os.WriteFile("c.txt", []byte("c\n"), 0o644)
+	git(t, "add", "c.txt")
+	git(t, "commit", "-qm", "add c")
+	os.WriteFile("b.txt", []byte("b fixed\n"), 0o644)
+	git(t, "add", "b.txt")
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, ok := r.Get("fixup")
+	if !ok {
+		t.Fatal("fixup action not registered")
+	}
+	in := action.ActionInput{"target": "HEAD~1", "fix": "staged changes", "autosquash": "true"}
+	if err := a.Validate(in); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range a.Steps(in) {
+		git(t, s.Args...)
+	}
+	if got := git(t, "log", "--format=%s"); got != "add c\nadd b\nadd a" {
+		t.Fatalf("history after autosquash:\n%s", got)
+	}
+	if got := git(t, "show", "HEAD~1:b.txt"); got != "b fixed" {
+		t.Fatalf("fix not folded into target: %q", got)
+	}
+}
+
+func TestRewritesPublished(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	git(t, "commit", "-q", "--allow-empty", "-m", "one")
+	git(t, "commit", "-q", "--allow-empty", "-m", "two")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "HEAD")
+
+	ctx := context.Background()
+	if pub := safety.RewritesPublished(ctx, []string{"commit", "--amend", "--no-edit"}); len(pub) != 1 {
+		t.Fatalf("amending a pushed HEAD: %q", pub)
+	}
+	git(t, "commit", "-q", "--allow-empty", "-m", "three")
+	if pub := safety.RewritesPublished(ctx, []string{"commit", "--amend", "--no-edit"}); len(pub) != 0 {
+		t.Fatalf("amending an unpushed HEAD: %q", pub)
+	}
+	if pub := safety.RewritesPublished(ctx, []string{"rebase", "-i", "HEAD~2"}); len(pub) != 1 {
+		t.Fatalf("rebase over one pushed commit: %q", pub)
+	}
+}
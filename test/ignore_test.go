@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"ezgit/internal/ignore"
+	"ezgit/internal/repo"
+)
+
+func TestIgnorePatternsAndTemplates(t *testing.T) {
+	cases := []struct {
+		root, kind string
+		global     bool
+		want       string
+	}{
+		{"logs/app.log", ignore.KindFile, false, "/logs/app.log"},
+		{"logs/app.log", ignore.KindExt, false, "*.log"},
+		{"logs/app.log", ignore.KindDir, false, "/logs/"},
+		{"logs/app.log", ignore.KindFile, true, "app.log"},
+		{"logs/app.log", ignore.KindDir, true, "logs/"},
+		{".env", ignore.KindExt, false, ""},
+		{"top.txt", ignore.KindDir, false, ""},
+		{"web/node_modules/", ignore.KindFile, false, "/web/node_modules/"},
+		{"web/node_modules/", ignore.KindFile, true, "node_modules/"},
+		{"web/node_modules/", ignore.KindDir, false, "/web/"},
+		{"build.d/", ignore.KindExt, false, ""},
+	}
+	for _, c := range cases {
+		if got := ignore.Pattern(c.root, c.kind, c.global); got != c.want {
+			t.Errorf("Pattern(%q, %s, %v) = %q, want %q", c.root, c.kind, c.global, got, c.want)
+		}
+	}
+
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q")
+	tmpl, err := ignore.Template("Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(".gitignore", []byte("*.exe\n"), 0o644)
+	first, _ := ignore.Merge(".gitignore", "Go", tmpl)
+	again, _ := ignore.Merge(".gitignore", "Go", tmpl)
+	if len(first) == 0 || len(again) != 0 {
+		t.Fatalf("merge added %d then %d lines", len(first), len(again))
+	}
+	b, _ := os.ReadFile(".gitignore")
+	if strings.Count(string(b), "*.exe\n") != 1 || !strings.Contains(string(b), "\n# Go\n") {
+		t.Fatalf(".gitignore is\n%s", b)
+	}
+
+	ctx := context.Background()
+	exclude, err := repo.IgnoreFile(ctx, repo.IgnoreExclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ignore.Append(exclude, []string{"/notes.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("notes.txt", nil, 0o644)
+	os.WriteFile("tool.exe", nil, 0o644)
+	os.WriteFile("main.go", nil, 0o644)
+	os.MkdirAll("node_modules/left-pad", 0o755)
+	os.WriteFile("node_modules/left-pad/index.js", nil, 0o644)
+	os.WriteFile("node_modules/left-pad/package.json", nil, 0o644)
+	if files, _ := repo.Untracked(ctx, false); strings.Join(files, ",") != ".gitignore,main.go,node_modules/" {
+		t.Fatalf("untracked = %q", files)
+	}
+	why := repo.WhyIgnored(ctx, []string{"notes.txt", "tool.exe", "main.go"})
+	if r := why["tool.exe"]; r.Source != ".gitignore" || r.Pattern != "*.exe" {
+		t.Errorf("tool.exe: %+v", r)
+	}
+	if r := why["notes.txt"]; !strings.HasSuffix(r.Source, "info/exclude") {
+		t.Errorf("notes.txt: %+v", r)
+	}
+	if _, ok := why["main.go"]; ok {
+		t.Error("main.go is not ignored")
+	}
+}
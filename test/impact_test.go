@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"ezgit/internal/safety"
+)
+
+func TestComputeImpact(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", "-b", "main", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	git(t, "add", "a.txt")
+	git(t, "commit", "-qm", "root")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "main")
+	os.WriteFile("a.txt", []byte("b\n"), 0o644)
+	git(t, "commit", "-qam", "second")
+	ctx := context.Background()
+
+	im := safety.ComputeImpact(ctx, []string{"reset", "--hard", "HEAD~1"})
+	if len(im.Unreachable) != 1 || !strings.HasSuffix(im.Unreachable[0], "second") {
+		t.Errorf("reset --hard HEAD~1 unreachable = %q", im.Unreachable)
+	}
+	if len(im.Diffstat) != 0 {
+		t.Errorf("clean tree has diffstat %q", im.Diffstat)
+	}
+
+	os.WriteFile("a.txt", []byte("dirty\n"), 0o644)
+	im = safety.ComputeImpact(ctx, []string{"reset", "--hard"})
+	if len(im.Diffstat) == 0 || !strings.Contains(im.Diffstat[0], "a.txt") {
+		t.Errorf("reset --hard on a dirty tree: diffstat %q", im.Diffstat)
+	}
+	if im := safety.ComputeImpact(ctx, []string{"checkout", "--", "a.txt"}); len(im.Diffstat) == 0 {
+		t.Error("checkout -- a.txt would lose the edit but shows no diffstat")
+	}
+	if im := safety.ComputeImpact(ctx, []string{"reset", "--soft", "HEAD~1"}); !im.Empty() {
+		t.Errorf("reset --soft loses nothing, got %+v", im)
+	}
+	git(t, "checkout", "-q", "--", "a.txt")
+
+	os.WriteFile("junk.log", []byte("12345"), 0o644)
+	os.MkdirAll("build/out", 0o755)
+	os.WriteFile("build/out/bin", make([]byte, 2048), 0o644)
+	im = safety.ComputeImpact(ctx, []string{"clean", "-fd"})
+	sizes := map[string]int64{}
+	for _, f := range im.Deleted {
+		sizes[f.Path] = f.Size
+	}
+	if sizes["junk.log"] != 5 || sizes["build/"] != 2048 || len(sizes) != 2 {
+		t.Errorf("clean -fd deletes %+v", im.Deleted)
+	}
+	if !strings.Contains(strings.Join(im.Lines(), "\n"), "Files that will be deleted (2, 2.0 KiB)") {
+		t.Errorf("lines:\n%s", strings.Join(im.Lines(), "\n"))
+	}
+	if im := safety.ComputeImpact(ctx, []string{"clean", "-f"}); len(im.Deleted) != 1 {
+		t.Errorf("clean -f leaves directories alone, got %+v", im.Deleted)
+	}
+	if im := safety.ComputeImpact(ctx, []string{"clean", "-nfd"}); !im.Empty() {
+		t.Errorf("a dry run deletes nothing, got %+v", im)
+	}
+
+	git(t, "switch", "-q", "-c", "feature")
+	os.WriteFile("f.txt", []byte("f\n"), 0o644)
+	git(t, "add", "f.txt")
+	git(t, "commit", "-qm", "only on feature")
+	git(t, "switch", "-q", "main")
+	im = safety.ComputeImpact(ctx, []string{"branch", "-D", "feature"})
+	if len(im.Unreachable) != 1 || !strings.HasPrefix(im.Unreachable[0], "feature: ") {
+		t.Errorf("branch -D feature unreachable = %q", im.Unreachable)
+	}
+	if im := safety.ComputeImpact(ctx, []string{"branch", "-d", "feature"}); !im.Empty() {
+		t.Errorf("branch -d refuses unmerged work itself, got %+v", im)
+	}
+
+	other := t.TempDir()
+	git(t, "clone", "-q", remote, other)
+	os.WriteFile(other+"/r.txt", []byte("r\n"), 0o644)
+	git(t, "-C", other, "add", "r.txt")
+	git(t, "-C", other, "commit", "-qm", "theirs on the remote")
+	git(t, "-C", other, "push", "-q", "origin", "main")
+	git(t, "fetch", "-q", "origin")
+	for _, args := range [][]string{{"push", "--force", "origin", "main"}, {"push", "origin", "+main"}} {
+		im = safety.ComputeImpact(ctx, args)
+		if len(im.Overwritten) != 1 || !strings.HasSuffix(im.Overwritten[0], "theirs on the remote") {
+			t.Errorf("%q overwritten = %q", args, im.Overwritten)
+		}
+	}
+	if im := safety.ComputeImpact(ctx, []string{"push", "origin", "main"}); !im.Empty() {
+		t.Errorf("a plain push overwrites nothing, got %+v", im)
+	}
+}
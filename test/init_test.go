@@ -0,0 +1,69 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/scaffold"
+)
+
+func TestInitScaffold(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, k := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(k, "Ada")
+	}
+	for _, k := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(k, "ada@example.com")
+	}
+	os.MkdirAll(filepath.Join(home, ".ezgit"), 0o700)
+	os.WriteFile(filepath.Join(home, ".ezgit", "config.json"), []byte(`{"initial_branch": "trunk"}`), 0o600)
+	t.Chdir(t.TempDir())
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, _ := r.Get("init")
+	in := action.ActionInput{"path": "proj", "readme": "true", "gitignore": "Go\nmacOS", "license": "MIT", "commit": "true", "remote": "https://example.com/proj.git"}
+	if err := a.Validate(in); err != nil {
+		t.Fatal(err)
+	}
+	_, _, preview := a.Build(in)
+	for _, f := range []string{"README.md", ".gitignore", "LICENSE"} {
+		if !strings.Contains(preview, "# creates "+filepath.Join("proj", f)) {
+			t.Errorf("preview does not list %s:\n%s", f, preview)
+		}
+	}
+
+	dir, files := a.Files(in)
+	if _, _, err := scaffold.Write(dir, files); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range a.Steps(in) {
+		git(t, s.Args...)
+	}
+	if b := git(t, "-C", "proj", "branch", "--show-current"); b != "trunk" {
+		t.Errorf("initial branch = %q, want the configured trunk", b)
+	}
+	if got := git(t, "-C", "proj", "ls-files"); got != ".gitignore\nLICENSE\nREADME.md" {
+		t.Errorf("initial commit has:\n%s", got)
+	}
+	if u := git(t, "-C", "proj", "remote", "get-url", "origin"); u != "https://example.com/proj.git" {
+		t.Errorf("origin = %q", u)
+	}
+	ig, _ := os.ReadFile("proj/.gitignore")
+	lic, _ := os.ReadFile("proj/LICENSE")
+	if !strings.Contains(string(ig), "*.test") || !strings.Contains(string(ig), ".DS_Store") || !strings.Contains(string(lic), "Copyright (c) ") {
+		t.Errorf("templates not rendered:\n%s\n%s", ig, lic)
+	}
+
+	os.WriteFile("proj/README.md", []byte("mine\n"), 0o644)
+	if _, kept, _ := scaffold.Write(dir, files); len(kept) != 3 {
+		t.Errorf("existing files must be kept, got %q", kept)
+	}
+	if b, _ := os.ReadFile("proj/README.md"); string(b) != "mine\n" {
+		t.Error("README.md was overwritten")
+	}
+}
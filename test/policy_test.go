@@ -0,0 +1,76 @@
+package test
+
+import (
+	"testing"
+
+	"ezgit/internal/safety"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	pol := &safety.Policy{
+		ProtectedBranches: []string{"main", "release/*"},
+		Rules: []safety.Rule{
+			{Name: "no-force", Command: "push", Flags: []string{"--force", "-f", "--force-with-lease"}, Protected: true, Severity: safety.SeverityDeny},
+			{Name: "no-commit-main", Command: "commit", Branches: []string{"main"}, Severity: safety.SeverityDeny},
+			{Name: "big-push", Command: "push", MaxCommits: 3, Severity: safety.SeverityWarn},
+		},
+	}
+	rc := safety.RepoContext{
+		CurrentBranch: "feature",
+		PushCount:     func(remote, local, branch string) int { return 5 },
+	}
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"push", "--force-with-lease", "origin", "release/1.2"}, []string{"no-force", "big-push"}},
+		{[]string{"push", "-uf", "origin", "HEAD:main"}, []string{"no-force", "big-push"}},
+		{[]string{"push", "--force", "origin", "feature"}, []string{"big-push"}},
+		{[]string{"commit", "-m", "x"}, nil},
+		{[]string{"push", "origin", "+main"}, []string{"no-force", "big-push"}},
+		{[]string{"push", "origin", "+feature:release/2"}, []string{"no-force", "big-push"}},
+		{[]string{"-c", "x=y", "push", "--force", "origin", "main"}, []string{"no-force", "big-push"}},
+		{[]string{"-C", "sub", "push", "-f", "origin", "main"}, []string{"no-force", "big-push"}},
+		{[]string{"push", "origin", "+HEAD"}, []string{"big-push"}},
+		{[]string{"push", "-o", "ci.skip", "--force", "origin", "main"}, []string{"no-force", "big-push"}},
+		{[]string{"push", "--push-option", "ci.skip", "--repo", "origin", "-f", "origin", "release/3"}, []string{"no-force", "big-push"}},
+		{[]string{"--git-dir", ".git", "push", "--receive-pack", "git-receive-pack", "-f", "origin", "main"}, []string{"no-force", "big-push"}},
+	}
+	for _, c := range cases {
+		got := pol.Evaluate(c.args, rc)
+		if len(got) != len(c.want) {
+			t.Fatalf("%v: got %v, want rules %v", c.args, got, c.want)
+		}
+		for i, f := range got {
+			if f.Rule != c.want[i] {
+				t.Fatalf("%v: got %v, want rules %v", c.args, got, c.want)
+			}
+		}
+	}
+
+	rc.CurrentBranch = "main"
+	for _, args := range [][]string{
+		{"push", "-f", "origin", "HEAD"},
+		{"push", "--force", "origin", "HEAD:main"},
+		{"push", "origin", "+HEAD"},
+		{"push", "origin", "+:main"},
+	} {
+		if got := pol.Evaluate(args, rc); len(got) == 0 || got[0].Rule != "no-force" {
+			t.Errorf("%v on main: got %v", args, got)
+		}
+	}
+	if got := pol.Evaluate([]string{"-c", "core.editor=true", "commit", "-m", "x"}, rc); len(got) != 1 {
+		t.Errorf("commit on main behind -c: got %v", got)
+	}
+	rebase := &safety.Policy{
+		ProtectedBranches: []string{"main"},
+		Rules:             []safety.Rule{{Name: "no-rebase", Command: "rebase", Protected: true, Severity: safety.SeverityDeny}},
+	}
+	if got := rebase.Evaluate([]string{"-c", "sequence.editor=:", "rebase", "-i", "--autosquash", "HEAD~3"}, rc); len(got) != 1 {
+		t.Errorf("scripted rebase on main: got %v", got)
+	}
+	if got := pol.Evaluate([]string{"commit", "-m", "x"}, rc); len(got) != 1 || got[0].Severity != safety.SeverityDeny {
+		t.Fatalf("commit on main: got %v", got)
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/tui"
+)
+
+func TestPromptNormalize(t *testing.T) {
+	mode := action.Prompt{Key: "mode", Type: action.PromptEnum, Options: []string{"soft", "mixed", "hard"}}
+	if v, err := mode.Normalize("HARD"); err != nil || v != "hard" {
+		t.Fatalf("enum: %q %v", v, err)
+	}
+	if _, err := mode.Normalize("hrad"); err == nil {
+		t.Fatal("enum typo accepted")
+	}
+
+	force := action.Prompt{Key: "force", Type: action.PromptBool}
+	if v, err := force.Normalize("Y"); err != nil || v != "true" {
+		t.Fatalf("bool: %q %v", v, err)
+	}
+	if _, err := force.Normalize("maybe"); err == nil {
+		t.Fatal("bool accepted maybe")
+	}
+
+	depth := action.Prompt{Key: "depth", Type: action.PromptInt}
+	if _, err := depth.Normalize("ten"); err == nil {
+		t.Fatal("int accepted ten")
+	}
+
+	files := action.Prompt{Key: "files", Type: action.PromptMultiSelect, Options: []string{"a.go", "b.go"}}
+	v, err := files.Normalize("a.go, B.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := (action.ActionInput{"files": v}).List("files"); len(got) != 2 || got[1] != "b.go" {
+		t.Fatalf("multi-select: %q", got)
+	}
+}
+
+func TestBasicPrompterTyped(t *testing.T) {
+	a := &action.ActionDef{Prompts: []action.Prompt{
+		{Key: "mode", Type: action.PromptEnum, Options: []string{"soft", "mixed", "hard"}, Default: "mixed"},
+		{Key: "force", Type: action.PromptBool, Default: "false"},
+		{Key: "body", Type: action.PromptMultiline},
+	}}
+	// the typo is asked again, the empty bool takes its default
+	in := "hrad\nsoft\n\nline one\nline two\n.\n"
+	got, err := tui.BasicPrompter(a, bufio.NewReader(strings.NewReader(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["mode"] != "soft" || got.Bool("force") || got["body"] != "line one\nline two" {
+		t.Fatalf("got %q", got)
+	}
+}
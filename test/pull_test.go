@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+)
+
+func TestPullRecommendation(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "root")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "main")
+	root := git(t, "rev-parse", "HEAD")
+	git(t, "commit", "-q", "--allow-empty", "-m", "theirs")
+	git(t, "push", "-q", "origin", "main")
+	git(t, "reset", "-q", "--hard", root)
+
+	ctx := context.Background()
+	recommend := func() (repo.Divergence, string) {
+		t.Helper()
+		d, err := repo.Diverge(ctx, "origin/main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _ := action.RecommendPull(d)
+		return d, s
+	}
+	if d, s := recommend(); s != action.PullFastForward || d.Behind != 1 || d.Ahead != 0 {
+		t.Fatalf("behind only: %+v -> %q", d, s)
+	}
+
+	git(t, "commit", "-q", "--allow-empty", "-m", "mine")
+	if d, s := recommend(); s != action.PullRebase || len(d.Local) != 1 || len(d.Remote) != 1 {
+		t.Fatalf("diverged: %+v -> %q", d, s)
+	}
+
+	git(t, "push", "-q", "origin", "HEAD:refs/heads/shared")
+	if d, s := recommend(); s != action.PullMerge || !d.LocalShared {
+		t.Fatalf("shared local commit: %+v -> %q", d, s)
+	}
+
+	for _, s := range action.PullSteps(action.PullRebase, "origin/main", true) {
+		git(t, s.Args...)
+	}
+	if git(t, "config", "pull.rebase") != "true" {
+		t.Fatal("pull.rebase was not saved")
+	}
+	if _, s := recommend(); s != "" {
+		t.Fatalf("after the rebase there should be nothing to pull, got %q", s)
+	}
+}
+
+func TestPullSaveReplacesEarlierDefault(t *testing.T) {
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	saved := func(strategy string) (rebase, ff string) {
+		t.Helper()
+		for _, s := range action.PullSteps(strategy, "origin/main", true) {
+			if s.Args[0] == "config" {
+				git(t, s.Args...)
+			}
+		}
+		return git(t, "config", "pull.rebase"), git(t, "config", "pull.ff")
+	}
+	if r, ff := saved(action.PullFastForward); r != "false" || ff != "only" {
+		t.Fatalf("fast-forward saved pull.rebase=%s pull.ff=%s", r, ff)
+	}
+	if r, ff := saved(action.PullMerge); r != "false" || ff != "true" {
+		t.Fatalf("merge saved pull.rebase=%s pull.ff=%s", r, ff)
+	}
+	saved(action.PullRebase)
+	if r, ff := saved(action.PullFastForward); r != "false" || ff != "only" {
+		t.Fatalf("fast-forward after rebase saved pull.rebase=%s pull.ff=%s", r, ff)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+)
+
+func TestPushDefaultsAndLease(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "root")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "switch", "-q", "-c", "feat")
+	git(t, "commit", "-q", "--allow-empty", "-m", "feature work")
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, _ := r.Get("push")
+	in := action.ActionInput{}
+	if !a.Prompts[2].Asked(in) {
+		t.Fatal("a branch without upstream should be offered --set-upstream")
+	}
+	in["set_upstream"] = "true"
+	_, args, preview := a.Build(in)
+	if want := []string{"push", "--set-upstream", "origin", "feat"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %q", args)
+	}
+	if !strings.Contains(preview, "pushes 2 commit(s)") || !strings.Contains(preview, "feature work") {
+		t.Fatalf("preview does not list the commits:\n%s", preview)
+	}
+	git(t, args...)
+
+	in = action.ActionInput{"force": "true"}
+	if a.Prompts[2].Asked(in) {
+		t.Fatal("upstream is set now")
+	}
+	_, args, _ = a.Build(in)
+	lease := "--force-with-lease=refs/heads/feat:" + git(t, "rev-parse", "origin/feat")
+	if want := []string{"push", lease, "origin", "feat"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %q", args)
+	}
+}
+
+func TestPullRetryAfterRejectedPush(t *testing.T) {
+	stderr := " ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs"
+	if !action.PushRejected(stderr) || action.PushRejected("fatal: could not read from remote") {
+		t.Fatal("PushRejected misclassified")
+	}
+	steps := action.PullRetrySteps([]string{"push", "--force-with-lease=refs/heads/main:abc", "-u", "origin", "main:release"})
+	want := [][]string{
+		{"pull", "--rebase", "--autostash", "origin", "release"},
+		{"push", "-u", "origin", "main:release"},
+	}
+	for i, s := range steps {
+		if !reflect.DeepEqual(s.Args, want[i]) {
+			t.Errorf("step %d = %q, want %q", i, s.Args, want[i])
+		}
+	}
+}
+
+func TestStaleLeaseOffersFetchAndReview(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	other := t.TempDir()
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "root")
+	git(t, "remote", "add", "origin", remote)
+	git(t, "push", "-q", "-u", "origin", "main")
+	lease := "--force-with-lease=refs/heads/main:" + git(t, "rev-parse", "origin/main")
+	git(t, "clone", "-q", "-b", "main", remote, other)
+	git(t, "-C", other, "commit", "-q", "--allow-empty", "-m", "theirs")
+	git(t, "-C", other, "push", "-q", "origin", "main")
+	git(t, "commit", "-q", "--allow-empty", "-m", "mine")
+
+	push := []string{"push", lease, "origin", "main"}
+	out, err := exec.Command("git", push...).CombinedOutput()
+	if err == nil {
+		t.Fatal("push with a stale lease went through")
+	}
+	if !action.LeaseStale(string(out)) || action.PushRejected(string(out)) {
+		t.Fatalf("stale lease misclassified:\n%s", out)
+	}
+	steps := action.FetchReviewSteps(push)
+	want := [][]string{
+		{"fetch", "origin"},
+		{"log", "--oneline", "HEAD..refs/remotes/origin/main"},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("steps = %v", steps)
+	}
+	for i, s := range steps {
+		if !reflect.DeepEqual(s.Args, want[i]) {
+			t.Errorf("step %d = %q, want %q", i, s.Args, want[i])
+		}
+		git(t, s.Args...)
+	}
+	if got := git(t, "log", "--format=%s", "HEAD..origin/main"); got != "theirs" {
+		t.Errorf("review lists %q, want their commit", got)
+	}
+}
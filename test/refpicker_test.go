@@ -0,0 +1,70 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ezgit/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRefPickerChoice(t *testing.T) {
+	p := tui.NewRefPicker([]tui.RefItem{
+		{Name: "main", Kind: tui.RefBranch},
+		{Name: "feature/login-form", Kind: tui.RefBranch},
+		{Name: "origin/main", Kind: tui.RefRemote},
+	})
+
+	p.SetFilter("flog")
+	if got := p.Choice("flog"); got != "flog" {
+		t.Fatalf("a fuzzy match must not replace the typed text, got %q", got)
+	}
+	p.SetFilter("feat")
+	if got := p.Choice("feat"); got != "feature/login-form" {
+		t.Fatalf("a unique prefix should complete, got %q", got)
+	}
+
+	commits := tui.NewRefPicker([]tui.RefItem{
+		{Name: "a1b2c3d", Kind: tui.RefCommit, Subject: "bump version to 1.0.3"},
+		{Name: "a1f00ba", Kind: tui.RefCommit, Subject: "fix"},
+	})
+	commits.SetFilter("v1.0")
+	if sel, _ := commits.Selected(); sel.Name != "a1b2c3d" {
+		t.Fatalf("expected the subject to match the filter, got %q", sel.Name)
+	}
+	if got := commits.Choice("v1.0"); got != "v1.0" {
+		t.Fatalf("a subject match replaced the typed ref: %q", got)
+	}
+	commits.SetFilter("a1")
+	if got := commits.Choice("a1"); got != "a1" {
+		t.Fatalf("an ambiguous prefix must stay as typed, got %q", got)
+	}
+
+	p.SetFilter("main")
+	if got := p.Choice("main"); got != "main" {
+		t.Fatalf("exact name should be kept, got %q", got)
+	}
+	p.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if got := p.Choice("main"); got != "origin/main" {
+		t.Fatalf("navigated choice = %q", got)
+	}
+
+	p.SetFilter("-b topic")
+	if got := p.Choice("-b topic"); got != "-b topic" {
+		t.Fatalf("free-form args should pass through, got %q", got)
+	}
+}
+
+func TestRefPickerTruncatesByRune(t *testing.T) {
+	subject := strings.Repeat("a", 38) + "ééé and more"
+	p := tui.NewRefPicker([]tui.RefItem{{Name: "a1b2c3d", Kind: tui.RefCommit, Subject: subject}})
+	v := p.View()
+	if !utf8.ValidString(v) {
+		t.Fatalf("view cut a character in half: %q", v)
+	}
+	if !strings.Contains(v, strings.Repeat("a", 38)+"é…") {
+		t.Errorf("subject not cut to 39 characters:\n%s", v)
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"ezgit/internal/combos"
+	"ezgit/internal/watch"
+)
+
+func TestCombosReplace(t *testing.T) {
+	combos.Replace(&combos.CombosFile{Commands: []combos.CommandSpec{
+		{ActionKey: "old", Name: "Old"},
+		{ActionKey: "kept", ActionAliases: []string{"K"}},
+	}})
+	combos.Replace(&combos.CombosFile{Commands: []combos.CommandSpec{
+		{ActionKey: "kept", ActionAliases: []string{"K"}},
+	}})
+	if _, ok := combos.Get("old"); ok {
+		t.Error("a command removed from the catalog is still registered")
+	}
+	if _, ok := combos.Get("Old"); ok {
+		t.Error("the alias of a removed command still resolves")
+	}
+	if c, ok := combos.Get("k"); !ok || c.ActionKey != "kept" {
+		t.Errorf("alias k = %+v, %v", c, ok)
+	}
+	t.Cleanup(func() { combos.Replace(&combos.CombosFile{}) })
+
+	// a reader racing a reload sees the old or the new catalog, never none
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, ok := combos.Get("kept"); !ok {
+				t.Error("catalog was empty during Replace")
+				return
+			}
+		}
+	}()
+	doc := &combos.CombosFile{Commands: []combos.CommandSpec{{ActionKey: "kept"}}}
+	for i := 0; i < 2000; i++ {
+		combos.Replace(doc)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestWatcherChanged(t *testing.T) {
+	t.Chdir(t.TempDir())
+	w := watch.New("a.json", "b.json")
+	if got := w.Changed(); len(got) != 0 {
+		t.Fatalf("nothing happened yet, got %q", got)
+	}
+	os.WriteFile("a.json", []byte("{}"), 0o644)
+	if got := w.Changed(); !reflect.DeepEqual(got, []string{"a.json"}) {
+		t.Errorf("after create: %q", got)
+	}
+	if got := w.Changed(); len(got) != 0 {
+		t.Errorf("a change is reported once, got %q", got)
+	}
+	os.WriteFile("a.json", []byte(`{"commands": []}`), 0o644)
+	later := time.Now().Add(time.Second)
+	os.Chtimes("a.json", later, later)
+	if got := w.Changed(); !reflect.DeepEqual(got, []string{"a.json"}) {
+		t.Errorf("after write: %q", got)
+	}
+	os.Remove("a.json")
+	os.WriteFile("b.json", nil, 0o644)
+	if got := w.Changed(); !reflect.DeepEqual(got, []string{"a.json", "b.json"}) {
+		t.Errorf("after delete and create: %q", got)
+	}
+}
+
+func TestWatcherRemove(t *testing.T) {
+	t.Chdir(t.TempDir())
+	w := watch.New("a.json", "b.json")
+	w.Remove("a.json")
+	w.Remove("missing.json")
+	os.WriteFile("a.json", nil, 0o644)
+	os.WriteFile("b.json", nil, 0o644)
+	if got := w.Changed(); !reflect.DeepEqual(got, []string{"b.json"}) {
+		t.Errorf("after removing a.json: %q", got)
+	}
+	w.Add("a.json")
+	os.Remove("a.json")
+	if got := w.Changed(); !reflect.DeepEqual(got, []string{"a.json"}) {
+		t.Errorf("after adding a.json back: %q", got)
+	}
+}
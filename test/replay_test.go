@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ezgit/internal/action"
+	execpkg "ezgit/internal/exec"
+)
+
+// replay gives a registry of the builtins whose git commands are all
+// answered from a fixture recorded with EZGIT_RECORD or execpkg.Recorder,
+// outside any repository.
+func replay(t *testing.T, fixture string) (*action.Registry, *execpkg.Replayer) {
+	t.Helper()
+	p, err := execpkg.LoadReplay(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	r := action.NewRegistry()
+	r.SetExecutor(p)
+	action.RegisterBuiltins(r)
+	return r, p
+}
+
+func TestReplaySyncPlan(t *testing.T) {
+	r, p := replay(t, "sync_dirty.json")
+	a, _ := r.Get("sync")
+	in := action.ActionInput{"message": "my work"}
+	if err := a.Validate(in); err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, s := range a.Steps(in) {
+		got = append(got, s.Args)
+	}
+	want := [][]string{{"add", "-A"}, {"commit", "-m", "my work"}, {"fetch", "origin"}, {"rebase", "origin/main"}, {"push", "origin", "main"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %q", got)
+	}
+	if left := p.Unused(); len(left) > 0 {
+		t.Errorf("%d recorded command(s) never asked for, first %q", len(left), left[0].Argv)
+	}
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	var streamed []string
+	var phases []string
+	fake := execpkg.NewReplayer([]execpkg.Call{
+		{Argv: []string{"git", "fetch", "--progress", "origin"}, Env: execpkg.NoPromptEnv,
+			Stderr: "Receiving objects:  50% (1/2)\rReceiving objects: 100% (2/2), done.\nFrom /srv/git/proj", Exit: 0},
+	})
+	rec := &execpkg.Recorder{Next: fake}
+	res, err := rec.Exec(context.Background(), execpkg.Cmd{
+		Name: "git", Args: []string{"fetch", "--progress", "origin"}, Env: execpkg.NoPromptEnv,
+		Stream:   func(line string, _ bool) { streamed = append(streamed, line) },
+		Progress: func(p execpkg.Progress) { phases = append(phases, p.Phase) },
+	})
+	if err != nil || res.Exit != 0 {
+		t.Fatalf("exit %d: %v", res.Exit, err)
+	}
+	if len(phases) != 2 || !reflect.DeepEqual(streamed, []string{"From /srv/git/proj"}) {
+		t.Errorf("progress %q, lines %q", phases, streamed)
+	}
+
+	path := filepath.Join(t.TempDir(), "fetch.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	again, err := execpkg.LoadReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := again.Exec(context.Background(), execpkg.Cmd{Name: "git", Args: []string{"fetch", "--progress", "origin"}}); err == nil {
+		t.Error("a run without the recorded env was answered")
+	}
+	res2, err := again.Exec(context.Background(), execpkg.Cmd{Name: "git", Args: []string{"fetch", "--progress", "origin"}, Env: execpkg.NoPromptEnv})
+	if err != nil || res2 != res {
+		t.Errorf("replayed %+v, %v; recorded %+v", res2, err, res)
+	}
+}
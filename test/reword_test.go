@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+	"ezgit/internal/undo"
+)
+
+func TestRewordAndUndo(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+	git(t, "init", "-q")
+	for _, f := range []string{"a", "b", "c"} {
+		os.WriteFile(f, []byte(f+"\n"), 0o644)
+		git(t, "add", f)
+		git(t, "commit", "-qm", "add "+f)
+	}
+	os.WriteFile("a", []byte("dirty\n"), 0o644)
+	before := git(t, "rev-parse", "HEAD")
+
+	for _, target := range []string{"HEAD~1", "HEAD~2"} {
+		sha := git(t, "rev-parse", target)
+		if err := repo.Reword(ctx, sha, "reworded "+target+"\n\n#123: with a body\n\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := git(t, "log", "--format=%s"); got != "add c\nreworded HEAD~1\nreworded HEAD~2" {
+		t.Fatalf("history after reword:\n%s", got)
+	}
+	if got := git(t, "log", "-1", "--format=%b", "HEAD~1"); got != "#123: with a body" {
+		t.Fatalf("body = %q", got)
+	}
+	if got := git(t, "status", "--porcelain"); got != "M a" {
+		t.Fatalf("local changes not kept: %q", got)
+	}
+
+	after := git(t, "rev-parse", "HEAD")
+	if err := undo.Record(ctx, undo.Entry{Action: "reword", Branch: repo.CurrentBranch(ctx), Before: before, After: after}); err != nil {
+		t.Fatal(err)
+	}
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, _ := r.Get("undo-rewrite")
+	in := action.ActionInput{}
+	if err := a.Validate(in); err != nil {
+		t.Fatal(err)
+	}
+	git(t, a.Steps(in)[0].Args...)
+	if got := git(t, "rev-parse", "HEAD"); got != before {
+		t.Fatal("undo-rewrite did not restore the branch")
+	}
+	if err := a.Validate(in); err == nil {
+		t.Fatal("undo-rewrite should have nothing left to undo")
+	}
+}
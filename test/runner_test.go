@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	execpkg "ezgit/internal/exec"
+)
+
+func TestRunnerSplitsCarriageReturns(t *testing.T) {
+	script := `printf 'Receiving objects:  10%% (1/10)\rReceiving objects: 100%% (10/10), done.\n'; head -c 200000 /dev/zero | tr '\0' a; printf '\nend\n'`
+	var lines []string
+	var events []execpkg.Progress
+	r := &execpkg.Runner{OnProgress: func(p execpkg.Progress) { events = append(events, p) }}
+	exit, out, _, err := r.Run(context.Background(), "sh", []string{"-c", script}, func(line string, _ bool) {
+		lines = append(lines, line)
+	}, 0)
+	if err != nil || exit != 0 {
+		t.Fatalf("exit %d: %v", exit, err)
+	}
+	if len(events) != 2 || events[0].Percent != 10 || !events[1].Done {
+		t.Errorf("progress events = %+v", events)
+	}
+	if n := len(lines); n == 0 || lines[n-1] != "end" {
+		t.Errorf("output after a long line was lost: %d lines", n)
+	}
+	if strings.Contains(out, "10%") || !strings.Contains(out, "100% (10/10), done.") {
+		t.Errorf("stdout should keep only the final meter:\n%.200s", out)
+	}
+}
+
+func TestWithProgress(t *testing.T) {
+	cases := []struct{ in, want []string }{
+		{[]string{"fetch", "origin"}, []string{"fetch", "--progress", "origin"}},
+		{[]string{"-c", "x=y", "push", "-u", "origin"}, []string{"-c", "x=y", "push", "--progress", "-u", "origin"}},
+		{[]string{"--git-dir", "fetch", "fetch", "origin"}, []string{"--git-dir", "fetch", "fetch", "--progress", "origin"}},
+		{[]string{"fetch", "--quiet"}, []string{"fetch", "--quiet"}},
+		{[]string{"status"}, []string{"status"}},
+	}
+	for _, c := range cases {
+		if got := execpkg.WithProgress(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %q", c.in, got)
+		}
+	}
+}
+
+func TestCloneReportsProgress(t *testing.T) {
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "src")
+	for i := 0; i < 5; i++ {
+		os.WriteFile(fmt.Sprintf("src/f%d", i), []byte(strings.Repeat("x", i+1)), 0o644)
+	}
+	git(t, "-C", "src", "add", ".")
+	git(t, "-C", "src", "commit", "-qm", "files")
+
+	phases := map[string]bool{}
+	r := &execpkg.Runner{OnProgress: func(p execpkg.Progress) { phases[p.Phase] = true }}
+	args := execpkg.WithProgress([]string{"clone", "--no-local", "src", "dst"})
+	if exit, _, errOut, err := r.Run(context.Background(), "git", args, nil, 0); err != nil || exit != 0 {
+		t.Fatalf("clone: %d %v %s", exit, err, errOut)
+	}
+	if !phases["Receiving objects"] {
+		t.Errorf("no Receiving objects meter, got %v", phases)
+	}
+}
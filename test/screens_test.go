@@ -0,0 +1,57 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"ezgit/internal/action"
+)
+
+// Actions with their own TUI screen still build a plain command for
+// front ends without one.
+func TestScreenActionFallbacks(t *testing.T) {
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	git(t, "add", "a.txt")
+	git(t, "commit", "-qm", "root")
+	git(t, "commit", "-q", "--allow-empty", "-m", "second")
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	cases := []struct {
+		name string
+		in   action.ActionInput
+		want string
+	}{
+		{"conflicts", nil, "diff --name-only --diff-filter=U"},
+		{"ignore", nil, "status --short --ignored"},
+		{"pull", action.ActionInput{"strategy": action.PullFastForward}, "pull --ff-only"},
+		{"pull", action.ActionInput{"strategy": action.PullMerge}, "pull --no-rebase"},
+		{"pull", action.ActionInput{"strategy": action.PullRebase}, "pull --rebase --autostash"},
+		{"split", action.ActionInput{"target": "HEAD"}, "rebase -i HEAD^"},
+	}
+	for _, c := range cases {
+		a, ok := r.Get(c.name)
+		if !ok || a.Screen == "" {
+			t.Fatalf("%s: not a screen action", c.name)
+		}
+		cmd, args, preview := a.BuildFunc(c.in)
+		if got := strings.Join(args, " "); cmd != "git" || got != c.want {
+			t.Errorf("%s %v: %s %s, want git %s", c.name, c.in, cmd, got, c.want)
+		}
+		if !strings.Contains(preview, c.want) {
+			t.Errorf("%s: preview %q does not show the command", c.name, preview)
+		}
+		if a.ValidateFunc != nil {
+			if err := a.ValidateFunc(c.in); err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			}
+		}
+	}
+	// the read-only fallbacks run as they are
+	for _, args := range [][]string{{"diff", "--name-only", "--diff-filter=U"}, {"status", "--short", "--ignored"}} {
+		git(t, args...)
+	}
+}
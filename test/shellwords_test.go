@@ -0,0 +1,47 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"ezgit/internal/parser"
+)
+
+func TestSplitArgs(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{`commit -m "fix the bug"`, []string{"commit", "-m", "fix the bug"}},
+		{`grep -n -- "TODO"`, []string{"grep", "-n", "--", "TODO"}},
+		{`log --format='%h %s'`, []string{"log", "--format=%h %s"}},
+		{`add my\ file.txt`, []string{"add", "my file.txt"}},
+		{`commit -m "say \"hi\" \n"`, []string{"commit", "-m", `say "hi" \n`}},
+		{`commit -m ''`, []string{"commit", "-m", ""}},
+		{`  status  `, []string{"status"}},
+		{``, nil},
+	}
+	for _, c := range cases {
+		got, err := parser.SplitArgs(c.in)
+		if err != nil {
+			t.Fatalf("SplitArgs(%q): %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("SplitArgs(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{`commit -m "oops`, `log --format='%h`, `add file\`} {
+		if _, err := parser.SplitArgs(bad); err == nil {
+			t.Fatalf("SplitArgs(%q): expected error", bad)
+		}
+	}
+}
+
+func TestJoinArgsRoundTrips(t *testing.T) {
+	args := []string{"commit", "-m", "it's done", "--", "a b.txt", "stash@{0}"}
+	got, err := parser.SplitArgs(parser.JoinArgs(args))
+	if err != nil || !reflect.DeepEqual(got, args) {
+		t.Fatalf("round trip = %q, %v; want %q", got, err, args)
+	}
+}
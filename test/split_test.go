@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"ezgit/internal/hunks"
+	"ezgit/internal/repo"
+)
+
+func TestSplitCommitByHunk(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+	git(t, "init", "-q")
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	os.WriteFile("a.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	git(t, "add", "a.txt")
+	git(t, "commit", "-qm", "base")
+	lines[1], lines[17] = "line two", "line eighteen"
+	os.WriteFile("a.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	git(t, "commit", "-qam", "two changes")
+	os.WriteFile("b.txt", []byte("b\n"), 0o644)
+	git(t, "add", "b.txt")
+	git(t, "commit", "-qm", "add b")
+	before := git(t, "rev-parse", "HEAD^{tree}")
+
+	todo, err := repo.Todo(ctx, "HEAD~2")
+	if err != nil || len(todo) != 2 || todo[0].Subject != "two changes" {
+		t.Fatalf("todo = %v, %v", todo, err)
+	}
+	todo[0].Action = "edit"
+	editor, err := repo.SequenceEditor(ctx, todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, append(editor, "rebase", "-i", "HEAD~2")...)
+	if !repo.RebaseInProgress(ctx) {
+		t.Fatal("rebase did not stop at the edited commit")
+	}
+	git(t, "reset", "-q", "HEAD^")
+
+	files := hunks.Parse(git(t, "diff", "--src-prefix=a/", "--dst-prefix=b/") + "\n")
+	if len(files) != 1 || len(files[0].Hunks) != 2 {
+		t.Fatalf("parsed %+v", files)
+	}
+	// the second hunk alone, then the rest
+	os.WriteFile("p.diff", []byte(hunks.Patch(files, func(f, h int) bool { return h == 1 })), 0o644)
+	git(t, "apply", "--cached", "p.diff")
+	os.Remove("p.diff")
+	git(t, "commit", "-qm", "eighteen")
+	git(t, "commit", "-qam", "two")
+	git(t, "-c", "core.editor=true", "rebase", "--continue")
+
+	if got := git(t, "log", "--format=%s"); got != "add b\ntwo\neighteen\nbase" {
+		t.Fatalf("history after split:\n%s", got)
+	}
+	if got := git(t, "rev-parse", "HEAD^{tree}"); got != before {
+		t.Fatal("split changed the final tree")
+	}
+	if got := git(t, "diff", "--stat", "HEAD~3", "HEAD~2"); !strings.Contains(got, "1 insertion(+), 1 deletion(-)") {
+		t.Fatalf("first piece should hold one hunk: %s", got)
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/ignore"
+	"ezgit/internal/repo"
+	"ezgit/internal/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseStatusZ(t *testing.T) {
+	out := " M my file, v2.txt\x00R  new.go\x00old.go\x00?? -dash\x00A  dir/x\x00"
+	files := repo.ParseStatusZ(out)
+	if len(files) != 4 {
+		t.Fatalf("got %d entries: %+v", len(files), files)
+	}
+	if files[0].Path != "my file, v2.txt" || !files[0].Unstaged() || files[0].Staged() {
+		t.Fatalf("modified entry: %+v", files[0])
+	}
+	if files[1].Path != "new.go" || files[1].Orig != "old.go" || files[1].Code() != "R " {
+		t.Fatalf("rename entry: %+v", files[1])
+	}
+	if !files[2].Untracked() || files[2].Path != "-dash" {
+		t.Fatalf("untracked entry: %+v", files[2])
+	}
+}
+
+func TestIgnoreAppend(t *testing.T) {
+	p := filepath.Join(t.TempDir(), ".gitignore")
+	if err := os.WriteFile(p, []byte("/build"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	added, err := ignore.Append(p, []string{"/build", ignore.PathPattern("logs/a*.log"), "/build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{`/logs/a\*.log`}) {
+		t.Fatalf("added %q", added)
+	}
+	b, _ := os.ReadFile(p)
+	if string(b) != "/build\n/logs/a\\*.log\n" {
+		t.Fatalf("file is %q", b)
+	}
+}
+
+func TestTrackedFilesFromSubdir(t *testing.T) {
+	top := t.TempDir()
+	t.Chdir(top)
+	git(t, "init", "-q")
+	os.MkdirAll("sub", 0o755)
+	os.WriteFile("sub/a.txt", []byte("a\n"), 0o644)
+	os.WriteFile("b.txt", []byte("b\n"), 0o644)
+	git(t, "add", ".")
+	git(t, "commit", "-qm", "root")
+	os.WriteFile("sub/a.txt", []byte("changed\n"), 0o644)
+	t.Chdir(filepath.Join(top, "sub"))
+
+	files, err := repo.TrackedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "a.txt" || files[0].Root != "sub/a.txt" || !files[0].Unstaged() {
+		t.Fatalf("tracked from sub = %+v", files)
+	}
+	if p := ignore.PathPattern(files[0].Root); p != "/sub/a.txt" {
+		t.Errorf("ignore pattern = %q", p)
+	}
+}
+
+func TestFileListSelection(t *testing.T) {
+	l := tui.NewFileList([]repo.FileStatus{{X: '?', Y: '?', Path: "a"}, {X: '?', Y: '?', Path: "b"}})
+	if sel := l.Selected(); len(sel) != 0 {
+		t.Errorf("nothing checked but Selected = %+v", sel)
+	}
+	if tg := l.Targets(); len(tg) != 1 || tg[0].Path != "a" {
+		t.Errorf("Targets falls back to the highlighted file, got %+v", tg)
+	}
+	l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if sel := l.Selected(); len(sel) != 1 || sel[0].Path != "a" {
+		t.Errorf("after checking a: %+v", sel)
+	}
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	add, _ := r.Get("add")
+	if _, args, _ := add.Build(action.ActionInput{"paths": ""}); !reflect.DeepEqual(args, []string{"add", "-A"}) {
+		t.Errorf("add with nothing picked = %q", args)
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"ezgit/internal/action"
+	"ezgit/internal/repo"
+)
+
+func TestSwitchStashesForSourceBranch(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+	git(t, "init", "-q", "-b", "main")
+	os.WriteFile("a", []byte("a\n"), 0o644)
+	git(t, "add", "a")
+	git(t, "commit", "-qm", "a")
+	git(t, "branch", "other")
+	os.WriteFile("a", []byte("edited on main\n"), 0o644)
+
+	r := action.NewRegistry()
+	action.RegisterBuiltins(r)
+	a, _ := r.Get("switch")
+	in := action.ActionInput{"branch": "other"}
+	if !a.Prompts[1].Asked(in) {
+		t.Fatal("dirty tree should ask what to do with the changes")
+	}
+	in["dirty"] = a.Prompts[1].Options[2]
+	if err := a.Validate(in); err == nil {
+		t.Fatal("abort should not run anything")
+	}
+	in["dirty"] = a.Prompts[1].Default
+	if err := a.Validate(in); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range a.Steps(in) {
+		git(t, s.Args...)
+	}
+	if repo.CurrentBranch(ctx) != "other" || repo.Dirty(ctx) {
+		t.Fatal("expected a clean switch to other")
+	}
+	if got := repo.SwitchStashes(ctx, "other"); len(got) != 0 {
+		t.Fatalf("other has no saved work: %+v", got)
+	}
+	got := repo.SwitchStashes(ctx, "main")
+	if len(got) != 1 || got[0].Ref != "stash@{0}" {
+		t.Fatalf("main stashes = %+v", got)
+	}
+	git(t, "switch", "-q", "main")
+	git(t, "stash", "pop", "-q", got[0].Ref)
+	if b, _ := os.ReadFile("a"); string(b) != "edited on main\n" {
+		t.Fatalf("restored %q", b)
+	}
+}
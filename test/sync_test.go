@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"ezgit/internal/action"
+)
+
+func TestSyncStepsAndRollback(t *testing.T) {
+	remote := t.TempDir()
+	git(t, "init", "-q", "--bare", remote)
+	t.Chdir(t.TempDir())
+	git(t, "init", "-q", "-b", "main")
+	os.WriteFile("a.txt", []byte("a\n"), 0o644)
+	git(t, "add", "a.txt")
+	git(t, "commit", "-qm", "root")
+	git(t, "remote", "add", "origin", remote)
+
+	ctx := context.Background()
+	target, err := action.ResolveSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	steps := action.SyncSteps(target, "")
+	for _, s := range steps {
+		names = append(names, s.Name)
+	}
+	if want := []string{"fetch", "push"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("clean tree without upstream: %q", names)
+	}
+	if want := []string{"push", "--set-upstream", "origin", "main"}; !reflect.DeepEqual(steps[1].Args, want) {
+		t.Fatalf("push = %q", steps[1].Args)
+	}
+	git(t, steps[1].Args...)
+
+	target, _ = action.ResolveSync(ctx)
+	before := git(t, "rev-parse", "HEAD")
+	os.WriteFile("a.txt", []byte("changed\n"), 0o644)
+	os.WriteFile("new.txt", []byte("new\n"), 0o644)
+	steps = action.SyncSteps(target, "my work")
+	names = nil
+	for _, s := range steps {
+		names = append(names, s.Name)
+	}
+	if want := []string{"stage", "commit", "fetch", "rebase", "push"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("dirty tree with upstream: %q", names)
+	}
+	git(t, steps[0].Args...)
+	git(t, steps[1].Args...)
+	committed := git(t, "rev-parse", "HEAD")
+
+	for _, s := range action.SyncRollback(ctx, before, committed) {
+		git(t, s.Args...)
+	}
+	if git(t, "rev-parse", "HEAD") != before {
+		t.Fatal("rollback did not return to the starting commit")
+	}
+	if st := git(t, "status", "--porcelain"); st != "M a.txt\n?? new.txt" {
+		t.Fatalf("rollback lost the local changes:\n%s", st)
+	}
+}
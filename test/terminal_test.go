@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	execpkg "ezgit/internal/exec"
+)
+
+func TestNeedsTerminal(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"commit"}, true},
+		{[]string{"commit", "-am", "msg"}, false},
+		{[]string{"commit", "-F", ".git/MSG"}, false},
+		{[]string{"commit", "--amend", "--no-edit"}, false},
+		{[]string{"commit", "-m", "msg", "-e"}, true},
+		{[]string{"rebase", "-i", "HEAD~3"}, true},
+		{[]string{"rebase", "origin/main"}, false},
+		{[]string{"-c", "core.editor=true", "rebase", "--continue"}, false},
+		{[]string{"-c", "core.editor=true", "commit"}, false},
+		{[]string{"-c", "core.editor=true", "add", "-p"}, true},
+		{[]string{"-c", "core.editor=:", "mergetool"}, true},
+		{[]string{"add", "-p"}, true},
+		{[]string{"add", "--", "-p"}, false},
+		{[]string{"mergetool"}, true},
+		{[]string{"bisect", "visualize"}, true},
+		{[]string{"bisect", "good"}, false},
+		{[]string{"revert", "HEAD"}, true},
+		{[]string{"revert", "--no-edit", "HEAD"}, false},
+		{[]string{"-C", "sub", "tag", "-a", "v1"}, true},
+		{[]string{"--git-dir", ".git", "commit"}, true},
+		{[]string{"--work-tree", "commit", "status"}, false},
+		{[]string{"tag", "-a", "v1", "-m", "release"}, false},
+		{[]string{"status"}, false},
+	}
+	for _, c := range cases {
+		if got := execpkg.NeedsTerminal(c.args); got != c.want {
+			t.Errorf("NeedsTerminal(%q) = %v, want %v", c.args, got, c.want)
+		}
+	}
+}